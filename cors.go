@@ -0,0 +1,152 @@
+package httpmock
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSOptions configures the preflight responses of [Mock.OnCORS].
+type CORSOptions struct {
+	// Origins that are allowed to make requests, such as
+	// "https://app.example.com". "*" allows any origin. If empty, any origin
+	// is allowed.
+	AllowedOrigins []string
+
+	// Methods that are allowed. "*" allows any method. If empty, GET, HEAD, and
+	// POST are allowed.
+	AllowedMethods []string
+
+	// Request headers that are allowed, which are compared without regard to
+	// case. "*" allows any header. If empty, no headers are allowed.
+	AllowedHeaders []string
+
+	// Whether requests may include credentials, such as cookies.
+	AllowCredentials bool
+
+	// How long the preflight response may be cached. If 0, the
+	// Access-Control-Max-Age header is not sent.
+	MaxAge time.Duration
+}
+
+// OnCORS registers an expected [Request] for CORS preflight requests to the
+// given URL, which are OPTIONS requests with Origin and
+// Access-Control-Request-Method headers, and responds to them according to
+// opts. This is useful for testing browser-style clients. Other OPTIONS
+// requests do not match, and [Request]'s registered for other methods on the
+// same URL are matched as usual.
+//
+// If the origin, the requested method, and every requested header are allowed,
+// a 204 is returned with the Access-Control-Allow-Origin,
+// Access-Control-Allow-Methods, and Access-Control-Allow-Headers headers, and
+// Access-Control-Allow-Credentials and Access-Control-Max-Age if they are
+// configured. The origin is echoed rather than "*" when
+// [CORSOptions.AllowCredentials] is set or specific origins are allowed.
+// Otherwise, a 204 is returned without them, so the client rejects the
+// preflight as a browser would.
+//
+//	Mock.OnCORS("/users", httpmock.CORSOptions{
+//		AllowedOrigins: []string{"https://app.example.com"},
+//		AllowedMethods: []string{http.MethodGet, http.MethodPost},
+//		AllowedHeaders: []string{"Content-Type"},
+//	})
+//	Mock.On(http.MethodPost, "/users", AnyBody).RespondNoContent()
+func (m *Mock) OnCORS(URL string, opts CORSOptions) *Request {
+	r := m.On(http.MethodOptions, URL, AnyBody).Matches(corsPreflightMatcher)
+	r.RespondUsing(corsPreflightWriter(opts))
+	return r
+}
+
+// corsPreflightMatcher is a [RequestMatcher] that expects a received
+// [http.Request] to be a CORS preflight request.
+func corsPreflightMatcher(received *http.Request) (output string, differences int) {
+	origin, method := received.Header.Get("Origin"), received.Header.Get("Access-Control-Request-Method")
+	if origin == "" || method == "" {
+		output = fmt.Sprintf("FAIL:  cors preflight: %s != Origin and Access-Control-Request-Method", fmtMissing)
+		differences = 1
+		return
+	}
+	output = fmt.Sprintf("PASS:  cors preflight: %s from %q", method, origin)
+	return
+}
+
+// corsPreflightWriter creates a [ResponseWriter] that responds to a CORS
+// preflight request according to opts.
+func corsPreflightWriter(opts CORSOptions) ResponseWriter {
+	methods := opts.AllowedMethods
+	if len(methods) == 0 {
+		methods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+	}
+
+	writer := func(w http.ResponseWriter, r *http.Request) (int, error) {
+		h := w.Header()
+		h.Add("Vary", "Origin")
+		h.Add("Vary", "Access-Control-Request-Method")
+		h.Add("Vary", "Access-Control-Request-Headers")
+
+		origin := r.Header.Get("Origin")
+		method := r.Header.Get("Access-Control-Request-Method")
+		var requested []string
+		for _, value := range r.Header.Values("Access-Control-Request-Headers") {
+			for _, header := range strings.Split(value, ",") {
+				if header = strings.TrimSpace(header); header != "" {
+					requested = append(requested, header)
+				}
+			}
+		}
+
+		if !corsAllowedOrigin(opts.AllowedOrigins, origin) || !corsAllowed(methods, method) {
+			w.WriteHeader(http.StatusNoContent)
+			return 0, nil
+		}
+		for _, header := range requested {
+			if !corsAllowed(opts.AllowedHeaders, header) {
+				w.WriteHeader(http.StatusNoContent)
+				return 0, nil
+			}
+		}
+
+		allowOrigin := "*"
+		if opts.AllowCredentials || (len(opts.AllowedOrigins) > 0 && !slices.Contains(opts.AllowedOrigins, "*")) {
+			allowOrigin = origin
+		}
+		h.Set("Access-Control-Allow-Origin", allowOrigin)
+
+		allowMethods := methods
+		if slices.Contains(methods, "*") {
+			allowMethods = []string{method}
+		}
+		h.Set("Access-Control-Allow-Methods", strings.Join(allowMethods, ", "))
+		if len(requested) > 0 {
+			h.Set("Access-Control-Allow-Headers", strings.Join(requested, ", "))
+		}
+		if opts.AllowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+		if opts.MaxAge > 0 {
+			h.Set("Access-Control-Max-Age", strconv.Itoa(int(opts.MaxAge.Seconds())))
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		return 0, nil
+	}
+
+	return writer
+}
+
+// corsAllowedOrigin reports whether a CORS origin is allowed. Any origin is
+// allowed if allowed is empty.
+func corsAllowedOrigin(allowed []string, origin string) bool {
+	return len(allowed) == 0 || corsAllowed(allowed, origin)
+}
+
+// corsAllowed reports whether value is in allowed, without regard to case, or
+// allowed contains "*".
+func corsAllowed(allowed []string, value string) bool {
+	return slices.ContainsFunc(allowed, func(a string) bool {
+		return a == "*" || strings.EqualFold(a, value)
+	})
+}
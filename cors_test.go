@@ -0,0 +1,191 @@
+package httpmock
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_corsPreflightMatcher(t *testing.T) {
+	tests := []struct {
+		name            string
+		header          http.Header
+		wantOutput      string
+		wantDifferences int
+	}{
+		{
+			name:            "match",
+			header:          http.Header{"Origin": []string{"https://app.example.com"}, "Access-Control-Request-Method": []string{"POST"}},
+			wantOutput:      `PASS:  cors preflight: POST from "https://app.example.com"`,
+			wantDifferences: 0,
+		},
+		{
+			name:            "missing-method",
+			header:          http.Header{"Origin": []string{"https://app.example.com"}},
+			wantOutput:      `FAIL:  cors preflight: (Missing) != Origin and Access-Control-Request-Method`,
+			wantDifferences: 1,
+		},
+		{
+			name:            "missing-origin",
+			header:          http.Header{"Access-Control-Request-Method": []string{"POST"}},
+			wantOutput:      `FAIL:  cors preflight: (Missing) != Origin and Access-Control-Request-Method`,
+			wantDifferences: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			received := &http.Request{Header: tt.header}
+
+			// Test
+			gotOutput, gotDifferences := corsPreflightMatcher(received)
+
+			// Assertions
+			assert.Equal(t, tt.wantOutput, gotOutput)
+			assert.Equal(t, tt.wantDifferences, gotDifferences)
+		})
+	}
+}
+
+func Test_corsPreflightWriter(t *testing.T) {
+	tests := []struct {
+		name           string
+		opts           CORSOptions
+		origin         string
+		method         string
+		requestHeaders string
+		wantHeader     http.Header
+	}{
+		{
+			name:   "defaults",
+			origin: "https://app.example.com",
+			method: http.MethodPost,
+			wantHeader: http.Header{
+				"Access-Control-Allow-Origin":  []string{"*"},
+				"Access-Control-Allow-Methods": []string{"GET, HEAD, POST"},
+			},
+		},
+		{
+			name: "allowed",
+			opts: CORSOptions{
+				AllowedOrigins:   []string{"https://app.example.com"},
+				AllowedMethods:   []string{http.MethodGet, http.MethodPut},
+				AllowedHeaders:   []string{"Content-Type", "X-Request-Id"},
+				AllowCredentials: true,
+				MaxAge:           10 * time.Minute,
+			},
+			origin:         "https://app.example.com",
+			method:         http.MethodPut,
+			requestHeaders: "content-type, x-request-id",
+			wantHeader: http.Header{
+				"Access-Control-Allow-Origin":      []string{"https://app.example.com"},
+				"Access-Control-Allow-Methods":     []string{"GET, PUT"},
+				"Access-Control-Allow-Headers":     []string{"content-type, x-request-id"},
+				"Access-Control-Allow-Credentials": []string{"true"},
+				"Access-Control-Max-Age":           []string{"600"},
+			},
+		},
+		{
+			name:           "wildcards",
+			opts:           CORSOptions{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"*"}, AllowedHeaders: []string{"*"}},
+			origin:         "https://app.example.com",
+			method:         http.MethodDelete,
+			requestHeaders: "Authorization",
+			wantHeader: http.Header{
+				"Access-Control-Allow-Origin":  []string{"*"},
+				"Access-Control-Allow-Methods": []string{"DELETE"},
+				"Access-Control-Allow-Headers": []string{"Authorization"},
+			},
+		},
+		{
+			name:       "origin-not-allowed",
+			opts:       CORSOptions{AllowedOrigins: []string{"https://app.example.com"}},
+			origin:     "https://evil.example.com",
+			method:     http.MethodGet,
+			wantHeader: http.Header{},
+		},
+		{
+			name:       "method-not-allowed",
+			origin:     "https://app.example.com",
+			method:     http.MethodDelete,
+			wantHeader: http.Header{},
+		},
+		{
+			name:           "header-not-allowed",
+			opts:           CORSOptions{AllowedHeaders: []string{"Content-Type"}},
+			origin:         "https://app.example.com",
+			method:         http.MethodPost,
+			requestHeaders: "Content-Type, Authorization",
+			wantHeader:     http.Header{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			req := httptest.NewRequest(http.MethodOptions, "/users", http.NoBody)
+			req.Header.Set("Origin", tt.origin)
+			req.Header.Set("Access-Control-Request-Method", tt.method)
+			if tt.requestHeaders != "" {
+				req.Header.Set("Access-Control-Request-Headers", tt.requestHeaders)
+			}
+			recorder := httptest.NewRecorder()
+
+			// Test
+			gotN, gotErr := corsPreflightWriter(tt.opts)(recorder, req)
+
+			// Assertions
+			assert.NoError(t, gotErr)
+			assert.Zero(t, gotN)
+			assert.Equal(t, http.StatusNoContent, recorder.Code)
+			got := recorder.Header().Clone()
+			assert.Equal(t, []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"}, got.Values("Vary"))
+			got.Del("Vary")
+			assert.Equal(t, tt.wantHeader, got)
+		})
+	}
+}
+
+func TestServer_OnCORS(t *testing.T) {
+	// Setup
+	s := NewServer()
+	defer s.Close()
+	s.OnCORS("/users", CORSOptions{AllowedOrigins: []string{"https://app.example.com"}})
+	s.On(http.MethodOptions, "/users", nil).Respond(http.StatusOK, nil).Header("Allow", "OPTIONS, POST")
+	s.On(http.MethodPost, "/users", AnyBody).Respond(http.StatusCreated, nil)
+
+	preflight := mustNewRequest(http.NewRequest(http.MethodOptions, s.URLf("/users"), http.NoBody))
+	preflight.Header.Set("Origin", "https://app.example.com")
+	preflight.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	options := mustNewRequest(http.NewRequest(http.MethodOptions, s.URLf("/users"), http.NoBody))
+
+	// Test
+	gotPreflight, err := s.Client().Do(preflight)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gotPreflight.Body.Close()
+	gotOptions, err := s.Client().Do(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gotOptions.Body.Close()
+	gotPost, err := s.Client().Post(s.URLf("/users"), "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gotPost.Body.Close()
+
+	// Assertions
+	assert.Equal(t, http.StatusNoContent, gotPreflight.StatusCode)
+	assert.Equal(t, "https://app.example.com", gotPreflight.Header.Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, http.StatusOK, gotOptions.StatusCode)
+	assert.Equal(t, "OPTIONS, POST", gotOptions.Header.Get("Allow"))
+	assert.Equal(t, http.StatusCreated, gotPost.StatusCode)
+	s.Mock.AssertExpectations(t)
+}
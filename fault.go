@@ -0,0 +1,90 @@
+package httpmock
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// RespondReset configures the [Request] to abruptly close the connection
+// instead of writing a response, which simulates a network failure. The
+// connection is hijacked and closed without lingering, so the client sees a
+// connection reset or an unexpected EOF. The request still counts towards
+// [Request.Times] and the request assertions.
+//
+// With [Mock.RoundTripper], the round trip fails with an error that wraps
+// [syscall.ECONNRESET]. If the connection cannot be hijacked otherwise, such as
+// with HTTP/2, a truncated response is written instead, and a warning is logged
+// to the logger set with [Server.SetLogger].
+//
+//	Mock.On(http.MethodGet, "/some/path", nil).RespondReset().Once()
+func (r *Request) RespondReset() *Response {
+	return r.RespondUsing(resetWriter)
+}
+
+// RespondTruncated specifies a response that declares a Content-Length of
+// declaredLen, but only writes body, so that the client sees a short read. It
+// is useful for testing clients that trust the Content-Length header. If body
+// is longer than declaredLen, it is cut to declaredLen, since the server will
+// not write more than the declared length.
+//
+// Since the response is incomplete, the server closes the connection after
+// writing it rather than keeping it alive for reuse, and the client sees an
+// unexpected EOF while reading the body. [Mock.RoundTripper] returns the same
+// error once the written body has been read.
+//
+//	Mock.On(http.MethodGet, "/some/path", nil).RespondTruncated(http.StatusOK, []byte(`{"id": `), 100)
+func (r *Request) RespondTruncated(statusCode int, body []byte, declaredLen int) *Response {
+	resp := newResponse(r, statusCode, body)
+	resp.truncated = true
+	resp.declaredLen = declaredLen
+
+	r.lock()
+	defer r.unlock()
+
+	r.response = resp
+
+	return resp
+}
+
+// resetWriter is a [ResponseWriter] that hijacks the connection and closes it
+// without writing a response. If the connection cannot be hijacked, it resets
+// a [resetter] instead, or falls back to writing a response that declares a
+// body but does not write it.
+func resetWriter(w http.ResponseWriter, r *http.Request) (int, error) {
+	conn, _, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		if rw, ok := w.(resetter); ok {
+			rw.reset()
+			return 0, nil
+		}
+
+		if logger := requestLogger(r); logger != nil {
+			logger.Warn("httpmock: unable to reset connection, writing a truncated response instead", "method", r.Method, "path", r.URL.Path, "error", err.Error())
+		} else {
+			fmt.Printf("httpmock: unable to reset connection, writing a truncated response instead: %v\n", err)
+		}
+
+		w.Header().Set("Content-Length", "1")
+		w.WriteHeader(http.StatusOK)
+		return 0, nil
+	}
+
+	// Discard any unsent data so that the close sends a reset
+	netConn := conn
+	if tc, ok := conn.(interface{ NetConn() net.Conn }); ok {
+		netConn = tc.NetConn()
+	}
+	if tc, ok := netConn.(*net.TCPConn); ok {
+		_ = tc.SetLinger(0)
+	}
+
+	_ = conn.Close()
+	return 0, nil
+}
+
+// resetter is implemented by a [http.ResponseWriter] that cannot be hijacked,
+// but can report a reset connection to its client in another way.
+type resetter interface {
+	reset()
+}
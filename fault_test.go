@@ -0,0 +1,182 @@
+package httpmock
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequest_RespondReset(t *testing.T) {
+	// Setup
+	r := &Request{parent: new(Mock)}
+
+	// Test
+	got := r.RespondReset()
+
+	// Assertions
+	assert.Equal(t, got, r.response)
+	assert.NotNil(t, got.writer)
+}
+
+func TestRequest_RespondTruncated(t *testing.T) {
+	// Setup
+	r := &Request{parent: new(Mock)}
+
+	// Test
+	got := r.RespondTruncated(http.StatusOK, []byte(testBody), 100)
+
+	// Assertions
+	want := &Response{
+		parent:      r,
+		header:      http.Header{},
+		statusCode:  http.StatusOK,
+		body:        []byte(testBody),
+		truncated:   true,
+		declaredLen: 100,
+	}
+	assert.Equal(t, want, got)
+	assert.Equal(t, got, r.response)
+}
+
+func TestResponse_Write_Truncated(t *testing.T) {
+	tests := []struct {
+		name              string
+		declaredLen       int
+		wantContentLength string
+		wantBody          string
+	}{
+		{
+			name:              "truncated",
+			declaredLen:       100,
+			wantContentLength: "100",
+			wantBody:          testBody,
+		},
+		{
+			name:              "cut",
+			declaredLen:       5,
+			wantContentLength: "5",
+			wantBody:          "Hello",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			r := &Request{parent: new(Mock).Test(t)}
+			response := r.RespondTruncated(http.StatusOK, []byte(testBody), tt.declaredLen).Header("X-Request-Id", "1234")
+			recorder := httptest.NewRecorder()
+
+			// Test
+			gotN, gotErr := response.Write(recorder, nil)
+
+			// Assertions
+			assert.NoError(t, gotErr)
+			assert.Equal(t, len(tt.wantBody), gotN)
+			assert.Equal(t, tt.wantContentLength, recorder.Header().Get("Content-Length"))
+			assert.Equal(t, "1234", recorder.Header().Get("X-Request-Id"))
+			assert.Equal(t, tt.wantBody, recorder.Body.String())
+		})
+	}
+}
+
+func Test_resetWriter_NotHijacker(t *testing.T) {
+	// Setup
+	recorder := httptest.NewRecorder()
+
+	// Test
+	gotN, gotErr := resetWriter(recorder, httptest.NewRequest(http.MethodGet, "/foo", http.NoBody))
+
+	// Assertions
+	assert.NoError(t, gotErr)
+	assert.Zero(t, gotN)
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "1", recorder.Header().Get("Content-Length"))
+	assert.Empty(t, recorder.Body.Bytes())
+}
+
+func Test_resetWriter_NotHijacker_Logger(t *testing.T) {
+	// Setup
+	var buf bytes.Buffer
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/foo", http.NoBody)
+	req = req.WithContext(context.WithValue(req.Context(), loggerKey{}, newTestLogger(&buf)))
+
+	// Test
+	gotN, gotErr := resetWriter(recorder, req)
+
+	// Assertions
+	assert.NoError(t, gotErr)
+	assert.Zero(t, gotN)
+	assert.Equal(t, "1", recorder.Header().Get("Content-Length"))
+	assert.Contains(t, buf.String(), `level=WARN msg="httpmock: unable to reset connection, writing a truncated response instead" method=GET path=/foo`)
+}
+
+func TestMock_Client_RespondReset(t *testing.T) {
+	// Setup
+	m := new(Mock).Test(t)
+	m.On(http.MethodGet, "/foo", nil).RespondReset().Once()
+	m.On(http.MethodGet, "/foo", nil).RespondOK([]byte(testBody))
+
+	// Test
+	_, gotErr := m.Client().Get("http://test.com/foo")
+	got, err := m.Client().Get("http://test.com/foo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer got.Body.Close()
+
+	// Assertions
+	assert.ErrorIs(t, gotErr, syscall.ECONNRESET)
+	assert.Equal(t, http.StatusOK, got.StatusCode)
+	m.AssertExpectations(t)
+	m.AssertNumberOfRequests(t, http.MethodGet, "/foo", 2)
+}
+
+func TestServer_defaultHandler_RespondReset(t *testing.T) {
+	// Setup
+	s := NewServer()
+	defer s.Close()
+	s.On(http.MethodGet, "/foo", nil).RespondReset().Once()
+	s.On(http.MethodGet, "/foo", nil).RespondOK([]byte(testBody))
+
+	// Test
+	_, gotErr := s.Client().Get(s.URL + "/foo")
+	got, err := s.Client().Get(s.URL + "/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer got.Body.Close()
+
+	// Assertions
+	assert.Error(t, gotErr)
+	assert.Equal(t, http.StatusOK, got.StatusCode)
+	s.Mock.AssertExpectations(t)
+	s.Mock.AssertNumberOfRequests(t, http.MethodGet, "/foo", 2)
+}
+
+func TestServer_defaultHandler_RespondTruncated(t *testing.T) {
+	// Setup
+	s := NewServer()
+	defer s.Close()
+	s.On(http.MethodGet, "/foo", nil).RespondTruncated(http.StatusOK, []byte(testBody), 100)
+
+	// Test
+	got, err := s.Client().Get(s.URL + "/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer got.Body.Close()
+	gotBody, gotErr := io.ReadAll(got.Body)
+
+	// Assertions
+	assert.Equal(t, http.StatusOK, got.StatusCode)
+	assert.Equal(t, int64(100), got.ContentLength)
+	assert.ErrorIs(t, gotErr, io.ErrUnexpectedEOF)
+	assert.Equal(t, testBody, string(gotBody))
+}
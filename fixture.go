@@ -0,0 +1,245 @@
+package httpmock
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var ErrLoadFixtures = errors.New("error loading fixtures")
+
+// fixture describes an expected [Request] and its [Response] in a fixture file
+// loaded by [Mock.LoadFixtures].
+type fixture struct {
+	// The HTTP method to expect. Use "httpmock.AnyMethod" to match any method.
+	Method string `json:"method" yaml:"method"`
+
+	// The URL to expect. Use "httpmock.AnyURL" to match any URL.
+	URL string `json:"url" yaml:"url"`
+
+	// The body to expect. If omitted, any body is matched.
+	Body *string `json:"body" yaml:"body"`
+
+	// Headers that must be present with the given values.
+	Headers map[string]string `json:"headers" yaml:"headers"`
+
+	// Query parameters that must be present with the given values.
+	Query map[string]string `json:"query" yaml:"query"`
+
+	// Number of times the request may be received. If omitted, it is not
+	// limited.
+	Times int `json:"times" yaml:"times"`
+
+	// The response to return.
+	Response fixtureResponse `json:"response" yaml:"response"`
+}
+
+// fixtureResponse describes the [Response] of a fixture.
+type fixtureResponse struct {
+	// The HTTP status code. If omitted, 200 is used.
+	Status int `json:"status" yaml:"status"`
+
+	// Headers to set on the response.
+	Headers map[string]string `json:"headers" yaml:"headers"`
+
+	// The response body.
+	Body string `json:"body" yaml:"body"`
+
+	// Path of a file containing the response body, relative to the fixture
+	// file. Mutually exclusive with Body.
+	BodyFile string `json:"bodyFile" yaml:"bodyFile"`
+}
+
+// LoadFixtures registers the expected [Request]'s described in a YAML or JSON
+// fixture file, which lets large suites declare their mocks in data rather than
+// code. The format is chosen by the file extension, which must be ".yaml",
+// ".yml", or ".json". The file contains a list of entries, such as:
+//
+//	# fixtures.yaml
+//	- method: GET
+//	  url: /users/1234
+//	  headers:
+//	    Accept: application/json
+//	  query:
+//	    verbose: "true"
+//	  times: 1
+//	  response:
+//	    status: 200
+//	    headers:
+//	      Content-Type: application/json
+//	    bodyFile: testdata/user.json
+//
+// The method and URL are required, and are handled the same as [Mock.On]. An
+// entry without a body matches any body. Response body files are resolved
+// relative to the fixture file, and their Content-Type is detected from their
+// contents unless a Content-Type header is given.
+//
+// Every entry is validated before any are registered. Unknown fields are an
+// error, which names the index of the entry.
+func (m *Mock) LoadFixtures(path string) error {
+	fixtures, err := readFixtures(path)
+	if err != nil {
+		return fmt.Errorf("%w %q: %v", ErrLoadFixtures, path, err)
+	}
+
+	bodies := make([][]byte, len(fixtures))
+	for i, f := range fixtures {
+		if bodies[i], err = f.validate(filepath.Dir(path)); err != nil {
+			return fmt.Errorf("%w %q: entry %d: %v", ErrLoadFixtures, path, i, err)
+		}
+	}
+
+	for i, f := range fixtures {
+		if err := f.register(m, bodies[i]); err != nil {
+			return fmt.Errorf("%w %q: entry %d: %v", ErrLoadFixtures, path, i, err)
+		}
+	}
+	return nil
+}
+
+// readFixtures decodes the entries of a fixture file, rejecting unknown fields.
+func readFixtures(path string) ([]fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var fixtures []fixture
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		var entries []json.RawMessage
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, err
+		}
+		for i, entry := range entries {
+			var f fixture
+			dec := json.NewDecoder(bytes.NewReader(entry))
+			dec.DisallowUnknownFields()
+			if err := dec.Decode(&f); err != nil {
+				return nil, fmt.Errorf("entry %d: %v", i, err)
+			}
+			fixtures = append(fixtures, f)
+		}
+	case ".yaml", ".yml":
+		var entries []yaml.Node
+		if err := yaml.Unmarshal(data, &entries); err != nil {
+			return nil, err
+		}
+		for i, entry := range entries {
+			// Nodes cannot be decoded strictly, so re-encode each entry
+			raw, err := yaml.Marshal(&entry)
+			if err != nil {
+				return nil, fmt.Errorf("entry %d: %v", i, err)
+			}
+			var f fixture
+			dec := yaml.NewDecoder(bytes.NewReader(raw))
+			dec.KnownFields(true)
+			if err := dec.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("entry %d: %v", i, err)
+			}
+			fixtures = append(fixtures, f)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported fixture file extension %q", ext)
+	}
+
+	return fixtures, nil
+}
+
+// validate checks that a fixture can be registered, and returns its response
+// body. Body files are resolved relative to dir.
+func (f fixture) validate(dir string) ([]byte, error) {
+	if f.Method == "" {
+		return nil, errors.New("method is required")
+	}
+	if f.Method != AnyMethod && !validMethod(f.Method) {
+		return nil, fmt.Errorf("invalid method %q", f.Method)
+	}
+	if f.URL == "" {
+		return nil, errors.New("url is required")
+	}
+	if _, err := url.Parse(f.URL); err != nil {
+		return nil, fmt.Errorf("failed to parse url: %v", err)
+	}
+	if f.Times < 0 {
+		return nil, fmt.Errorf("invalid times %d", f.Times)
+	}
+	if f.Response.Status != 0 && (f.Response.Status < 100 || f.Response.Status > 599) {
+		return nil, fmt.Errorf("invalid response status %d", f.Response.Status)
+	}
+
+	if f.Response.BodyFile == "" {
+		return []byte(f.Response.Body), nil
+	}
+	if f.Response.Body != "" {
+		return nil, errors.New("response body and bodyFile are mutually exclusive")
+	}
+	path := f.Response.BodyFile
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response bodyFile: %v", err)
+	}
+	return body, nil
+}
+
+// register adds the fixture to a [Mock] as an expected [Request] that responds
+// with body.
+func (f fixture) register(m *Mock, body []byte) error {
+	expectedBody := AnyBody
+	if f.Body != nil {
+		expectedBody = []byte(*f.Body)
+	}
+
+	r, err := m.OnE(f.Method, f.URL, expectedBody)
+	if err != nil {
+		return err
+	}
+	for _, key := range sortedKeys(f.Headers) {
+		r.MatchHeader(key, f.Headers[key])
+	}
+	for _, key := range sortedKeys(f.Query) {
+		r.MatchQuery(key, f.Query[key])
+	}
+	if f.Times > 0 {
+		r.Times(f.Times)
+	}
+
+	statusCode := f.Response.Status
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+	resp := r.Respond(statusCode, body)
+	for _, key := range sortedKeys(f.Response.Headers) {
+		resp.Header(key, f.Response.Headers[key])
+	}
+	if f.Response.BodyFile != "" {
+		resp.lock()
+		resp.contentType = http.DetectContentType(body)
+		resp.unlock()
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of a map in sorted order, so that matchers are
+// added in a consistent order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
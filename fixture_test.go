@@ -0,0 +1,219 @@
+package httpmock
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mustWriteFixture is a convenience test helper that writes a fixture file with
+// the given name and contents to dir, and returns its path. It fails the test
+// if an error occurs.
+func mustWriteFixture(t *testing.T, dir string, name string, contents string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("unexpected error writing test file: %v", err)
+	}
+	return path
+}
+
+func TestMock_LoadFixtures(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		contents string
+	}{
+		{
+			name:     "yaml",
+			filename: "fixtures.yaml",
+			contents: `
+- method: GET
+  url: https://test.com/users/1234
+  headers:
+    Accept: application/json
+  query:
+    verbose: "true"
+  times: 1
+  response:
+    status: 200
+    headers:
+      X-Trace: abc
+    bodyFile: user.json
+- method: POST
+  url: https://test.com/users
+  body: Hello World!
+  response:
+    status: 201
+`,
+		},
+		{
+			name:     "json",
+			filename: "fixtures.json",
+			contents: `[
+	{
+		"method": "GET",
+		"url": "https://test.com/users/1234",
+		"headers": {"Accept": "application/json"},
+		"query": {"verbose": "true"},
+		"times": 1,
+		"response": {"status": 200, "headers": {"X-Trace": "abc"}, "bodyFile": "user.json"}
+	},
+	{
+		"method": "POST",
+		"url": "https://test.com/users",
+		"body": "Hello World!",
+		"response": {"status": 201}
+	}
+]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			dir := t.TempDir()
+			mustWriteFixture(t, dir, "user.json", `{"id": 1234}`)
+			path := mustWriteFixture(t, dir, tt.filename, tt.contents)
+
+			m := new(Mock).Test(t)
+
+			get := mustNewRequest(http.NewRequest(http.MethodGet, "https://test.com/users/1234?verbose=true", http.NoBody))
+			get.Header.Set("Accept", "application/json")
+			post := mustNewRequest(http.NewRequest(http.MethodPost, "https://test.com/users", strings.NewReader(testBody)))
+
+			// Test
+			gotErr := m.LoadFixtures(path)
+
+			// Assertions
+			assert.NoError(t, gotErr)
+			assert.Len(t, m.ExpectedRequests, 2)
+
+			getRecorder := httptest.NewRecorder()
+			_, err := m.Requested(get).Write(getRecorder, get)
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusOK, getRecorder.Code)
+			assert.Equal(t, "abc", getRecorder.Header().Get("X-Trace"))
+			assert.Equal(t, "text/plain; charset=utf-8", getRecorder.Header().Get("Content-Type"))
+			assert.Equal(t, `{"id": 1234}`, getRecorder.Body.String())
+
+			postRecorder := httptest.NewRecorder()
+			_, err = m.Requested(post).Write(postRecorder, post)
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusCreated, postRecorder.Code)
+
+			m.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMock_LoadFixtures_AnyBody(t *testing.T) {
+	// Setup
+	path := mustWriteFixture(t, t.TempDir(), "fixtures.yml", `
+- method: httpmock.AnyMethod
+  url: https://test.com/users
+`)
+	m := new(Mock).Test(t)
+
+	received := mustNewRequest(http.NewRequest(http.MethodPut, "https://test.com/users", strings.NewReader(testBody)))
+
+	// Test
+	gotErr := m.LoadFixtures(path)
+
+	// Assertions
+	assert.NoError(t, gotErr)
+	gotIndex, _ := m.findExpectedRequest(received)
+	assert.Equal(t, 0, gotIndex)
+}
+
+func TestMock_LoadFixtures_Error(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		contents string
+		wantErr  string
+	}{
+		{
+			name:     "unsupported-extension",
+			filename: "fixtures.txt",
+			contents: `[]`,
+			wantErr:  `unsupported fixture file extension ".txt"`,
+		},
+		{
+			name:     "invalid-json",
+			filename: "fixtures.json",
+			contents: `{`,
+			wantErr:  `unexpected end of JSON input`,
+		},
+		{
+			name:     "unknown-field-json",
+			filename: "fixtures.json",
+			contents: `[{"method": "GET", "url": "/foo"}, {"method": "GET", "url": "/bar", "status": 200}]`,
+			wantErr:  `entry 1: json: unknown field "status"`,
+		},
+		{
+			name:     "unknown-field-yaml",
+			filename: "fixtures.yaml",
+			contents: "- method: GET\n  url: /foo\n  response:\n    code: 200\n",
+			wantErr:  `entry 0: yaml: unmarshal errors:`,
+		},
+		{
+			name:     "missing-method",
+			filename: "fixtures.yaml",
+			contents: "- url: /foo\n",
+			wantErr:  `entry 0: method is required`,
+		},
+		{
+			name:     "invalid-method",
+			filename: "fixtures.yaml",
+			contents: "- method: GET\n  url: /foo\n- method: GE T\n  url: /bar\n",
+			wantErr:  `entry 1: invalid method "GE T"`,
+		},
+		{
+			name:     "missing-url",
+			filename: "fixtures.yaml",
+			contents: "- method: GET\n",
+			wantErr:  `entry 0: url is required`,
+		},
+		{
+			name:     "invalid-status",
+			filename: "fixtures.yaml",
+			contents: "- method: GET\n  url: /foo\n  response:\n    status: 1000\n",
+			wantErr:  `entry 0: invalid response status 1000`,
+		},
+		{
+			name:     "body-and-body-file",
+			filename: "fixtures.yaml",
+			contents: "- method: GET\n  url: /foo\n  response:\n    body: foo\n    bodyFile: foo.json\n",
+			wantErr:  `entry 0: response body and bodyFile are mutually exclusive`,
+		},
+		{
+			name:     "missing-body-file",
+			filename: "fixtures.yaml",
+			contents: "- method: GET\n  url: /foo\n  response:\n    bodyFile: missing.json\n",
+			wantErr:  `entry 0: failed to read response bodyFile:`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			path := mustWriteFixture(t, t.TempDir(), tt.filename, tt.contents)
+			m := new(Mock).Test(t)
+
+			// Test
+			gotErr := m.LoadFixtures(path)
+
+			// Assertions
+			assert.ErrorIs(t, gotErr, ErrLoadFixtures)
+			assert.ErrorContains(t, gotErr, tt.wantErr)
+			assert.Empty(t, m.ExpectedRequests)
+		})
+	}
+}
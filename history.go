@@ -0,0 +1,383 @@
+package httpmock
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// RecordedCall is a snapshot of a [http.Request] received by a [Mock],
+// regardless of whether it matched an expected [Request]. The body is buffered,
+// so it may be inspected any number of times.
+type RecordedCall struct {
+	// The time that the request was received.
+	Time time.Time
+
+	// The HTTP method that was requested.
+	Method string
+
+	// The URL that was requested.
+	URL *url.URL
+
+	// The headers that were requested.
+	Header http.Header
+
+	// The body that was requested.
+	Body []byte
+
+	// Whether the request matched an expected [Request].
+	Matched bool
+
+	// The expected [Request] that the request matched, if any.
+	request *Request
+
+	// Whether the request was forwarded to an upstream server because it did
+	// not match an expected [Request].
+	passthrough bool
+}
+
+func newRecordedCall(received *http.Request, body []byte) RecordedCall {
+	u := *received.URL
+	return RecordedCall{
+		Time:   time.Now(),
+		Method: received.Method,
+		URL:    &u,
+		Header: received.Header.Clone(),
+		Body:   slices.Clone(body),
+	}
+}
+
+// clone returns a copy of a [RecordedCall], so that changes to the copy do not
+// affect the history of the [Mock].
+func (c RecordedCall) clone() RecordedCall {
+	u := *c.URL
+	c.URL = &u
+	c.Header = c.Header.Clone()
+	c.Body = slices.Clone(c.Body)
+	return c
+}
+
+// History returns every [http.Request] received by the [Mock], in the order
+// they were received. Unlike [Mock.Requests], this includes requests that did
+// not match an expected [Request].
+func (m *Mock) History() []RecordedCall {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	history := make([]RecordedCall, 0, len(m.history))
+	for _, call := range m.history {
+		history = append(history, call.clone())
+	}
+	return history
+}
+
+// CallsFor returns the received requests in [Mock.History] that matched an
+// expected [Request], in the order they were received. This includes requests
+// that received the response set with [Request.RespondExhausted]. Each
+// [RecordedCall] is a copy, so it may be inspected or modified without
+// affecting the [Mock], which allows custom assertions to be built on top of
+// it.
+//
+//	get := Mock.On(http.MethodGet, "/users/1234", nil)
+//	...
+//	for _, call := range Mock.CallsFor(get) {
+//		if call.Header.Get("Authorization") == "" {
+//			t.Errorf("request at %s was not authorized", call.Time)
+//		}
+//	}
+func (m *Mock) CallsFor(req *Request) []RecordedCall {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var calls []RecordedCall
+	for _, call := range m.history {
+		if req != nil && call.request == req {
+			calls = append(calls, call.clone())
+		}
+	}
+	return calls
+}
+
+// recordCall adds a received request to the history and wakes any callers of
+// [Mock.WaitForCalls]. It returns the index of the call in the history. The
+// mutex of the [Mock] must be held.
+func (m *Mock) recordCall(call RecordedCall) int {
+	m.history = append(m.history, call)
+	if m.historyChanged != nil {
+		close(m.historyChanged)
+		m.historyChanged = nil
+	}
+	return len(m.history) - 1
+}
+
+// WaitForCalls blocks until at least n requests have been received, according
+// to [Mock.History], or until the timeout elapses. It returns whether n requests
+// were received. This counts every received request, whether it matched an
+// expected [Request] or not. It is useful when the code being tested sends
+// requests from background goroutines.
+//
+//	go client.SyncAll()
+//	if !Mock.WaitForCalls(3, time.Second) {
+//		t.Fatal("timed out waiting for requests")
+//	}
+func (m *Mock) WaitForCalls(n int, timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		m.mutex.Lock()
+		if len(m.history) >= n {
+			m.mutex.Unlock()
+			return true
+		}
+		if m.historyChanged == nil {
+			m.historyChanged = make(chan struct{})
+		}
+		changed := m.historyChanged
+		m.mutex.Unlock()
+
+		select {
+		case <-changed:
+		case <-timer.C:
+			return false
+		}
+	}
+}
+
+// Calls returns the received requests in [Mock.History] that match a method
+// and URL pattern. If method is empty or [AnyMethod], requests with any method
+// are returned. The pattern uses the [http.ServeMux] syntax, so wildcards like
+// "/users/{id}" and subtrees like "/static/" are supported.
+//
+//	Mock.Calls(http.MethodGet, "/users/{id}")
+func (m *Mock) Calls(method string, urlPattern string) []RecordedCall {
+	calls, err := filterCalls(m.History(), method, urlPattern)
+	if err != nil {
+		m.fail("failed to parse url pattern %q. Error: %v\n", urlPattern, err)
+		return nil
+	}
+
+	return calls
+}
+
+// AssertCalled asserts that a request matching the method and URL pattern was
+// received, according to [Mock.History]. The method and pattern are handled the
+// same as in [Mock.Calls]. On failure, every received method and URL is listed.
+//
+//	Mock.AssertCalled(t, http.MethodGet, "/users/{id}")
+func (m *Mock) AssertCalled(t mock.TestingT, method string, urlPattern string) bool {
+	if th, ok := t.(tHelper); ok {
+		th.Helper()
+	}
+
+	history := m.History()
+	calls, err := filterCalls(history, method, urlPattern)
+	if err != nil {
+		t.Errorf("FAIL: unable to parse url pattern %q: %v", urlPattern, err)
+		t.FailNow()
+	}
+
+	if len(calls) == 0 {
+		return assert.Fail(
+			t,
+			"Should have been called with the given constraints",
+			fmt.Sprintf("Expected to have been called with\n\t%s %s\nbut the received requests were\n%s", method, urlPattern, formatCalls(history)),
+		)
+	}
+	return true
+}
+
+// AssertNotCalled asserts that no request matching the method and URL pattern
+// was received, according to [Mock.History]. The method and pattern are
+// handled the same as in [Mock.Calls]. On failure, the matching methods and
+// URLs are listed.
+//
+//	Mock.AssertNotCalled(t, http.MethodDelete, "/users/{id}")
+func (m *Mock) AssertNotCalled(t mock.TestingT, method string, urlPattern string) bool {
+	if th, ok := t.(tHelper); ok {
+		th.Helper()
+	}
+
+	calls, err := filterCalls(m.History(), method, urlPattern)
+	if err != nil {
+		t.Errorf("FAIL: unable to parse url pattern %q: %v", urlPattern, err)
+		t.FailNow()
+	}
+
+	if len(calls) > 0 {
+		return assert.Fail(
+			t,
+			"Should not have been called with the given constraints",
+			fmt.Sprintf("Expected not to have been called with\n\t%s %s\nbut it was called by\n%s", method, urlPattern, formatCalls(calls)),
+		)
+	}
+	return true
+}
+
+// AssertCallOrder asserts that requests matching each pattern in sequence were
+// received in that relative order, according to [Mock.History]. Other requests
+// may be received before, between, or after them. Each pattern uses the
+// [http.ServeMux] syntax, as in [Mock.Calls], and may be prefixed with a method,
+// like "POST /users/{id}". On failure, every received method and URL is listed.
+//
+// Unlike [Mock.InOrder], the order is only checked after the fact, so requests
+// that are received out of order are still matched and responded to.
+//
+//	Mock.AssertCallOrder(t, "POST /login", "GET /users/{id}", "POST /logout")
+func (m *Mock) AssertCallOrder(t mock.TestingT, sequence ...string) bool {
+	if th, ok := t.(tHelper); ok {
+		th.Helper()
+	}
+
+	muxes := make([]*http.ServeMux, 0, len(sequence))
+	for _, pattern := range sequence {
+		mux, err := newPatternMux(pattern)
+		if err != nil {
+			t.Errorf("FAIL: unable to parse url pattern %q: %v", pattern, err)
+			t.FailNow()
+		}
+		muxes = append(muxes, mux)
+	}
+
+	history := m.History()
+	var next int
+	for _, call := range history {
+		if next == len(muxes) {
+			break
+		}
+		if patternMatches(muxes[next], call.Method, call.URL) {
+			next++
+		}
+	}
+
+	if next < len(muxes) {
+		expected := make([]string, 0, len(sequence))
+		for _, pattern := range sequence {
+			expected = append(expected, "\t"+pattern)
+		}
+		return assert.Fail(
+			t,
+			"Should have been called in the given order",
+			fmt.Sprintf("Expected to have been called in order\n%s\nbut %q was not called after the requests before it. The received requests were\n%s", strings.Join(expected, "\n"), sequence[next], formatCalls(history)),
+		)
+	}
+	return true
+}
+
+// AssertNoUnexpectedCalls asserts that every request in [Mock.History] matched
+// an expected [Request], or was forwarded with [Mock.Passthrough] or
+// [Mock.Record]. Requests that received the response set with
+// [Mock.RespondDefault] are unexpected, so this catches extra requests that the
+// default response would otherwise hide. On failure, the method, URL, and a
+// preview of the body of each unexpected request are listed.
+//
+//	defer Mock.AssertNoUnexpectedCalls(t)
+func (m *Mock) AssertNoUnexpectedCalls(t mock.TestingT) bool {
+	if th, ok := t.(tHelper); ok {
+		th.Helper()
+	}
+
+	var unexpected []string
+	for _, call := range m.History() {
+		if call.Matched || call.passthrough {
+			continue
+		}
+		unexpected = append(unexpected, fmt.Sprintf("\t%s %s %s", call.Method, call.URL, previewBody(call.Body)))
+	}
+
+	if len(unexpected) > 0 {
+		return assert.Fail(
+			t,
+			"Should not have received unexpected requests",
+			fmt.Sprintf("Received %d request(s) that did not match an expected request\n%s", len(unexpected), strings.Join(unexpected, "\n")),
+		)
+	}
+	return true
+}
+
+// filterCalls returns the calls that match a method and URL pattern. If method
+// is empty or [AnyMethod], calls with any method are returned.
+func filterCalls(history []RecordedCall, method string, urlPattern string) ([]RecordedCall, error) {
+	mux, err := newPatternMux(urlPattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var calls []RecordedCall
+	for _, call := range history {
+		if method != "" && method != AnyMethod && call.Method != method {
+			continue
+		}
+		if patternMatches(mux, call.Method, call.URL) {
+			calls = append(calls, call)
+		}
+	}
+
+	return calls, nil
+}
+
+// formatCalls formats the method and URL of each call on its own indented line.
+func formatCalls(calls []RecordedCall) string {
+	if len(calls) == 0 {
+		return "\t(None)"
+	}
+
+	lines := make([]string, 0, len(calls))
+	for _, call := range calls {
+		lines = append(lines, fmt.Sprintf("\t%s %s", call.Method, call.URL))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// newPatternMux creates a [http.ServeMux] with a single pattern registered,
+// such as "/users/{id}" or "GET /users/{id}", so that requests can be checked
+// against it with [patternMatches].
+func newPatternMux(pattern string) (mux *http.ServeMux, err error) {
+	mux = http.NewServeMux()
+
+	// ServeMux panics on invalid patterns
+	defer func() {
+		if rc := recover(); rc != nil {
+			mux = nil
+			err = fmt.Errorf("%v", rc)
+		}
+	}()
+	mux.HandleFunc(pattern, func(_ http.ResponseWriter, r *http.Request) {
+		if matched, ok := r.Context().Value(patternMatchedKey{}).(*bool); ok {
+			*matched = true
+		}
+	})
+
+	return mux, nil
+}
+
+// patternMatchedKey is the context key of the flag that the handler of a mux
+// created with [newPatternMux] sets when its pattern matches.
+type patternMatchedKey struct{}
+
+// patternMatches reports whether a method and URL match the pattern registered
+// in a mux created with [newPatternMux]. The request is dispatched to the
+// registered handler, rather than looked up with [http.ServeMux.Handler], since
+// that also reports the pattern of a redirect, such as from "/users" to
+// "/users/", which is not a match.
+func patternMatches(mux *http.ServeMux, method string, u *url.URL) bool {
+	target := *u
+	if target.Path == "" {
+		// A server receives an empty path as "/"
+		target.Path = "/"
+	}
+
+	var matched bool
+	ctx := context.WithValue(context.Background(), patternMatchedKey{}, &matched)
+	req := (&http.Request{Method: method, URL: &target, Host: u.Host, Header: http.Header{}}).WithContext(ctx)
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+	return matched
+}
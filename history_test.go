@@ -0,0 +1,592 @@
+package httpmock
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_newRecordedCall(t *testing.T) {
+	// Setup
+	received := mustNewRequest(http.NewRequest(http.MethodPost, "https://test.com/foo?limit=1", http.NoBody))
+	received.Header.Set("X-Request-Id", "1234")
+	body := []byte(testBody)
+
+	// Test
+	got := newRecordedCall(received, body)
+
+	// Assertions
+	assert.WithinDuration(t, time.Now(), got.Time, time.Second)
+	want := RecordedCall{
+		Time:   got.Time,
+		Method: http.MethodPost,
+		URL: &url.URL{
+			Scheme:   "https",
+			Host:     "test.com",
+			Path:     "/foo",
+			RawQuery: "limit=1",
+		},
+		Header: http.Header{"X-Request-Id": []string{"1234"}},
+		Body:   []byte(testBody),
+	}
+	assert.Equal(t, want, got)
+
+	// Mutating the received request should not affect the recorded call
+	received.URL.Path = "/bar"
+	received.Header.Set("X-Request-Id", "5678")
+	body[0] = 'J'
+	assert.Equal(t, want, got)
+}
+
+func TestMock_History(t *testing.T) {
+	// Setup
+	m := new(Mock)
+	m.On(http.MethodGet, "/foo/1234", nil).RespondOK(nil)
+	m.RespondDefault(http.StatusNotFound, nil)
+
+	// Test
+	m.Requested(mustNewRequest(http.NewRequest(http.MethodGet, "/foo/1234", http.NoBody)))
+	m.Requested(mustNewRequest(http.NewRequest(http.MethodPost, "/foo", strings.NewReader(testBody))))
+	got := m.History()
+
+	// Assertions
+	assert.Len(t, got, 2)
+	assert.Equal(t, http.MethodGet, got[0].Method)
+	assert.Equal(t, "/foo/1234", got[0].URL.String())
+	assert.Empty(t, got[0].Body)
+	assert.Equal(t, http.MethodPost, got[1].Method)
+	assert.Equal(t, "/foo", got[1].URL.String())
+	assert.Equal(t, []byte(testBody), got[1].Body)
+	assert.Len(t, m.Requests, 1)
+}
+
+func TestMock_History_Unexpected(t *testing.T) {
+	// Setup
+	m := new(Mock)
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("Did not expect to get here")
+		}
+		// Assertions
+		got := m.History()
+		assert.Len(t, got, 1)
+		assert.Equal(t, http.MethodDelete, got[0].Method)
+	}()
+
+	// Test
+	m.Requested(mustNewRequest(http.NewRequest(http.MethodDelete, "/foo/1234", http.NoBody)))
+}
+
+func TestMock_History_Copy(t *testing.T) {
+	// Setup
+	m := new(Mock)
+	m.On(http.MethodPost, "/foo", AnyBody).RespondOK(nil)
+	m.Requested(mustNewRequest(http.NewRequest(http.MethodPost, "/foo", strings.NewReader(testBody))))
+
+	// Test
+	got := m.History()
+	got[0].URL.Path = "/bar"
+	got[0].Header.Set("X-Foo", "bar")
+	got[0].Body[0] = 'J'
+
+	// Assertions
+	gotAgain := m.History()
+	assert.Equal(t, "/foo", gotAgain[0].URL.Path)
+	assert.Empty(t, gotAgain[0].Header)
+	assert.Equal(t, testBody, string(gotAgain[0].Body))
+}
+
+func TestMock_CallsFor(t *testing.T) {
+	// Setup
+	m := new(Mock)
+	foo := m.On(http.MethodPost, "/foo", AnyBody).Once()
+	foo.RespondOK(nil)
+	foo.RespondExhausted(http.StatusGone, nil)
+	bar := m.On(http.MethodGet, "/bar", nil)
+	bar.RespondOK(nil)
+	unused := m.On(http.MethodGet, "/unused", nil)
+	m.RespondDefault(http.StatusNotFound, nil)
+
+	first := mustNewRequest(http.NewRequest(http.MethodPost, "/foo", strings.NewReader(testBody)))
+	first.Header.Set("X-Request-Id", "1234")
+	second := mustNewRequest(http.NewRequest(http.MethodPost, "/foo", strings.NewReader("second")))
+
+	m.Requested(first)
+	m.Requested(mustNewRequest(http.NewRequest(http.MethodGet, "/bar", http.NoBody)))
+	m.Requested(mustNewRequest(http.NewRequest(http.MethodGet, "/baz", http.NoBody)))
+	m.Requested(second)
+
+	// Test
+	got := m.CallsFor(foo)
+
+	// Assertions
+	assert.Len(t, got, 2)
+	assert.Equal(t, "1234", got[0].Header.Get("X-Request-Id"))
+	assert.Equal(t, testBody, string(got[0].Body))
+	assert.Equal(t, "second", string(got[1].Body))
+	assert.False(t, got[1].Time.Before(got[0].Time))
+	assert.Len(t, m.CallsFor(bar), 1)
+	assert.Empty(t, m.CallsFor(unused))
+	assert.Empty(t, m.CallsFor(nil))
+
+	// Modifying a call does not affect the history
+	got[0].Body[0] = 'J'
+	assert.Equal(t, testBody, string(m.CallsFor(foo)[0].Body))
+}
+
+func TestMock_WaitForCalls(t *testing.T) {
+	// Setup
+	m := new(Mock)
+	m.On(http.MethodGet, "/foo", nil).RespondOK(nil)
+	m.RespondDefault(http.StatusNotFound, nil)
+
+	// Test
+	go func() {
+		m.Requested(mustNewRequest(http.NewRequest(http.MethodGet, "/foo", http.NoBody)))
+		m.Requested(mustNewRequest(http.NewRequest(http.MethodGet, "/bar", http.NoBody)))
+	}()
+	got := m.WaitForCalls(2, 5*time.Second)
+
+	// Assertions
+	assert.True(t, got)
+	assert.Len(t, m.History(), 2)
+}
+
+func TestMock_WaitForCalls_Timeout(t *testing.T) {
+	// Setup
+	m := new(Mock)
+	m.RespondDefault(http.StatusNotFound, nil)
+	m.Requested(mustNewRequest(http.NewRequest(http.MethodGet, "/foo", http.NoBody)))
+
+	// Test
+	got := m.WaitForCalls(2, 10*time.Millisecond)
+
+	// Assertions
+	assert.False(t, got)
+	assert.True(t, m.WaitForCalls(1, 0))
+}
+
+func TestMock_Calls_BadPattern(t *testing.T) {
+	// Setup
+	var successfulCallsCall int
+
+	mockT := new(MockTestingT)
+	m := new(Mock).Test(mockT)
+	m.On(http.MethodGet, "/foo/1234", nil)
+	m.Requested(mustNewRequest(http.NewRequest(http.MethodGet, "/foo/1234", http.NoBody)))
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("Did not expect to get here")
+		}
+		// Assertions
+		assert.Equal(t, "FailNow was called", r.(string))
+		assert.Equal(t, 1, mockT.failNowCount)
+		assert.Zero(t, successfulCallsCall)
+	}()
+
+	// Test
+	m.Calls(http.MethodGet, "/foo/{id")
+	successfulCallsCall++
+}
+
+func TestMock_Calls(t *testing.T) {
+	// Setup
+	m := new(Mock).Test(t)
+	m.RespondDefault(http.StatusOK, nil)
+	m.Requested(mustNewRequest(http.NewRequest(http.MethodGet, "/users/1234", http.NoBody)))
+	m.Requested(mustNewRequest(http.NewRequest(http.MethodDelete, "/users/1234", http.NoBody)))
+	m.Requested(mustNewRequest(http.NewRequest(http.MethodGet, "/users/5678?verbose=true", http.NoBody)))
+	m.Requested(mustNewRequest(http.NewRequest(http.MethodGet, "/users/1234/groups", http.NoBody)))
+	m.Requested(mustNewRequest(http.NewRequest(http.MethodGet, "/static/js/app.js", http.NoBody)))
+
+	tests := []struct {
+		name     string
+		method   string
+		pattern  string
+		wantURLs []string
+	}{
+		{
+			name:     "exact",
+			method:   http.MethodGet,
+			pattern:  "/users/1234",
+			wantURLs: []string{"/users/1234"},
+		},
+		{
+			name:     "wildcard",
+			method:   http.MethodGet,
+			pattern:  "/users/{id}",
+			wantURLs: []string{"/users/1234", "/users/5678?verbose=true"},
+		},
+		{
+			name:     "any-method",
+			method:   AnyMethod,
+			pattern:  "/users/{id}",
+			wantURLs: []string{"/users/1234", "/users/1234", "/users/5678?verbose=true"},
+		},
+		{
+			name:     "empty-method",
+			method:   "",
+			pattern:  "/users/1234",
+			wantURLs: []string{"/users/1234", "/users/1234"},
+		},
+		{
+			name:     "subtree",
+			method:   http.MethodGet,
+			pattern:  "/static/",
+			wantURLs: []string{"/static/js/app.js"},
+		},
+		{
+			name:    "no-match",
+			method:  http.MethodPost,
+			pattern: "/users/{id}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Test
+			got := m.Calls(tt.method, tt.pattern)
+
+			// Assertions
+			var gotURLs []string
+			for _, call := range got {
+				gotURLs = append(gotURLs, call.URL.String())
+			}
+			assert.Equal(t, tt.wantURLs, gotURLs)
+		})
+	}
+}
+
+func TestMock_AssertCalled_BadPattern(t *testing.T) {
+	// Setup
+	var successfulAssertCalledCall int
+
+	mockT := new(MockTestingT)
+	m := new(Mock).Test(mockT)
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("Did not expect to get here")
+		}
+		// Assertions
+		assert.Equal(t, "FailNow was called", r.(string))
+		assert.Equal(t, 1, mockT.errorfCount)
+		assert.Equal(t, 1, mockT.failNowCount)
+		assert.Zero(t, successfulAssertCalledCall)
+	}()
+
+	// Test
+	m.AssertCalled(mockT, http.MethodGet, "/foo/{id")
+	successfulAssertCalledCall++
+}
+
+func TestMock_AssertCalled(t *testing.T) {
+	// Setup
+	m := new(Mock)
+	m.RespondDefault(http.StatusOK, nil)
+	m.Requested(mustNewRequest(http.NewRequest(http.MethodGet, "/users/1234", http.NoBody)))
+	m.Requested(mustNewRequest(http.NewRequest(http.MethodPost, "/users", strings.NewReader(testBody))))
+
+	tests := []struct {
+		name    string
+		method  string
+		pattern string
+		want    bool
+	}{
+		{
+			name:    "exact",
+			method:  http.MethodPost,
+			pattern: "/users",
+			want:    true,
+		},
+		{
+			name:    "wildcard",
+			method:  http.MethodGet,
+			pattern: "/users/{id}",
+			want:    true,
+		},
+		{
+			name:    "any-method",
+			method:  AnyMethod,
+			pattern: "/users/{id}",
+			want:    true,
+		},
+		{
+			name:    "wrong-method",
+			method:  http.MethodDelete,
+			pattern: "/users/{id}",
+			want:    false,
+		},
+		{
+			name:    "wrong-path",
+			method:  http.MethodGet,
+			pattern: "/groups/{id}",
+			want:    false,
+		},
+		{
+			name:    "trailing-slash-redirect",
+			method:  http.MethodPost,
+			pattern: "/users/",
+			want:    false,
+		},
+		{
+			name:    "trailing-slash-wildcard-redirect",
+			method:  http.MethodPost,
+			pattern: "/users/{path...}",
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockT := new(MockTestingT)
+
+			// Test
+			gotCalled := m.AssertCalled(mockT, tt.method, tt.pattern)
+			gotNotCalled := m.AssertNotCalled(mockT, tt.method, tt.pattern)
+
+			// Assertions
+			assert.Equal(t, tt.want, gotCalled)
+			assert.Equal(t, !tt.want, gotNotCalled)
+			assert.Equal(t, 1, mockT.errorfCount)
+		})
+	}
+}
+
+func TestMock_AssertNoUnexpectedCalls(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(m *Mock)
+		requests []*http.Request
+		want     bool
+	}{
+		{
+			name: "matched",
+			setup: func(m *Mock) {
+				m.On(http.MethodGet, "/foo", nil).RespondOK(nil).Once().RespondExhausted(http.StatusTooManyRequests, nil)
+			},
+			requests: []*http.Request{
+				mustNewRequest(http.NewRequest(http.MethodGet, "/foo", http.NoBody)),
+				mustNewRequest(http.NewRequest(http.MethodGet, "/foo", http.NoBody)),
+			},
+			want: true,
+		},
+		{
+			name: "passthrough",
+			setup: func(m *Mock) {
+				m.Passthrough("https://test.com")
+			},
+			requests: []*http.Request{
+				mustNewRequest(http.NewRequest(http.MethodGet, "/foo", http.NoBody)),
+			},
+			want: true,
+		},
+		{
+			name: "default",
+			setup: func(m *Mock) {
+				m.On(http.MethodGet, "/foo", nil).RespondOK(nil)
+				m.RespondDefault(http.StatusNotFound, nil)
+			},
+			requests: []*http.Request{
+				mustNewRequest(http.NewRequest(http.MethodGet, "/foo", http.NoBody)),
+				mustNewRequest(http.NewRequest(http.MethodPost, "/bar", strings.NewReader(testBody))),
+			},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			m := new(Mock)
+			tt.setup(m)
+			for _, req := range tt.requests {
+				m.Requested(req)
+			}
+			mockT := new(MockTestingT)
+
+			// Test
+			got := m.AssertNoUnexpectedCalls(mockT)
+
+			// Assertions
+			assert.Equal(t, tt.want, got)
+			if tt.want {
+				assert.Zero(t, mockT.errorfCount)
+			} else {
+				assert.Equal(t, 1, mockT.errorfCount)
+			}
+		})
+	}
+}
+
+func TestMock_AssertNoUnexpectedCalls_Unexpected(t *testing.T) {
+	// Setup
+	m := new(Mock)
+
+	func() {
+		defer func() {
+			_ = recover()
+		}()
+		m.Requested(mustNewRequest(http.NewRequest(http.MethodDelete, "/foo/1234", http.NoBody)))
+	}()
+
+	// Test
+	got := m.AssertNoUnexpectedCalls(new(MockTestingT))
+
+	// Assertions
+	assert.False(t, got)
+	assert.False(t, m.History()[0].Matched)
+}
+
+func TestMock_AssertNotCalled_BadPattern(t *testing.T) {
+	// Setup
+	var successfulAssertNotCalledCall int
+
+	mockT := new(MockTestingT)
+	m := new(Mock).Test(mockT)
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("Did not expect to get here")
+		}
+		// Assertions
+		assert.Equal(t, "FailNow was called", r.(string))
+		assert.Equal(t, 1, mockT.errorfCount)
+		assert.Equal(t, 1, mockT.failNowCount)
+		assert.Zero(t, successfulAssertNotCalledCall)
+	}()
+
+	// Test
+	m.AssertNotCalled(mockT, http.MethodGet, "/foo/{id")
+	successfulAssertNotCalledCall++
+}
+
+func TestMock_AssertCallOrder(t *testing.T) {
+	// Setup
+	m := new(Mock)
+	m.RespondDefault(http.StatusOK, nil)
+	m.Requested(mustNewRequest(http.NewRequest(http.MethodPost, "/login", strings.NewReader(testBody))))
+	m.Requested(mustNewRequest(http.NewRequest(http.MethodGet, "/health", http.NoBody)))
+	m.Requested(mustNewRequest(http.NewRequest(http.MethodGet, "/users/1234", http.NoBody)))
+	m.Requested(mustNewRequest(http.NewRequest(http.MethodPost, "/logout", http.NoBody)))
+
+	tests := []struct {
+		name     string
+		sequence []string
+		want     bool
+	}{
+		{
+			name: "empty",
+			want: true,
+		},
+		{
+			name:     "in-order",
+			sequence: []string{"POST /login", "GET /users/{id}", "POST /logout"},
+			want:     true,
+		},
+		{
+			name:     "in-order-without-method",
+			sequence: []string{"/login", "/logout"},
+			want:     true,
+		},
+		{
+			name:     "out-of-order",
+			sequence: []string{"POST /logout", "POST /login"},
+			want:     false,
+		},
+		{
+			name:     "wrong-method",
+			sequence: []string{"GET /login", "POST /logout"},
+			want:     false,
+		},
+		{
+			name:     "missing",
+			sequence: []string{"POST /login", "DELETE /users/{id}"},
+			want:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			mockT := new(MockTestingT)
+
+			// Test
+			got := m.AssertCallOrder(mockT, tt.sequence...)
+
+			// Assertions
+			assert.Equal(t, tt.want, got)
+			if tt.want {
+				assert.Equal(t, 0, mockT.errorfCount)
+			} else {
+				assert.Equal(t, 1, mockT.errorfCount)
+			}
+		})
+	}
+}
+
+func TestMock_AssertCallOrder_BadPattern(t *testing.T) {
+	// Setup
+	var successfulAssertCallOrderCall int
+
+	mockT := new(MockTestingT)
+	m := new(Mock).Test(mockT)
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("Did not expect to get here")
+		}
+		// Assertions
+		assert.Equal(t, "FailNow was called", r.(string))
+		assert.Equal(t, 1, mockT.errorfCount)
+		assert.Equal(t, 1, mockT.failNowCount)
+		assert.Zero(t, successfulAssertCallOrderCall)
+	}()
+
+	// Test
+	m.AssertCallOrder(mockT, "GET /foo", "GET /foo/{id")
+	successfulAssertCallOrderCall++
+}
+
+func Test_formatCalls(t *testing.T) {
+	tests := []struct {
+		name  string
+		calls []RecordedCall
+		want  string
+	}{
+		{
+			name: "none",
+			want: "\t(None)",
+		},
+		{
+			name: "calls",
+			calls: []RecordedCall{
+				{Method: http.MethodGet, URL: &url.URL{Path: "/users/1234"}},
+				{Method: http.MethodPost, URL: &url.URL{Path: "/users", RawQuery: "verbose=true"}},
+			},
+			want: "\tGET /users/1234\n\tPOST /users?verbose=true",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Test
+			got := formatCalls(tt.calls)
+
+			// Assertions
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,1500 @@
+package httpmock
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// headerMatcher creates a [RequestMatcher] that expects a received
+// [http.Request] to have a header with the given value.
+func headerMatcher(key string, value string) RequestMatcher {
+	key = textproto.CanonicalMIMEHeaderKey(key)
+
+	fn := func(received *http.Request) (output string, differences int) {
+		if _, ok := received.Header[key]; !ok {
+			output = fmt.Sprintf("FAIL:  header %s: %s != %q", key, fmtMissing, value)
+			differences = 1
+			return
+		}
+		actual := received.Header.Get(key)
+		if actual != value {
+			output = fmt.Sprintf("FAIL:  header %s: %q != %q", key, actual, value)
+			differences = 1
+			return
+		}
+		output = fmt.Sprintf("PASS:  header %s: %q == %q", key, actual, value)
+		return
+	}
+
+	return fn
+}
+
+// MatchHeader adds a [RequestMatcher] to the [Request] which expects a
+// received [http.Request] to have a header with the given value. The header
+// key is canonicalized, so "content-type" and "Content-Type" are equivalent.
+// Multiple calls must all match.
+//
+//	Mock.On(http.MethodGet, "/some/path", nil).MatchHeader("Content-Type", "application/json")
+func (r *Request) MatchHeader(key string, value string) *Request {
+	return r.Matches(headerMatcher(key, value))
+}
+
+// headerContainsMatcher creates a [RequestMatcher] that expects a received
+// [http.Request] to have a header, where any of the header's values equal the
+// given value.
+func headerContainsMatcher(key string, value string) RequestMatcher {
+	key = textproto.CanonicalMIMEHeaderKey(key)
+
+	fn := func(received *http.Request) (output string, differences int) {
+		actual, ok := received.Header[key]
+		if !ok {
+			output = fmt.Sprintf("FAIL:  header %s: %s != %q", key, fmtMissing, value)
+			differences = 1
+			return
+		}
+		if !slices.Contains(actual, value) {
+			output = fmt.Sprintf("FAIL:  header %s: %q != %q", key, actual, value)
+			differences = 1
+			return
+		}
+		output = fmt.Sprintf("PASS:  header %s: %q == %q", key, actual, value)
+		return
+	}
+
+	return fn
+}
+
+// headerValuesMatcher creates a [RequestMatcher] that expects a received
+// [http.Request] to have a header whose values exactly equal the given values,
+// in order.
+func headerValuesMatcher(key string, values []string) RequestMatcher {
+	key = textproto.CanonicalMIMEHeaderKey(key)
+
+	fn := func(received *http.Request) (output string, differences int) {
+		actual, ok := received.Header[key]
+		if !ok {
+			output = fmt.Sprintf("FAIL:  header %s: %s != %q", key, fmtMissing, values)
+			differences = 1
+			return
+		}
+		if !slices.Equal(actual, values) {
+			output = fmt.Sprintf("FAIL:  header %s: %q != %q", key, actual, values)
+			differences = 1
+			return
+		}
+		output = fmt.Sprintf("PASS:  header %s: %q == %q", key, actual, values)
+		return
+	}
+
+	return fn
+}
+
+// MatchHeaderContains adds a [RequestMatcher] to the [Request] which expects
+// a received [http.Request] to have a header where any of its values equal the
+// given value, such as when a header is sent on several lines. Each line is
+// compared as a whole, so comma-separated values are not split. The header key
+// is canonicalized.
+//
+//	Mock.On(http.MethodGet, "/some/path", nil).MatchHeaderContains("X-Forwarded-For", "10.0.0.1")
+func (r *Request) MatchHeaderContains(key string, value string) *Request {
+	return r.Matches(headerContainsMatcher(key, value))
+}
+
+// MatchHeaderValues adds a [RequestMatcher] to the [Request] which expects a
+// received [http.Request] to have a header whose values exactly equal the given
+// values, in order. The header key is canonicalized.
+//
+//	Mock.On(http.MethodGet, "/some/path", nil).MatchHeaderValues("X-Forwarded-For", []string{"10.0.0.1", "10.0.0.2"})
+func (r *Request) MatchHeaderValues(key string, values []string) *Request {
+	return r.Matches(headerValuesMatcher(key, values))
+}
+
+// contentTypeMatcher creates a [RequestMatcher] that expects a received
+// [http.Request] to have a Content-Type with the given media type and
+// parameters. Parameters that are not given are ignored.
+func contentTypeMatcher(mediaType string, params map[string]string) RequestMatcher {
+	mediaType = strings.ToLower(mediaType)
+	lowerParams := make(map[string]string, len(params))
+	for k, v := range params {
+		lowerParams[strings.ToLower(k)] = v
+	}
+	expected := mime.FormatMediaType(mediaType, lowerParams)
+	if expected == "" {
+		expected = mediaType
+	}
+
+	fn := func(received *http.Request) (output string, differences int) {
+		header := received.Header.Get("Content-Type")
+		if header == "" {
+			output = fmt.Sprintf("FAIL:  content type: %s != %q", fmtMissing, expected)
+			differences = 1
+			return
+		}
+		actualType, actualParams, err := mime.ParseMediaType(header)
+		if err != nil {
+			output = fmt.Sprintf("FAIL:  content type: %q (%v) != %q", header, err, expected)
+			differences = 1
+			return
+		}
+
+		// Only compare the parameters that are expected
+		comparedParams := make(map[string]string, len(lowerParams))
+		for k := range lowerParams {
+			if v, ok := actualParams[k]; ok {
+				comparedParams[k] = v
+			}
+		}
+		actual := mime.FormatMediaType(actualType, comparedParams)
+		if actual == "" {
+			actual = actualType
+		}
+
+		if actualType != mediaType || !maps.Equal(comparedParams, lowerParams) {
+			output = fmt.Sprintf("FAIL:  content type: %q != %q", actual, expected)
+			differences = 1
+			return
+		}
+		output = fmt.Sprintf("PASS:  content type: %q == %q", actual, expected)
+		return
+	}
+
+	return fn
+}
+
+// MatchContentType adds a [RequestMatcher] to the [Request] which expects a
+// received [http.Request] to have a Content-Type with the given media type.
+// Parameters, such as charset, are ignored, and the media type is compared
+// case-insensitively. A request without a Content-Type does not match.
+//
+//	Mock.On(http.MethodPost, "/some/path", AnyBody).MatchContentType("application/json")
+func (r *Request) MatchContentType(mediaType string) *Request {
+	return r.Matches(contentTypeMatcher(mediaType, nil))
+}
+
+// MatchContentTypeParams adds a [RequestMatcher] to the [Request] which
+// expects a received [http.Request] to have a Content-Type with the given media
+// type and parameters. Parameters that are not given are ignored, and parameter
+// names are compared case-insensitively.
+//
+//	Mock.On(http.MethodPost, "/some/path", AnyBody).MatchContentTypeParams("text/plain", map[string]string{"charset": "utf-8"})
+func (r *Request) MatchContentTypeParams(mediaType string, params map[string]string) *Request {
+	return r.Matches(contentTypeMatcher(mediaType, params))
+}
+
+// funcMatcher creates a [RequestMatcher] that expects a function to report that
+// a received [http.Request] matches. The diagnostics name the function by its
+// source location.
+func funcMatcher(match func(*http.Request) bool) RequestMatcher {
+	location := funcLocation(match)
+
+	fn := func(received *http.Request) (output string, differences int) {
+		if !match(received) {
+			output = fmt.Sprintf("FAIL:  func %s: false != true", location)
+			differences = 1
+			return
+		}
+		output = fmt.Sprintf("PASS:  func %s: true == true", location)
+		return
+	}
+
+	return fn
+}
+
+// funcLocation returns the file and line where a function is defined, or its
+// name if the location is unknown.
+func funcLocation(fn any) string {
+	f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer())
+	if f == nil {
+		return "(Unknown)"
+	}
+	file, line := f.FileLine(f.Entry())
+	if file == "" {
+		return f.Name()
+	}
+	return fmt.Sprintf("%s:%d", filepath.Base(file), line)
+}
+
+// MatchFunc adds a [RequestMatcher] to the [Request] which expects a function
+// to return true for a received [http.Request]. It is a simpler alternative to
+// [Request.Matches] for one-off checks. Multiple calls must all match, and they
+// are run after the method, URL, and body are compared. When the function
+// returns false, the diff names it by its source location.
+//
+// The function should read the body with [ReadBody], so that it is not
+// consumed. It must not call methods on the [Mock], since it is run while the
+// [Mock] is locked. If it panics, the panic is handled by the [Server] as
+// configured with [Server.NotRecoverable].
+//
+//	Mock.On(http.MethodPost, "/some/path", AnyBody).MatchFunc(func(r *http.Request) bool {
+//		return bytes.Contains(httpmock.ReadBody(r), []byte("foo"))
+//	})
+func (r *Request) MatchFunc(fn func(r *http.Request) bool) *Request {
+	return r.Matches(funcMatcher(fn))
+}
+
+// hostMatcher creates a [RequestMatcher] that expects a received
+// [http.Request] to have the given host.
+func hostMatcher(host string) RequestMatcher {
+	fn := func(received *http.Request) (output string, differences int) {
+		if received.Host == "" {
+			output = fmt.Sprintf("FAIL:  host: %s != %q", fmtMissing, host)
+			differences = 1
+			return
+		}
+		if !strings.EqualFold(received.Host, host) {
+			output = fmt.Sprintf("FAIL:  host: %q != %q", received.Host, host)
+			differences = 1
+			return
+		}
+		output = fmt.Sprintf("PASS:  host: %q == %q", received.Host, host)
+		return
+	}
+
+	return fn
+}
+
+// MatchHost adds a [RequestMatcher] to the [Request] which expects a received
+// [http.Request] to have the given host, as in [http.Request.Host]. This is the
+// Host header sent by the client, which may differ from the address it
+// connected to, and may include a port. It is compared case-insensitively.
+//
+//	Mock.On(http.MethodGet, "/some/path", nil).MatchHost("api.example.com")
+func (r *Request) MatchHost(host string) *Request {
+	return r.Matches(hostMatcher(host))
+}
+
+// rawURLMatcher creates a [RequestMatcher] that expects a received
+// [http.Request] to have the given request-target, including its query.
+func rawURLMatcher(raw string) RequestMatcher {
+	fn := func(received *http.Request) (output string, differences int) {
+		// Server requests have the unmodified request-target, but client
+		// requests must be re-encoded from their URL
+		actual := received.RequestURI
+		if actual == "" && received.URL != nil {
+			actual = received.URL.RequestURI()
+		}
+
+		if actual != raw {
+			output = fmt.Sprintf("FAIL:  raw url: %q != %q", actual, raw)
+			differences = 1
+			return
+		}
+		output = fmt.Sprintf("PASS:  raw url: %q == %q", actual, raw)
+		return
+	}
+
+	return fn
+}
+
+// MatchRawURL adds a [RequestMatcher] to the [Request] which expects a received
+// [http.Request] to have exactly the given request-target, as in
+// [http.Request.RequestURI]. Unlike the URL passed to [Mock.On], the path and
+// query are compared as sent, including their encoding, which is useful for
+// testing clients that encode query values in non-standard ways.
+//
+//	Mock.On(http.MethodGet, "/search", nil).MatchRawURL("/search?q=a%20b&sort=asc")
+func (r *Request) MatchRawURL(raw string) *Request {
+	return r.Matches(rawURLMatcher(raw))
+}
+
+// methodsMatcher creates a [RequestMatcher] that expects a received
+// [http.Request] to have one of the given methods.
+func methodsMatcher(methods []string) RequestMatcher {
+	fn := func(received *http.Request) (output string, differences int) {
+		actual, _ := diffMissing(received.Method)
+		if !slices.Contains(methods, received.Method) {
+			output = fmt.Sprintf("FAIL:  method: %s != one of %q", actual, methods)
+			differences = 1
+			return
+		}
+		output = fmt.Sprintf("PASS:  method: %s == one of %q", actual, methods)
+		return
+	}
+
+	return fn
+}
+
+// MatchMethods adds a [RequestMatcher] to the [Request] which expects a
+// received [http.Request] to have one of the given methods, so that one
+// [Request] and its [Response] may cover several methods. Methods are compared
+// case-sensitively. Since [Mock.On] also compares the method, use
+// [Mock.OnAny] to match on the methods alone. A [Request] registered for a
+// specific method with [Mock.On] takes precedence, as with any [Mock.OnAny]
+// [Request].
+//
+//	Mock.OnAny("/some/path/1234", AnyBody).MatchMethods(http.MethodPut, http.MethodPatch)
+func (r *Request) MatchMethods(methods ...string) *Request {
+	return r.Matches(methodsMatcher(slices.Clone(methods)))
+}
+
+// methodRegexMatcher creates a [RequestMatcher] that expects a received
+// [http.Request] to have a method that matches the given regular expression,
+// which must be anchored to match the whole method.
+func methodRegexMatcher(re *regexp.Regexp, pattern string) RequestMatcher {
+	fn := func(received *http.Request) (output string, differences int) {
+		actual, _ := diffMissing(received.Method)
+		if !re.MatchString(received.Method) {
+			output = fmt.Sprintf("FAIL:  method regex: %s != %q", actual, pattern)
+			differences = 1
+			return
+		}
+		output = fmt.Sprintf("PASS:  method regex: %s == %q", actual, pattern)
+		return
+	}
+
+	return fn
+}
+
+// MatchMethodRegex adds a [RequestMatcher] to the [Request] which expects a
+// received [http.Request] to have a method that matches the given regular
+// expression. The expression must match the whole method, so "PUT|PATCH" does
+// not match "PUTS". The pattern is compiled immediately, and the test fails if
+// it is invalid. Like [Request.MatchMethods], use [Mock.OnAny] to match on the
+// regular expression alone.
+//
+//	Mock.OnAny("/some/path/1234", AnyBody).MatchMethodRegex(`PUT|PATCH`)
+func (r *Request) MatchMethodRegex(pattern string) *Request {
+	re, err := regexp.Compile(`^(?:` + pattern + `)$`)
+	if err != nil {
+		r.parent.fail("failed to compile method regex %q for request %s. Error: %v\n", pattern, r.summary(), err)
+	}
+
+	return r.Matches(methodRegexMatcher(re, pattern))
+}
+
+// queryMatcher creates a [RequestMatcher] that expects a received
+// [http.Request] to have a query parameter, where any of the parameter's values
+// equal the given value.
+func queryMatcher(key string, value string) RequestMatcher {
+	fn := func(received *http.Request) (output string, differences int) {
+		query := received.URL.Query()
+		actual, ok := query[key]
+		if !ok {
+			output = fmt.Sprintf("FAIL:  query %s: %s ((%s)) != %q", key, fmtMissing, query.Encode(), value)
+			differences = 1
+			return
+		}
+		if !slices.Contains(actual, value) {
+			output = fmt.Sprintf("FAIL:  query %s: %q ((%s)) != %q", key, actual, query.Encode(), value)
+			differences = 1
+			return
+		}
+		output = fmt.Sprintf("PASS:  query %s: %q == %q", key, actual, value)
+		return
+	}
+
+	return fn
+}
+
+// queryValuesMatcher creates a [RequestMatcher] that expects a received
+// [http.Request] to have a query parameter whose values exactly equal the
+// given values, in order.
+func queryValuesMatcher(key string, values []string) RequestMatcher {
+	fn := func(received *http.Request) (output string, differences int) {
+		query := received.URL.Query()
+		actual, ok := query[key]
+		if !ok {
+			output = fmt.Sprintf("FAIL:  query %s: %s ((%s)) != %q", key, fmtMissing, query.Encode(), values)
+			differences = 1
+			return
+		}
+		if !slices.Equal(actual, values) {
+			output = fmt.Sprintf("FAIL:  query %s: %q ((%s)) != %q", key, actual, query.Encode(), values)
+			differences = 1
+			return
+		}
+		output = fmt.Sprintf("PASS:  query %s: %q == %q", key, actual, values)
+		return
+	}
+
+	return fn
+}
+
+// MatchQuery adds a [RequestMatcher] to the [Request] which expects a received
+// [http.Request] to have a query parameter with the given value. If the
+// parameter is repeated, any of its values may match. Query parameters that
+// are not named by a matcher are ignored.
+//
+//	Mock.On(http.MethodGet, "/search", nil).MatchQuery("q", "foo")
+func (r *Request) MatchQuery(key string, value string) *Request {
+	return r.Matches(queryMatcher(key, value))
+}
+
+// MatchQueryValues adds a [RequestMatcher] to the [Request] which expects a
+// received [http.Request] to have a query parameter whose values exactly equal
+// the given values, in order.
+//
+//	Mock.On(http.MethodGet, "/search", nil).MatchQueryValues("tag", []string{"foo", "bar"})
+func (r *Request) MatchQueryValues(key string, values []string) *Request {
+	return r.Matches(queryValuesMatcher(key, values))
+}
+
+// queryRegexMatcher creates a [RequestMatcher] that expects a received
+// [http.Request] to have a query parameter whose first value matches the given
+// regular expression.
+func queryRegexMatcher(key string, re *regexp.Regexp) RequestMatcher {
+	fn := func(received *http.Request) (output string, differences int) {
+		query := received.URL.Query()
+		if !query.Has(key) {
+			output = fmt.Sprintf("FAIL:  query regex %s: %s ((%s)) != %q", key, fmtMissing, query.Encode(), re.String())
+			differences = 1
+			return
+		}
+		actual := query.Get(key)
+		if !re.MatchString(actual) {
+			output = fmt.Sprintf("FAIL:  query regex %s: %q != %q", key, actual, re.String())
+			differences = 1
+			return
+		}
+		output = fmt.Sprintf("PASS:  query regex %s: %q == %q", key, actual, re.String())
+		return
+	}
+
+	return fn
+}
+
+// MatchQueryRegex adds a [RequestMatcher] to the [Request] which expects a
+// received [http.Request] to have a query parameter whose first value matches
+// the given regular expression, which is useful for values such as timestamps
+// and nonces. A missing parameter does not match. The pattern is compiled
+// immediately, and the test fails if it is invalid.
+//
+//	Mock.On(http.MethodGet, "/some/path", nil).MatchQueryRegex("cacheBust", `^\d+$`)
+func (r *Request) MatchQueryRegex(key string, pattern string) *Request {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		r.parent.fail("failed to compile query regex %q for request %s. Error: %v\n", pattern, r.summary(), err)
+	}
+
+	return r.Matches(queryRegexMatcher(key, re))
+}
+
+// jsonBodyMatcher creates a [RequestMatcher] that expects a received
+// [http.Request] to have a JSON body that is semantically equal to the given
+// JSON document. Key ordering and insignificant whitespace are ignored.
+func jsonBodyMatcher(expectedBody []byte) (RequestMatcher, error) {
+	var expected any
+	if err := json.Unmarshal(expectedBody, &expected); err != nil {
+		return nil, err
+	}
+
+	fn := func(received *http.Request) (output string, differences int) {
+		body, err := SafeReadBody(received)
+		if err != nil {
+			output = fmt.Sprintf("FAIL:  json body: %v", err)
+			differences = 1
+			return
+		}
+
+		var actual any
+		if err := json.Unmarshal(body, &actual); err != nil {
+			output = fmt.Sprintf("FAIL:  json body: (%d) %s is not valid JSON: %v", len(body), trimBody(body), err)
+			differences = 1
+			return
+		}
+
+		if !cmp.Equal(actual, expected) {
+			output = fmt.Sprintf("FAIL:  json body: (%d) %s != (%d) %s", len(body), trimBody(body), len(expectedBody), trimBody(expectedBody))
+			differences = 1
+			return
+		}
+		output = fmt.Sprintf("PASS:  json body: (%d) %s == (%d) %s", len(body), trimBody(body), len(expectedBody), trimBody(expectedBody))
+		return
+	}
+
+	return fn, nil
+}
+
+// MatchJSONBody adds a [RequestMatcher] to the [Request] which expects a
+// received [http.Request] to have a JSON body that is semantically equal to v.
+// Key ordering and insignificant whitespace are ignored, but differences in
+// values or types are not. If v is a []byte or [json.RawMessage], it is used
+// as the expected JSON document; otherwise, v is marshaled to JSON.
+//
+// Since the body is also compared by [Mock.On], the expected body should
+// usually be [AnyBody].
+//
+//	Mock.On(http.MethodPost, "/some/path", httpmock.AnyBody).MatchJSONBody(map[string]any{"foo": "bar"})
+func (r *Request) MatchJSONBody(v any) *Request {
+	var expectedBody []byte
+	switch b := v.(type) {
+	case []byte:
+		expectedBody = b
+	case json.RawMessage:
+		expectedBody = b
+	default:
+		var err error
+		if expectedBody, err = json.Marshal(v); err != nil {
+			r.parent.fail("failed to marshal expected JSON body for request %s. Error: %v\n", r.summary(), err)
+		}
+	}
+
+	fn, err := jsonBodyMatcher(expectedBody)
+	if err != nil {
+		r.parent.fail("failed to decode expected JSON body for request %s. Error: %v\n", r.summary(), err)
+	}
+
+	return r.Matches(fn)
+}
+
+// jsonSchemaMatcher creates a [RequestMatcher] that expects a received
+// [http.Request] to have a JSON body that is valid against the given JSON
+// Schema.
+func jsonSchemaMatcher(schema []byte) (RequestMatcher, error) {
+	compiled, err := jsonschema.CompileString("schema.json", string(schema))
+	if err != nil {
+		return nil, err
+	}
+
+	fn := func(received *http.Request) (output string, differences int) {
+		body, err := SafeReadBody(received)
+		if err != nil {
+			output = fmt.Sprintf("FAIL:  json schema: %v", err)
+			differences = 1
+			return
+		}
+
+		// Numbers are decoded precisely, as the validator expects
+		var actual any
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		if err := decoder.Decode(&actual); err != nil {
+			output = fmt.Sprintf("FAIL:  json schema: (%d) %s is not valid JSON: %v", len(body), trimBody(body), err)
+			differences = 1
+			return
+		}
+
+		if err := compiled.Validate(actual); err != nil {
+			output = fmt.Sprintf("FAIL:  json schema: (%d) %s does not match schema: %s", len(body), trimBody(body), schemaErrors(err))
+			differences = 1
+			return
+		}
+		output = fmt.Sprintf("PASS:  json schema: (%d) %s matches schema", len(body), trimBody(body))
+		return
+	}
+
+	return fn, nil
+}
+
+// schemaErrors formats the specific errors of a JSON Schema validation error,
+// each with the location of the invalid value, sorted by location.
+func schemaErrors(err error) string {
+	var ve *jsonschema.ValidationError
+	if !errors.As(err, &ve) {
+		return err.Error()
+	}
+
+	var messages []string
+	var collect func(ve *jsonschema.ValidationError)
+	collect = func(ve *jsonschema.ValidationError) {
+		if len(ve.Causes) == 0 {
+			messages = append(messages, fmt.Sprintf("at %q: %s", ve.InstanceLocation, ve.Message))
+			return
+		}
+		for _, cause := range ve.Causes {
+			collect(cause)
+		}
+	}
+	collect(ve)
+	slices.Sort(messages)
+
+	return strings.Join(messages, "; ")
+}
+
+// MatchJSONSchema adds a [RequestMatcher] to the [Request] which expects a
+// received [http.Request] to have a JSON body that is valid against the given
+// JSON Schema, which is useful for contract testing. The schema's "$schema"
+// keyword selects the draft, and the latest draft is used if it is missing. The
+// diagnostic of a mismatch lists each schema error with the location of the
+// invalid value. The schema is compiled immediately, and the test fails if it
+// is invalid.
+//
+// Since the body is also compared by [Mock.On], the expected body should
+// usually be [AnyBody].
+//
+//	Mock.On(http.MethodPost, "/users", httpmock.AnyBody).MatchJSONSchema([]byte(`{
+//		"type": "object",
+//		"required": ["name"],
+//		"properties": {"name": {"type": "string"}}
+//	}`))
+func (r *Request) MatchJSONSchema(schema []byte) *Request {
+	fn, err := jsonSchemaMatcher(schema)
+	if err != nil {
+		r.parent.fail("failed to compile JSON schema for request %s. Error: %v\n", r.summary(), err)
+	}
+
+	return r.Matches(fn)
+}
+
+// parseJSONPath splits a dotted path, such as "user.addresses[0].zip", into
+// its segments. Array indices may be given in brackets or as dotted segments,
+// as in "user.addresses.0.zip".
+func parseJSONPath(path string) ([]string, error) {
+	if path == "" {
+		return nil, errors.New("path is empty")
+	}
+
+	var segments []string
+	for _, part := range strings.Split(path, ".") {
+		key, rest, _ := strings.Cut(part, "[")
+		if key != "" {
+			segments = append(segments, key)
+		} else if rest == "" {
+			return nil, fmt.Errorf("path %q has an empty segment", path)
+		}
+		for rest != "" {
+			index, after, ok := strings.Cut(rest, "]")
+			if _, err := strconv.Atoi(index); !ok || err != nil {
+				return nil, fmt.Errorf("path %q has an invalid array index %q", path, index)
+			}
+			segments = append(segments, index)
+			if after == "" {
+				break
+			}
+			if !strings.HasPrefix(after, "[") {
+				return nil, fmt.Errorf("path %q has an invalid segment %q", path, part)
+			}
+			rest = after[1:]
+		}
+	}
+	return segments, nil
+}
+
+// lookupJSONPath finds the value at a path in an unmarshaled JSON document, and
+// reports whether it exists.
+func lookupJSONPath(v any, segments []string) (any, bool) {
+	for _, segment := range segments {
+		switch node := v.(type) {
+		case map[string]any:
+			var ok bool
+			if v, ok = node[segment]; !ok {
+				return nil, false
+			}
+		case []any:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			v = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// jsonFieldMatcher creates a [RequestMatcher] that expects a received
+// [http.Request] to have a JSON body with the given value at a path. The
+// expected value must be the JSON document of the value.
+func jsonFieldMatcher(path string, expectedValue []byte) (RequestMatcher, error) {
+	segments, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+	var expected any
+	if err := json.Unmarshal(expectedValue, &expected); err != nil {
+		return nil, err
+	}
+
+	fn := func(received *http.Request) (output string, differences int) {
+		body, err := SafeReadBody(received)
+		if err != nil {
+			output = fmt.Sprintf("FAIL:  json field %s: %v", path, err)
+			differences = 1
+			return
+		}
+
+		var doc any
+		if err := json.Unmarshal(body, &doc); err != nil {
+			output = fmt.Sprintf("FAIL:  json field %s: (%d) %s is not valid JSON: %v", path, len(body), trimBody(body), err)
+			differences = 1
+			return
+		}
+
+		actual, ok := lookupJSONPath(doc, segments)
+		if !ok {
+			output = fmt.Sprintf("FAIL:  json field %s: %s != %s", path, fmtMissing, expectedValue)
+			differences = 1
+			return
+		}
+		// The value was unmarshaled from JSON, so it can always be marshaled
+		actualValue, _ := json.Marshal(actual)
+
+		if !cmp.Equal(actual, expected) {
+			output = fmt.Sprintf("FAIL:  json field %s: %s != %s", path, actualValue, expectedValue)
+			differences = 1
+			return
+		}
+		output = fmt.Sprintf("PASS:  json field %s: %s == %s", path, actualValue, expectedValue)
+		return
+	}
+
+	return fn, nil
+}
+
+// MatchJSONField adds a [RequestMatcher] to the [Request] which expects a
+// received [http.Request] to have a JSON body with a value at the given path
+// that is semantically equal to expected, regardless of the rest of the body.
+// The path is made of object keys separated by dots, and array indices in
+// brackets or as dotted segments, such as "user.addresses[0].zip" or
+// "user.addresses.0.zip". Like [Request.MatchJSONBody], expected is marshaled
+// to JSON before comparing, so 5 matches 5.0, but differences in types are not
+// ignored. If expected is a []byte or [json.RawMessage], it is used as the
+// expected JSON document. A missing path does not match.
+//
+// Since the body is also compared by [Mock.On], the expected body should
+// usually be [AnyBody].
+//
+//	Mock.On(http.MethodPost, "/users", AnyBody).MatchJSONField("user.address.zip", "12345")
+func (r *Request) MatchJSONField(path string, expected any) *Request {
+	var expectedValue []byte
+	switch b := expected.(type) {
+	case []byte:
+		expectedValue = b
+	case json.RawMessage:
+		expectedValue = b
+	default:
+		var err error
+		if expectedValue, err = json.Marshal(expected); err != nil {
+			r.parent.fail("failed to marshal expected JSON field %q for request %s. Error: %v\n", path, r.summary(), err)
+		}
+	}
+
+	fn, err := jsonFieldMatcher(path, expectedValue)
+	if err != nil {
+		r.parent.fail("failed to create JSON field matcher %q for request %s. Error: %v\n", path, r.summary(), err)
+	}
+
+	return r.Matches(fn)
+}
+
+// parseForm parses the form values of a received [http.Request] without
+// consuming its body. It returns the combined form values, as with
+// [http.Request.Form], as well as the body-only form values, as with
+// [http.Request.PostForm].
+func parseForm(received *http.Request) (url.Values, url.Values, error) {
+	body, err := SafeReadBody(received)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Parse a copy of the request so that the received request's body and
+	// form fields are left untouched for other matchers
+	tempRequest := &http.Request{
+		Method: received.Method,
+		URL:    received.URL,
+		Header: received.Header,
+		Body:   io.NopCloser(bytes.NewReader(body)),
+	}
+	if err := tempRequest.ParseForm(); err != nil {
+		return nil, nil, err
+	}
+
+	return tempRequest.Form, tempRequest.PostForm, nil
+}
+
+// formMatcher creates a [RequestMatcher] that expects a received
+// [http.Request] to have a form field with the given value. If postOnly is
+// set, only the form values in the body are considered, as with
+// [http.Request.PostFormValue]. Otherwise, both query and body form values are
+// considered, as with [http.Request.FormValue].
+func formMatcher(key string, value string, postOnly bool) RequestMatcher {
+	name := "form"
+	if postOnly {
+		name = "post-form"
+	}
+
+	fn := func(received *http.Request) (output string, differences int) {
+		form, postForm, err := parseForm(received)
+		if err != nil {
+			output = fmt.Sprintf("FAIL:  %s %s: %v", name, key, err)
+			differences = 1
+			return
+		}
+		if postOnly {
+			form = postForm
+		}
+
+		if _, ok := form[key]; !ok {
+			output = fmt.Sprintf("FAIL:  %s %s: %s ((%s)) != %q", name, key, fmtMissing, form.Encode(), value)
+			differences = 1
+			return
+		}
+		actual := form.Get(key)
+		if actual != value {
+			output = fmt.Sprintf("FAIL:  %s %s: %q != %q", name, key, actual, value)
+			differences = 1
+			return
+		}
+		output = fmt.Sprintf("PASS:  %s %s: %q == %q", name, key, actual, value)
+		return
+	}
+
+	return fn
+}
+
+// MatchForm adds a [RequestMatcher] to the [Request] which expects a received
+// [http.Request] to have a form field with the given value. Both query
+// parameters and application/x-www-form-urlencoded body values are considered,
+// following the semantics of [http.Request.FormValue]. Form fields that are not
+// named by a matcher are ignored.
+//
+// Since the body is also compared by [Mock.On], the expected body should
+// usually be [AnyBody].
+//
+//	Mock.On(http.MethodPost, "/login", httpmock.AnyBody).MatchForm("username", "foo")
+func (r *Request) MatchForm(key string, value string) *Request {
+	return r.Matches(formMatcher(key, value, false))
+}
+
+// MatchPostForm adds a [RequestMatcher] to the [Request] which expects a
+// received [http.Request] to have an application/x-www-form-urlencoded body
+// field with the given value, following the semantics of
+// [http.Request.PostFormValue]. Query parameters are ignored.
+//
+//	Mock.On(http.MethodPost, "/login", httpmock.AnyBody).MatchPostForm("username", "foo")
+func (r *Request) MatchPostForm(key string, value string) *Request {
+	return r.Matches(formMatcher(key, value, true))
+}
+
+// cookieMatcher creates a [RequestMatcher] that expects a received
+// [http.Request] to have a cookie with the given value.
+func cookieMatcher(name string, value string) RequestMatcher {
+	fn := func(received *http.Request) (output string, differences int) {
+		cookie, err := received.Cookie(name)
+		if err != nil {
+			output = fmt.Sprintf("FAIL:  cookie %s: %s != %q", name, fmtMissing, value)
+			differences = 1
+			return
+		}
+		if cookie.Value != value {
+			output = fmt.Sprintf("FAIL:  cookie %s: %q != %q", name, cookie.Value, value)
+			differences = 1
+			return
+		}
+		output = fmt.Sprintf("PASS:  cookie %s: %q == %q", name, cookie.Value, value)
+		return
+	}
+
+	return fn
+}
+
+// MatchCookie adds a [RequestMatcher] to the [Request] which expects a
+// received [http.Request] to have a cookie with the given value. Multiple
+// calls must all match.
+//
+//	Mock.On(http.MethodGet, "/profile", nil).MatchCookie("session", "1234")
+func (r *Request) MatchCookie(name string, value string) *Request {
+	return r.Matches(cookieMatcher(name, value))
+}
+
+// clientCertCNMatcher creates a [RequestMatcher] that expects a received
+// [http.Request] to have been made over TLS with a client certificate whose
+// leaf has the given Common Name.
+func clientCertCNMatcher(commonName string) RequestMatcher {
+	fn := func(received *http.Request) (output string, differences int) {
+		if received.TLS == nil {
+			output = fmt.Sprintf("FAIL:  client certificate CN: (Not TLS) != %q", commonName)
+			differences = 1
+			return
+		}
+		if len(received.TLS.PeerCertificates) == 0 {
+			output = fmt.Sprintf("FAIL:  client certificate CN: (No Certificate) != %q", commonName)
+			differences = 1
+			return
+		}
+		actual := received.TLS.PeerCertificates[0].Subject.CommonName
+		if actual != commonName {
+			output = fmt.Sprintf("FAIL:  client certificate CN: %q != %q", actual, commonName)
+			differences = 1
+			return
+		}
+		output = fmt.Sprintf("PASS:  client certificate CN: %q == %q", actual, commonName)
+		return
+	}
+
+	return fn
+}
+
+// MatchClientCertCN adds a [RequestMatcher] to the [Request] which expects a
+// received [http.Request] to have been made over TLS with a client certificate
+// whose Common Name equals the given value. Requests that were not made over
+// TLS, or did not present a client certificate, do not match.
+//
+// The server must request client certificates for them to be presented, such
+// as by setting ClientAuth in [ServerConfig.TLSConfig].
+//
+//	Mock.On(http.MethodGet, "/some/path", nil).MatchClientCertCN("billing-service")
+func (r *Request) MatchClientCertCN(commonName string) *Request {
+	return r.Matches(clientCertCNMatcher(commonName))
+}
+
+// serverNameMatcher creates a [RequestMatcher] that expects a received
+// [http.Request] to have been made over TLS with the given server name
+// indication (SNI).
+func serverNameMatcher(name string) RequestMatcher {
+	fn := func(received *http.Request) (output string, differences int) {
+		if received.TLS == nil {
+			output = fmt.Sprintf("FAIL:  server name: (Not TLS) != %q", name)
+			differences = 1
+			return
+		}
+		actual, ok := diffMissing(received.TLS.ServerName)
+		if ok {
+			actual = strconv.Quote(actual)
+		}
+		if received.TLS.ServerName != name {
+			output = fmt.Sprintf("FAIL:  server name: %s != %q", actual, name)
+			differences = 1
+			return
+		}
+		output = fmt.Sprintf("PASS:  server name: %s == %q", actual, name)
+		return
+	}
+
+	return fn
+}
+
+// MatchServerName adds a [RequestMatcher] to the [Request] which expects a
+// received [http.Request] to have been made over TLS with the given server name
+// indication (SNI), which is useful for testing virtual-hosted TLS clients. The
+// names are compared exactly. Requests that were not made over TLS, or did not
+// send a server name, such as when connecting to an IP address, do not match.
+//
+// The server must use TLS, such as with [ServerConfig.TLS] or
+// [Server.StartTLS], and the client must trust a certificate for the name.
+//
+//	Mock.On(http.MethodGet, "/some/path", nil).MatchServerName("tenant-a.example.com")
+func (r *Request) MatchServerName(name string) *Request {
+	return r.Matches(serverNameMatcher(name))
+}
+
+// remoteAddrMatcher creates a [RequestMatcher] that expects a received
+// [http.Request] to have been sent from the given network address.
+func remoteAddrMatcher(addr string) RequestMatcher {
+	fn := func(received *http.Request) (output string, differences int) {
+		if received.RemoteAddr == "" {
+			output = fmt.Sprintf("FAIL:  remote addr: %s != %q", fmtMissing, addr)
+			differences = 1
+			return
+		}
+		if received.RemoteAddr != addr {
+			output = fmt.Sprintf("FAIL:  remote addr: %q != %q", received.RemoteAddr, addr)
+			differences = 1
+			return
+		}
+		output = fmt.Sprintf("PASS:  remote addr: %q == %q", received.RemoteAddr, addr)
+		return
+	}
+
+	return fn
+}
+
+// MatchRemoteAddr adds a [RequestMatcher] to the [Request] which expects a
+// received [http.Request]'s RemoteAddr, which is usually an "ip:port", to
+// equal addr. The port of a client is usually ephemeral, so
+// [Request.MatchRemoteIP] is more useful in most tests.
+//
+// A [Server] listens on a loopback address, so clients connect from a loopback
+// address as well. A received [http.Request] from [Mock.RoundTripper] has no
+// RemoteAddr unless the client sets one.
+//
+//	Mock.On(http.MethodGet, "/some/path", nil).MatchRemoteAddr("10.0.0.1:5000")
+func (r *Request) MatchRemoteAddr(addr string) *Request {
+	return r.Matches(remoteAddrMatcher(addr))
+}
+
+// remoteIPMatcher creates a [RequestMatcher] that expects a received
+// [http.Request] to have been sent from the given IP address, with any port.
+func remoteIPMatcher(ip string) RequestMatcher {
+	expected := net.ParseIP(ip)
+
+	fn := func(received *http.Request) (output string, differences int) {
+		if received.RemoteAddr == "" {
+			output = fmt.Sprintf("FAIL:  remote ip: %s != %q", fmtMissing, ip)
+			differences = 1
+			return
+		}
+		host := received.RemoteAddr
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if actual := net.ParseIP(host); actual == nil || !actual.Equal(expected) {
+			output = fmt.Sprintf("FAIL:  remote ip: %q != %q", host, ip)
+			differences = 1
+			return
+		}
+		output = fmt.Sprintf("PASS:  remote ip: %q == %q", host, ip)
+		return
+	}
+
+	return fn
+}
+
+// MatchRemoteIP adds a [RequestMatcher] to the [Request] which expects a
+// received [http.Request] to have been sent from the given IP address, with
+// any port, which is useful for testing IP allowlists. The addresses are
+// compared as IPs, so "::ffff:127.0.0.1" and "127.0.0.1" are equal. The test
+// fails if ip is not a valid IP address.
+//
+// Like [Request.MatchRemoteAddr], a [Server]'s clients usually connect from a
+// loopback address.
+//
+//	Mock.On(http.MethodGet, "/some/path", nil).MatchRemoteIP("127.0.0.1")
+func (r *Request) MatchRemoteIP(ip string) *Request {
+	if net.ParseIP(ip) == nil {
+		r.parent.fail("invalid remote ip %q for request %s\n", ip, r.summary())
+	}
+	return r.Matches(remoteIPMatcher(ip))
+}
+
+// contextValueMatcher creates a [RequestMatcher] that expects the context of a
+// received [http.Request] to have a value for the given key that equals the
+// expected value.
+func contextValueMatcher(key any, expected any) RequestMatcher {
+	fn := func(received *http.Request) (output string, differences int) {
+		name := fmt.Sprintf("%T(%v)", key, key)
+		actual := received.Context().Value(key)
+		if actual == nil {
+			output = fmt.Sprintf("FAIL:  context value %s: %s != %#v", name, fmtMissing, expected)
+			differences = 1
+			return
+		}
+		if !comparableEqual(actual, expected) {
+			output = fmt.Sprintf("FAIL:  context value %s: %#v != %#v", name, actual, expected)
+			differences = 1
+			return
+		}
+		output = fmt.Sprintf("PASS:  context value %s: %#v == %#v", name, actual, expected)
+		return
+	}
+
+	return fn
+}
+
+// comparableEqual reports whether two values are equal with ==. Values of
+// types that cannot be compared, such as slices and maps, are never equal,
+// rather than panicking.
+func comparableEqual(a any, b any) bool {
+	ta, tb := reflect.TypeOf(a), reflect.TypeOf(b)
+	if ta != tb || (ta != nil && !ta.Comparable()) {
+		return false
+	}
+	return a == b
+}
+
+// MatchContextValue adds a [RequestMatcher] to the [Request] which expects the
+// context of a received [http.Request] to have a value for the given key that
+// equals the expected value, as compared with ==. Since context keys are often
+// unexported types, any key may be used.
+//
+// Context values are not sent over a connection, so this only sees values that
+// are added by the same process, such as by middleware that wraps a [Server]'s
+// handler with [ServerConfig.Handler], or when calling [Mock.Requested]
+// directly.
+//
+//	Mock.On(http.MethodGet, "/some/path", nil).MatchContextValue(tenantKey{}, "acme")
+func (r *Request) MatchContextValue(key any, expected any) *Request {
+	return r.Matches(contextValueMatcher(key, expected))
+}
+
+// pathRegexMatcher creates a [RequestMatcher] that expects a received
+// [http.Request] to have a URL path that matches the given regular expression.
+func pathRegexMatcher(re *regexp.Regexp) RequestMatcher {
+	fn := func(received *http.Request) (output string, differences int) {
+		actual := received.URL.Path
+		if !re.MatchString(actual) {
+			output = fmt.Sprintf("FAIL:  path regex: %q != %q", actual, re.String())
+			differences = 1
+			return
+		}
+		output = fmt.Sprintf("PASS:  path regex: %q == %q", actual, re.String())
+		return
+	}
+
+	return fn
+}
+
+// MatchPathRegex adds a [RequestMatcher] to the [Request] which expects a
+// received [http.Request] to have a URL path that matches the given regular
+// expression. The pattern is compiled immediately, and the test fails if it is
+// invalid. Since [Mock.On] also compares the URL, use [AnyURL] to match on the
+// regular expression alone.
+//
+//	Mock.On(http.MethodGet, AnyURL, nil).MatchPathRegex(`\.json$`)
+func (r *Request) MatchPathRegex(pattern string) *Request {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		r.parent.fail("failed to compile path regex %q for request %s. Error: %v\n", pattern, r.summary(), err)
+	}
+
+	return r.Matches(pathRegexMatcher(re))
+}
+
+// basicAuthMatcher creates a [RequestMatcher] that expects a received
+// [http.Request] to use basic auth with the given username and password. The
+// password is not included in the output.
+func basicAuthMatcher(username string, password string) RequestMatcher {
+	fn := func(received *http.Request) (output string, differences int) {
+		actual, actualPassword, ok := received.BasicAuth()
+		if !ok {
+			output = fmt.Sprintf("FAIL:  basic auth: %s != %q", fmtMissing, username)
+			differences = 1
+			return
+		}
+		if actual != username {
+			output = fmt.Sprintf("FAIL:  basic auth: %q != %q", actual, username)
+			differences = 1
+			return
+		}
+		if actualPassword != password {
+			output = fmt.Sprintf("FAIL:  basic auth: %q (wrong password) != %q", actual, username)
+			differences = 1
+			return
+		}
+		output = fmt.Sprintf("PASS:  basic auth: %q == %q", actual, username)
+		return
+	}
+
+	return fn
+}
+
+// MatchBasicAuth adds a [RequestMatcher] to the [Request] which expects a
+// received [http.Request] to use basic auth with the given username and
+// password. Requests without a basic Authorization header do not match.
+//
+//	Mock.On(http.MethodGet, "/some/path", nil).MatchBasicAuth("admin", "hunter2")
+func (r *Request) MatchBasicAuth(username string, password string) *Request {
+	return r.Matches(basicAuthMatcher(username, password))
+}
+
+// bearerTokenMatcher creates a [RequestMatcher] that expects a received
+// [http.Request] to have a bearer Authorization header with the given token.
+// Tokens are compared in constant time and are not included in the output.
+func bearerTokenMatcher(token string) RequestMatcher {
+	expected := fmt.Sprintf("(Redacted, %d chars)", len(token))
+
+	fn := func(received *http.Request) (output string, differences int) {
+		value := received.Header.Get("Authorization")
+		if value == "" {
+			output = fmt.Sprintf("FAIL:  bearer token: %s != %s", fmtMissing, expected)
+			differences = 1
+			return
+		}
+		scheme, actual, _ := strings.Cut(value, " ")
+		if !strings.EqualFold(scheme, "Bearer") {
+			output = fmt.Sprintf("FAIL:  bearer token: %q scheme != \"Bearer\" scheme", scheme)
+			differences = 1
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(actual), []byte(token)) != 1 {
+			output = fmt.Sprintf("FAIL:  bearer token: (Redacted, %d chars) != %s", len(actual), expected)
+			differences = 1
+			return
+		}
+		output = fmt.Sprintf("PASS:  bearer token: (Redacted, %d chars) == %s", len(actual), expected)
+		return
+	}
+
+	return fn
+}
+
+// MatchBearerToken adds a [RequestMatcher] to the [Request] which expects a
+// received [http.Request] to have a bearer Authorization header with the given
+// token. The token is compared in constant time, and is never included in the
+// diff. Requests without a bearer Authorization header do not match.
+//
+//	Mock.On(http.MethodGet, "/some/path", nil).MatchBearerToken("jkel3450d")
+func (r *Request) MatchBearerToken(token string) *Request {
+	return r.Matches(bearerTokenMatcher(token))
+}
+
+// bodyLenMatcher creates a [RequestMatcher] that expects a received
+// [http.Request] to have a body whose length is between minLen and maxLen,
+// inclusive. If maxLen is -1, there is no upper bound.
+func bodyLenMatcher(minLen int, maxLen int) RequestMatcher {
+	expected := fmt.Sprintf("[%d, %d]", minLen, maxLen)
+	if maxLen < 0 {
+		expected = fmt.Sprintf("[%d, (No Max)]", minLen)
+	}
+
+	fn := func(received *http.Request) (output string, differences int) {
+		body, err := SafeReadBody(received)
+		if err != nil {
+			output = fmt.Sprintf("FAIL:  body length: %v", err)
+			differences = 1
+			return
+		}
+
+		actual := len(body)
+		if actual < minLen || (maxLen >= 0 && actual > maxLen) {
+			output = fmt.Sprintf("FAIL:  body length: %d != %s", actual, expected)
+			differences = 1
+			return
+		}
+		output = fmt.Sprintf("PASS:  body length: %d == %s", actual, expected)
+		return
+	}
+
+	return fn
+}
+
+// MatchBodyLen adds a [RequestMatcher] to the [Request] which expects a
+// received [http.Request] to have a body whose length is between minLen and
+// maxLen, inclusive. If maxLen is -1, there is no upper bound. Since [Mock.On]
+// also compares the body byte-for-byte, use [AnyBody] as the expected body.
+//
+//	Mock.On(http.MethodPut, "/upload", AnyBody).MatchBodyLen(1024, -1)
+func (r *Request) MatchBodyLen(minLen int, maxLen int) *Request {
+	return r.Matches(bodyLenMatcher(minLen, maxLen))
+}
+
+// bodyOneOfMatcher creates a [RequestMatcher] that expects a received
+// [http.Request] to have a body equal to any of the given bodies.
+func bodyOneOfMatcher(bodies [][]byte) RequestMatcher {
+	fn := func(received *http.Request) (output string, differences int) {
+		body, err := SafeReadBody(received)
+		if err != nil {
+			output = fmt.Sprintf("FAIL:  body one of: %v", err)
+			differences = 1
+			return
+		}
+
+		actual := previewBody(body)
+		for i, candidate := range bodies {
+			if bytes.Equal(body, candidate) {
+				output = fmt.Sprintf("PASS:  body one of: %s == candidate %d of %d", actual, i+1, len(bodies))
+				return
+			}
+		}
+		output = fmt.Sprintf("FAIL:  body one of: %s != %d candidate(s)", actual, len(bodies))
+		differences = 1
+		return
+	}
+
+	return fn
+}
+
+// previewBody formats a body as its length, a short hash, and the first bytes
+// of its contents, for diagnostics where the full body would be too noisy.
+func previewBody(body []byte) string {
+	const previewLen = 32
+
+	sum := sha256.Sum256(body)
+	preview := fmtMissing
+	if len(body) > previewLen {
+		preview = fmt.Sprintf("%q...", body[:previewLen])
+	} else if len(body) > 0 {
+		preview = fmt.Sprintf("%q", body)
+	}
+	return fmt.Sprintf("(%d, sha256:%x) %s", len(body), sum[:4], preview)
+}
+
+// MatchBodyOneOf adds a [RequestMatcher] to the [Request] which expects a
+// received [http.Request] to have a body equal to any of the given bodies. This
+// is useful when a client may send one of several equivalent payloads. Since
+// [Mock.On] also compares the body byte-for-byte, use [AnyBody] as the
+// expected body.
+//
+//	Mock.On(http.MethodPut, "/some/path", AnyBody).MatchBodyOneOf([]byte(`{"a":1,"b":2}`), []byte(`{"b":2,"a":1}`))
+func (r *Request) MatchBodyOneOf(bodies ...[]byte) *Request {
+	return r.Matches(bodyOneOfMatcher(bodies))
+}
+
+// defaultMultipartMaxMemory is the default maximum number of bytes of a
+// multipart form that are stored in memory, matching [http.Request.FormFile].
+const defaultMultipartMaxMemory = 32 << 20
+
+// bodyContainsMatcher creates a [RequestMatcher] that expects a received
+// [http.Request] to have a body that contains the given bytes.
+func bodyContainsMatcher(sub []byte) RequestMatcher {
+	fn := func(received *http.Request) (output string, differences int) {
+		body, err := SafeReadBody(received)
+		if err != nil {
+			output = fmt.Sprintf("FAIL:  body contains: %v", err)
+			differences = 1
+			return
+		}
+
+		if !bytes.Contains(body, sub) {
+			output = fmt.Sprintf("FAIL:  body contains: %s != %q", previewBody(body), sub)
+			differences = 1
+			return
+		}
+		output = fmt.Sprintf("PASS:  body contains: %s == %q", previewBody(body), sub)
+		return
+	}
+
+	return fn
+}
+
+// MatchBodyContains adds a [RequestMatcher] to the [Request] which expects a
+// received [http.Request] to have a body that contains the given bytes, which is
+// useful for loosely-structured payloads. Since [Mock.On] also compares the
+// body byte-for-byte, use [AnyBody] as the expected body.
+//
+//	Mock.On(http.MethodPost, "/logs", AnyBody).MatchBodyContains([]byte("level=error"))
+func (r *Request) MatchBodyContains(sub []byte) *Request {
+	return r.Matches(bodyContainsMatcher(sub))
+}
+
+// MatchBodyContainsString is a convenience method that calls
+// [Request.MatchBodyContains] with a string.
+//
+//	Mock.On(http.MethodPost, "/logs", AnyBody).MatchBodyContainsString("level=error")
+func (r *Request) MatchBodyContainsString(sub string) *Request {
+	return r.MatchBodyContains([]byte(sub))
+}
+
+// parseMultipartForm parses the multipart form of a received [http.Request]
+// without consuming its body. File parts beyond maxMemory bytes are stored in
+// temporary files, so the returned form must be cleaned up with
+// [multipart.Form.RemoveAll].
+func parseMultipartForm(received *http.Request, maxMemory int64) (*multipart.Form, error) {
+	body, err := SafeReadBody(received)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse a copy of the request so that the received request's body and
+	// form fields are left untouched for other matchers
+	tempRequest := &http.Request{
+		Method: received.Method,
+		URL:    received.URL,
+		Header: received.Header,
+		Body:   io.NopCloser(bytes.NewReader(body)),
+	}
+	if err := tempRequest.ParseMultipartForm(maxMemory); err != nil {
+		return nil, err
+	}
+
+	return tempRequest.MultipartForm, nil
+}
+
+// multipartFileMatcher creates a [RequestMatcher] that expects a received
+// [http.Request] to have a multipart form with a file in the given field. If
+// filename is not empty, one of the files must have that name. If content is
+// not nil, one of the files must have that content.
+func multipartFileMatcher(m *Mock, field string, filename string, content []byte) RequestMatcher {
+	name := "multipart file"
+	expected := fmt.Sprintf("%q", filename)
+	if content != nil {
+		name = "multipart file content"
+		expected = fmt.Sprintf("(%d) %s", len(content), trimBody(content))
+	}
+
+	fn := func(received *http.Request) (output string, differences int) {
+		maxMemory := m.multipartMaxMemory
+		if maxMemory <= 0 {
+			maxMemory = defaultMultipartMaxMemory
+		}
+
+		form, err := parseMultipartForm(received, maxMemory)
+		if err != nil {
+			output = fmt.Sprintf("FAIL:  %s %s: %v", name, field, err)
+			differences = 1
+			return
+		}
+		defer form.RemoveAll()
+
+		files := form.File[field]
+		if len(files) == 0 {
+			output = fmt.Sprintf("FAIL:  %s %s: %s != %s", name, field, fmtMissing, expected)
+			differences = 1
+			return
+		}
+
+		var actual []string
+		for _, fh := range files {
+			if content == nil {
+				if fh.Filename == filename {
+					output = fmt.Sprintf("PASS:  %s %s: %q == %s", name, field, fh.Filename, expected)
+					return
+				}
+				actual = append(actual, fh.Filename)
+				continue
+			}
+
+			body, err := readMultipartFile(fh)
+			if err != nil {
+				output = fmt.Sprintf("FAIL:  %s %s: %v", name, field, err)
+				differences = 1
+				return
+			}
+			if bytes.Equal(body, content) {
+				output = fmt.Sprintf("PASS:  %s %s: (%d) %s == %s", name, field, len(body), trimBody(body), expected)
+				return
+			}
+			actual = append(actual, fmt.Sprintf("(%d) %s", len(body), trimBody(body)))
+		}
+
+		output = fmt.Sprintf("FAIL:  %s %s: %s != %s", name, field, strings.Join(actual, ", "), expected)
+		differences = 1
+		return
+	}
+
+	return fn
+}
+
+// readMultipartFile reads the entire contents of a multipart file.
+func readMultipartFile(fh *multipart.FileHeader) ([]byte, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return io.ReadAll(f)
+}
+
+// MatchMultipartFile adds a [RequestMatcher] to the [Request] which expects a
+// received [http.Request] to have a multipart/form-data body with a file of the
+// given filename in the given field. Since [Mock.On] also compares the body
+// byte-for-byte, use [AnyBody] as the expected body.
+//
+// The body is parsed with the memory limit set by [Mock.MultipartMaxMemory],
+// and the received request is left intact for other matchers.
+//
+//	Mock.On(http.MethodPost, "/upload", AnyBody).MatchMultipartFile("avatar", "me.png")
+func (r *Request) MatchMultipartFile(field string, filename string) *Request {
+	return r.Matches(multipartFileMatcher(r.parent, field, filename, nil))
+}
+
+// MatchMultipartFileContent adds a [RequestMatcher] to the [Request] which
+// expects a received [http.Request] to have a multipart/form-data body with a
+// file of the given content in the given field. Since [Mock.On] also compares
+// the body byte-for-byte, use [AnyBody] as the expected body.
+//
+// The body is parsed with the memory limit set by [Mock.MultipartMaxMemory],
+// and the received request is left intact for other matchers.
+//
+//	Mock.On(http.MethodPost, "/upload", AnyBody).MatchMultipartFileContent("avatar", pngBytes)
+func (r *Request) MatchMultipartFileContent(field string, content []byte) *Request {
+	if content == nil {
+		content = []byte{}
+	}
+
+	return r.Matches(multipartFileMatcher(r.parent, field, "", content))
+}
@@ -2,17 +2,24 @@ package httpmock
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
+var ErrInvalidRequest = errors.New("invalid expected request")
+
 // tHelper is a minimal interface that expects a type to satisfy the
 // [testing.TB] Helper method.
 type tHelper interface {
@@ -32,17 +39,107 @@ type Mock struct {
 	// an invalid mock request was made.
 	test mock.TestingT
 
+	// failHandler is an optional function that failures are reported to
+	// instead of test. It is protected by testMutex, like test.
+	failHandler func(format string, args ...any)
+
+	// Holds every request that was made to a mocked handler or server,
+	// including those that did not match an expected request.
+	history []RecordedCall
+
+	// Closed and replaced whenever a request is added to the history, to wake
+	// callers of WaitForCalls.
+	historyChanged chan struct{}
+
+	// Response to return when a received request does not match any expected
+	// requests.
+	defaultResponse *Response
+
+	// Response that forwards a received request to an upstream server when it
+	// does not match any expected requests.
+	passthroughResponse *Response
+
+	// Hosts that requests sent with RoundTripper are forwarded to when they do
+	// not match any expected requests.
+	passthroughHosts []string
+
+	// Interactions recorded with Record, to be persisted with SaveRecording.
+	recordings []interaction
+
+	// Whether responses without a Content-Type should have one detected from
+	// their body.
+	sniffContentType bool
+
+	// Maximum number of bytes of a multipart form to store in memory while
+	// matching. 0 means to use the default.
+	multipartMaxMemory int64
+
+	// Maximum number of bytes of a received request's body to buffer for
+	// matching. 0 means to use the default, and a negative value means that
+	// bodies are not limited.
+	maxBodyBuffer int64
+
+	// Whether received request bodies should be matched as they were sent,
+	// rather than decoded according to their Content-Encoding.
+	keepRequestEncoding bool
+
+	// Functions that transform every response before it is written, in the
+	// order they were added.
+	responseHooks []func(*Response, *http.Request)
+
+	// Number of requests currently being handled, and the most that have been
+	// handled at once.
+	inFlight    atomic.Int64
+	maxInFlight atomic.Int64
+
+	// Source of random choices, such as those of RespondWeighted. nil means to
+	// use the top-level functions of math/rand.
+	rand *rand.Rand
+
+	// Whether panics in ServeHTTP should be allowed to propagate, rather than
+	// being caught and printed, with a 404 returned.
+	ignorePanic bool
+
 	mutex sync.Mutex
+
+	// Protects test separately from mutex, so that a failure can be reported
+	// while mutex is held, such as by a hung RequestMatcher.
+	testMutex sync.Mutex
 }
 
 // On starts a description of an expectation of the specified [Request] being
-// received.
+// received. If the method or URL pattern is malformed, the test fails. Use
+// [Mock.OnE] to handle the error instead.
 //
 //	Mock.On(http.MethodDelete, "/some/path/1234")
 func (m *Mock) On(method string, URL string, body []byte) *Request {
+	expected, err := m.OnE(method, URL, body)
+	if err != nil {
+		m.fail("%v\n", err)
+	}
+	return expected
+}
+
+// OnE is like [Mock.On], but returns an error wrapping [ErrInvalidRequest]
+// instead of failing the test if the method is not a valid HTTP method token
+// or [AnyMethod], or if the URL pattern cannot be parsed. No [Request] is
+// registered when an error is returned. This is useful when registering
+// expected requests from external data, such as in a loop.
+//
+//	for _, route := range routes {
+//		r, err := Mock.OnE(route.Method, route.URL, nil)
+//		if err != nil {
+//			return err
+//		}
+//		r.RespondOK(route.Body)
+//	}
+func (m *Mock) OnE(method string, URL string, body []byte) (*Request, error) {
+	if method != AnyMethod && !validMethod(method) {
+		return nil, fmt.Errorf("%w: invalid method %q", ErrInvalidRequest, method)
+	}
 	parsedURL, err := url.Parse(URL)
 	if err != nil {
-		m.fail("failed to parse url. Error: %v\n", err)
+		return nil, fmt.Errorf("%w: failed to parse url: %v", ErrInvalidRequest, err)
 	}
 
 	expected := newRequest(
@@ -56,29 +153,449 @@ func (m *Mock) On(method string, URL string, body []byte) *Request {
 	defer m.mutex.Unlock()
 
 	m.ExpectedRequests = append(m.ExpectedRequests, expected)
-	return expected
+	return expected, nil
+}
+
+// validMethod reports whether method is a non-empty HTTP token, as defined by
+// RFC 9110.
+func validMethod(method string) bool {
+	if method == "" {
+		return false
+	}
+	for _, c := range method {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case strings.ContainsRune("!#$%&'*+-.^_`|~", c):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// OnAny starts a description of an expectation of a [Request] with any method
+// being received. It is equivalent to [Mock.On] with [AnyMethod], and is useful
+// for catch-all rules, such as rejecting unsupported methods.
+//
+// A [Request] registered with a specific method takes precedence over one
+// registered with OnAny, regardless of the order in which they were registered.
+// Once the specific [Request] is exhausted with [Request.Times], matching
+// requests fall back to the OnAny [Request].
+//
+//	Mock.On(http.MethodGet, "/some/path", nil).RespondOK(nil)
+//	Mock.OnAny("/some/path", AnyBody).Respond(http.StatusMethodNotAllowed, nil)
+func (m *Mock) OnAny(URL string, body []byte) *Request {
+	return m.On(AnyMethod, URL, body)
+}
+
+// OnString is a convenience method to invoke the [Mock.On] method with a string
+// body.
+//
+//	Mock.OnString(http.MethodPost, "/some/path", `{"id": 1234}`)
+func (m *Mock) OnString(method string, URL string, body string) *Request {
+	return m.On(method, URL, []byte(body))
+}
+
+// OnReader is a convenience method to invoke the [Mock.On] method with a body
+// that is read fully from r when the [Request] is registered. If r cannot be
+// read, the test fails.
+//
+//	f, _ := os.Open("testdata/user.json")
+//	Mock.OnReader(http.MethodPost, "/users", f)
+func (m *Mock) OnReader(method string, URL string, r io.Reader) *Request {
+	body, err := io.ReadAll(r)
+	if err != nil {
+		m.fail("failed to read body for request %s %s. Error: %v\n", method, URL, err)
+	}
+
+	return m.On(method, URL, body)
+}
+
+// RespondDefault sets a fallback [Response] that is returned when a received
+// request does not match any expected [Request]. This suppresses the failure
+// that normally occurs for unexpected requests, which is useful when the code
+// being tested makes incidental requests that are not relevant to the test.
+//
+// Requests that receive the default response are not matched against an
+// expected [Request], so they do not count towards [Mock.AssertExpectations]
+// or the other request assertions.
+//
+//	Mock.RespondDefault(http.StatusNotFound, nil)
+func (m *Mock) RespondDefault(statusCode int, body []byte) *Response {
+	// The default response is not owned by an expected request, but responses
+	// require a parent to access the mock
+	parent := newRequest(m, AnyMethod, &url.URL{}, AnyBody)
+	resp := newResponse(parent, statusCode, body)
+	parent.response = resp
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.defaultResponse = resp
+	return resp
+}
+
+// Reset returns the [Mock] to a fresh state by clearing all expected
+// [Request]'s, received requests and history, the default response, the
+// passthrough upstream and hosts, recorded interactions, and the high-water
+// mark of [Mock.MaxConcurrentCalls].
+//
+// All settings are kept, including the test struct set with [Mock.Test], the
+// [Mock.SniffContentType], [Mock.MultipartMaxMemory], [Mock.MaxBodyBuffer],
+// [Mock.DecodeRequestBodies] and [Mock.NotRecoverable] settings, the handler
+// set with [Mock.SetFailHandler], the source set with [Mock.SetRand], and
+// hooks added with [Mock.ResponseHook]. This allows a long-lived [Server] to be
+// reused between subtests.
+//
+// Reset is safe to call while the [Server] is running. However, matching of
+// requests that are in-flight during a reset is undefined.
+func (m *Mock) Reset() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.ExpectedRequests = nil
+	m.Requests = nil
+	m.history = nil
+	m.defaultResponse = nil
+	m.passthroughResponse = nil
+	m.passthroughHosts = nil
+	m.recordings = nil
+	m.maxInFlight.Store(0)
+}
+
+// Clone returns a copy of the [Mock] with copies of its expected [Request]'s and
+// settings, such as its default response, but with no received requests or
+// history. The request counts of the copied [Request]'s are reset, so each one
+// may be received the number of times that was configured with [Request.Times].
+// Expectations registered on the clone do not affect the original, and vice
+// versa, so a clone can be given to each parallel subtest, rather than sharing
+// one [Mock] and calling [Mock.Reset].
+//
+// Functions are shared by reference rather than copied, including
+// [RequestMatcher]'s, the handler set with [Mock.SetFailHandler], and the
+// functions given to [Request.RespondFunc] and [Request.RespondUsing], as are
+// the targets of [Request.CaptureJSON] and the channels of
+// [Request.RespondGate]. Responses forwarded by [Mock.Record] are recorded on
+// the original [Mock].
+//
+//	func TestSomething(t *testing.T) {
+//		base := new(httpmock.Mock)
+//		base.On(http.MethodGet, "/some/path", nil).RespondOK(nil)
+//
+//		t.Run("subtest", func(t *testing.T) {
+//			t.Parallel()
+//			s := httpmock.NewServer()
+//			s.Mock = base.Clone().Test(t)
+//			defer s.Close()
+//		})
+//	}
+func (m *Mock) Clone() *Mock {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.testMutex.Lock()
+	test := m.test
+	failHandler := m.failHandler
+	m.testMutex.Unlock()
+
+	c := &Mock{
+		test:                test,
+		failHandler:         failHandler,
+		sniffContentType:    m.sniffContentType,
+		multipartMaxMemory:  m.multipartMaxMemory,
+		maxBodyBuffer:       m.maxBodyBuffer,
+		keepRequestEncoding: m.keepRequestEncoding,
+		ignorePanic:         m.ignorePanic,
+		passthroughHosts:    slices.Clone(m.passthroughHosts),
+		responseHooks:       slices.Clone(m.responseHooks),
+	}
+
+	clones := make(map[*Request]*Request, len(m.ExpectedRequests))
+	for _, expected := range m.ExpectedRequests {
+		clones[expected] = expected.clone(c)
+		c.ExpectedRequests = append(c.ExpectedRequests, clones[expected])
+	}
+	// Order requirements must refer to the copied requests
+	for _, expected := range c.ExpectedRequests {
+		for i, required := range expected.requires {
+			if clone, ok := clones[required]; ok {
+				expected.requires[i] = clone
+			}
+		}
+	}
+
+	cloneUnowned := func(resp *Response) *Response {
+		if resp == nil {
+			return nil
+		}
+		parent := newRequest(c, AnyMethod, &url.URL{}, AnyBody)
+		parent.response = resp.clone(parent)
+		return parent.response
+	}
+	c.defaultResponse = cloneUnowned(m.defaultResponse)
+	c.passthroughResponse = cloneUnowned(m.passthroughResponse)
+
+	return c
 }
 
 // Test sets the test struct variable of the [Mock] object.
 func (m *Mock) Test(t mock.TestingT) *Mock {
+	m.testMutex.Lock()
+	defer m.testMutex.Unlock()
+	m.test = t
+	return m
+}
+
+// InOrder requires that the given [Request]'s are received in the given order.
+// Each [Request] will not match a received request until the [Request] before
+// it has been received at least once, or the configured number of times if it
+// was limited with [Request.Times]. A request that arrives out of order is
+// matched against the remaining expected [Request]'s as usual, and the
+// diagnostics name the [Request] that was expected first.
+//
+// [Request]'s that are not passed to InOrder may be received at any time.
+//
+//	login := Mock.On(http.MethodPost, "/login", AnyBody).RespondNoContent().Once()
+//	logout := Mock.On(http.MethodPost, "/logout", AnyBody).RespondNoContent().Once()
+//	Mock.InOrder(login, logout)
+func (m *Mock) InOrder(requests ...*Request) *Mock {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
-	m.test = t
+
+	for i := 1; i < len(requests); i++ {
+		requests[i].requires = append(requests[i].requires, requests[i-1])
+	}
 	return m
 }
 
-// fail the current test with the given formatted format and args. In the case
-// that a testing object was defined, it uses the test APIs for failing a test;
-// otherwise, it uses panic.
-func (m *Mock) fail(format string, args ...interface{}) {
+// SniffContentType sets whether a [Response] that does not have a Content-Type
+// should have one detected from its body with [http.DetectContentType]. It is
+// disabled by default.
+//
+// A Content-Type header set with [Response.Header] or [Response.ContentType]
+// always takes precedence, followed by the Content-Type implied by the response
+// constructor, such as [Request.RespondJSON], and then the sniffed
+// Content-Type. Empty bodies are not sniffed.
+//
+//	Mock.SniffContentType(true)
+func (m *Mock) SniffContentType(enabled bool) *Mock {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.sniffContentType = enabled
+	return m
+}
+
+// MultipartMaxMemory sets the maximum number of bytes of a multipart form that
+// are stored in memory by [Request.MatchMultipartFile] and
+// [Request.MatchMultipartFileContent]. The remainder of the file parts are
+// stored in temporary files, which are removed after matching. It defaults to
+// 32 MB, the same as [http.Request.FormFile].
+//
+//	Mock.MultipartMaxMemory(1 << 20)
+func (m *Mock) MultipartMaxMemory(maxMemory int64) *Mock {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.multipartMaxMemory = maxMemory
+	return m
+}
+
+// ResponseHook adds a function that transforms every [Response] right before it
+// is written, such as to wrap bodies in an envelope or to add a signature
+// header. The hook receives a copy of the [Response], so it can change the
+// status code, headers, and body with methods such as [Response.SetStatusCode],
+// [Response.Header], and [Response.SetBody] without affecting later responses.
+//
+// Hooks run in the order they were added, each seeing the changes of the hooks
+// before it. They run once the body is known, including after
+// [Request.RespondSequence], [Request.RespondByAccept], [Request.RespondTemplate],
+// and [Request.RespondEcho] have chosen it, and before the Content-Type is
+// sniffed and the body is compressed with [Response.Gzip] or truncated with
+// [Request.RespondTruncated]. Middlewares added with [Server.ResponseMiddleware]
+// run before any hooks. Hooks do not run for responses written by a custom
+// [ResponseWriter], such as with [Request.RespondUsing] or [Mock.Passthrough],
+// and changing the body does not change the chunks of a streamed response.
+//
+//	Mock.ResponseHook(func(resp *Response, r *http.Request) {
+//		resp.Header("X-Signature", sign(resp.Body()))
+//	})
+func (m *Mock) ResponseHook(fn func(*Response, *http.Request)) *Mock {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
-	if m.test == nil {
+	m.responseHooks = append(m.responseHooks, fn)
+	return m
+}
+
+// DefaultMaxBodyBuffer is the default maximum number of bytes of a received
+// request's body that a [Mock] buffers for matching. See [Mock.MaxBodyBuffer].
+const DefaultMaxBodyBuffer int64 = 10 << 20
+
+// MaxBodyBuffer sets the maximum number of bytes of a received request's body
+// that are buffered for matching, which protects tests from running out of
+// memory on huge uploads. It defaults to [DefaultMaxBodyBuffer], which is 10
+// MiB. If n is 0 or less, bodies are not limited.
+//
+// A request with a larger body does not match any expected [Request]. It is
+// forwarded if [Mock.Passthrough] or [Mock.Record] was configured, or receives
+// the response set with [Mock.RespondDefault]. Otherwise, the test fails with a
+// diagnostic that names the limit. Only the first n bytes of the body are
+// recorded in [Mock.History].
+//
+//	Mock.MaxBodyBuffer(1 << 20)
+func (m *Mock) MaxBodyBuffer(n int64) *Mock {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.maxBodyBuffer = n
+	if n <= 0 {
+		m.maxBodyBuffer = -1
+	}
+	return m
+}
+
+// maxBodyBufferSize returns the maximum number of bytes of a received request's
+// body to buffer, or 0 or less if bodies are not limited. The mutex of the
+// [Mock] must be held.
+func (m *Mock) maxBodyBufferSize() int64 {
+	if m.maxBodyBuffer == 0 {
+		return DefaultMaxBodyBuffer
+	}
+	return m.maxBodyBuffer
+}
+
+// DecodeRequestBodies sets whether the body of a received request that was
+// compressed by the client is decoded before it is matched, so that body
+// matchers such as [Request.MatchJSONBody] compare the decoded content. It is
+// enabled by default. Bodies with a Content-Encoding of "gzip", "x-gzip", or
+// "deflate", or several of them, are decoded, and other encodings are matched
+// as they were sent. The decoded body, which is limited by [Mock.MaxBodyBuffer],
+// is recorded in [Mock.History].
+//
+// Once matching is done, the body of the received request is restored to the
+// original compressed bytes, so that it is forwarded unchanged by
+// [Mock.Passthrough] and read unchanged by the [ResponseWriter]. A body that
+// cannot be decoded does not match any expected [Request], and is handled the
+// same as a body that exceeds [Mock.MaxBodyBuffer], with a diagnostic that
+// names the encoding.
+//
+//	Mock.DecodeRequestBodies(false)
+func (m *Mock) DecodeRequestBodies(enabled bool) *Mock {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.keepRequestEncoding = !enabled
+	return m
+}
+
+// readReceivedBody reads the body of a received [http.Request] for matching,
+// buffering at most the max body buffer, and decodes it unless
+// [Mock.DecodeRequestBodies] was disabled. If the body is decoded, the body of
+// received is replaced with the decoded body, and restore resets it to the
+// original body. Otherwise, restore does nothing. A body that cannot be decoded
+// returns an error that wraps [ErrDecodeBody]. The mutex of the [Mock] must be
+// held.
+func (m *Mock) readReceivedBody(received *http.Request) (body []byte, tooLarge bool, restore func(), err error) {
+	restore = func() {}
+
+	limit := m.maxBodyBufferSize()
+	body, tooLarge, err = readLimitedBody(received, limit)
+	if err != nil || tooLarge || m.keepRequestEncoding {
+		return body, tooLarge, restore, err
+	}
+
+	decoded, tooLarge, err := decodeBody(received.Header.Get("Content-Encoding"), body, limit)
+	if err != nil || decoded == nil {
+		return body, false, restore, err
+	}
+
+	encoded := body
+	received.Body = io.NopCloser(bytes.NewReader(decoded))
+	restore = func() {
+		received.Body = io.NopCloser(bytes.NewReader(encoded))
+	}
+	return decoded, tooLarge, restore, nil
+}
+
+// SetFailHandler sets a function that the [Mock] reports failures to, such as
+// an unexpected request, instead of failing the test set with [Mock.Test]. This
+// routes failures into another test framework or a logger, or collects them,
+// such as in a fuzzer or benchmark. Passing nil restores the default behavior,
+// which fails the test set with [Mock.Test], or panics if none was set.
+//
+// A failure means that the operation that failed, such as matching a received
+// request, cannot continue. If the handler returns, the [Mock] panics with the
+// formatted message, the same as when no test is set, which a [Server]
+// recovers as usual. The handler may be called concurrently by requests that
+// are received at the same time.
+//
+//	Mock.SetFailHandler(func(format string, args ...any) {
+//		log.Printf("httpmock: "+format, args...)
+//	})
+func (m *Mock) SetFailHandler(fn func(format string, args ...any)) *Mock {
+	m.testMutex.Lock()
+	defer m.testMutex.Unlock()
+
+	m.failHandler = fn
+	return m
+}
+
+// SetRand sets the source of random choices of the [Mock], such as those of
+// [Request.RespondWeighted], so that tests can make them deterministic. If r is
+// nil, the top-level functions of [math/rand] are used, which is the default.
+// Since a [rand.Rand] is not safe for concurrent use, it is only used while the
+// [Mock] is locked, and it is not copied by [Mock.Clone].
+//
+//	Mock.SetRand(rand.New(rand.NewSource(1)))
+func (m *Mock) SetRand(r *rand.Rand) *Mock {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.rand = r
+	return m
+}
+
+// NotRecoverable sets the [Mock] as not recoverable, so that panics in
+// [Mock.ServeHTTP] and [Mock.RoundTripper] are allowed to propagate, rather
+// than being caught and printed with a 404 written, or returned as an error.
+// This has no effect on a [Server]; use [Server.NotRecoverable] instead.
+func (m *Mock) NotRecoverable() *Mock {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.ignorePanic = true
+	return m
+}
+
+// IsRecoverable returns whether or not the [Mock] is considered recoverable by
+// [Mock.ServeHTTP] and [Mock.RoundTripper].
+func (m *Mock) IsRecoverable() bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return !m.ignorePanic
+}
+
+// fail the current test with the given formatted format and args. In the case
+// that a fail handler was set, it is called first. In the case that a testing
+// object was defined, it uses the test APIs for failing a test; otherwise, it
+// uses panic.
+func (m *Mock) fail(format string, args ...interface{}) {
+	m.testMutex.Lock()
+	test := m.test
+	failHandler := m.failHandler
+	m.testMutex.Unlock()
+
+	if failHandler != nil {
+		failHandler(format, args...)
 		panic(fmt.Sprintf(format, args...))
 	}
-	m.test.Errorf(format, args...)
-	m.test.FailNow()
+	if test == nil {
+		panic(fmt.Sprintf(format, args...))
+	}
+	test.Errorf(format, args...)
+	test.FailNow()
 }
 
 // expectedRequests provides a safe mechanism for viewing and modifying the list
@@ -94,20 +611,35 @@ func (m *Mock) requests() []Request {
 }
 
 // findExpectedRequest finds the first [Request] that exactly matches a received
-// request and does not have its repeatability disabled.
+// request and does not have its repeatability disabled. [Request]'s with a
+// specific method are preferred over those with [AnyMethod]. If every match is
+// exhausted, one is returned with an index of -1, preferring one with an
+// exhausted response.
 func (m *Mock) findExpectedRequest(actual *http.Request) (int, *Request) {
 	var expected *Request
+	wildcard := -1
 	for i, er := range m.ExpectedRequests {
 		if _, d := er.diff(actual); d != 0 {
 			continue
 		}
 
-		expected = er
-		if er.repeatability > -1 {
+		if expected == nil || expected.exhaustedResponse == nil {
+			expected = er
+		}
+		if er.repeatability <= -1 {
+			continue
+		}
+		if er.method != AnyMethod {
 			return i, er
 		}
+		if wildcard < 0 {
+			wildcard = i
+		}
 	}
 
+	if wildcard >= 0 {
+		return wildcard, m.ExpectedRequests[wildcard]
+	}
 	return -1, expected
 }
 
@@ -138,21 +670,99 @@ func (m *Mock) findClosestRequest(received *http.Request) (*Request, string) {
 // Requested tells the mock that a [http.Request] has been received and gets a
 // response to return. Panics if the request is unexpected (i.e. not preceded
 // by appropriate [Mock.On] calls).
+//
+// If the matched [Request] was never configured with [Request.Respond] or one
+// of its variants, a 200 response with an empty body is returned. If no
+// [Request] matches and [Mock.Passthrough] or [Mock.RespondDefault] was
+// configured, the passthrough or default response is returned instead of
+// panicking. If the matching [Request] is exhausted and has a response set with
+// [Request.RespondExhausted], that response is returned instead.
 func (m *Mock) Requested(received *http.Request) *Response {
+	response, _ := m.requested(received, false)
+	return response
+}
+
+// requested implements [Mock.Requested]. If forwardHosts is true, as it is for
+// [Mock.RoundTripper], a request for a host given to [Mock.PassthroughHosts]
+// that does not match an expected [Request] returns a nil [Response] and true,
+// so that it is forwarded to its host. The request is matched only once, while
+// the mutex is held, so that the decision cannot race with other requests.
+func (m *Mock) requested(received *http.Request, forwardHosts bool) (*Response, bool) {
 	m.mutex.Lock()
+	forwardHost := forwardHosts && m.passthroughHostListed(received)
 
-	receivedBody, err := SafeReadBody(received)
-	if err != nil {
+	receivedBody, tooLarge, restore, err := m.readReceivedBody(received)
+	// The original body is written or forwarded, rather than the decoded body
+	defer restore()
+	decodeErr := err
+	if err != nil && !errors.Is(err, ErrDecodeBody) {
 		m.mutex.Unlock()
 		m.fail("\nassert: httpmock: Failed to read requested body. Error: %v", err)
 	}
+	call := m.recordCall(newRecordedCall(received, receivedBody))
+
+	if (tooLarge || decodeErr != nil) && forwardHost {
+		m.history[call].passthrough = true
+		m.mutex.Unlock()
+
+		return nil, true
+	}
+	if tooLarge || decodeErr != nil {
+		// The body cannot be matched without buffering or decoding all of it
+		response := m.passthroughResponse
+		if response != nil {
+			m.history[call].passthrough = true
+		} else {
+			response = m.defaultResponse
+		}
+		limit := m.maxBodyBufferSize()
+		m.mutex.Unlock()
+
+		if response == nil && decodeErr != nil {
+			m.fail("\nassert: httpmock: The body of the request %s %s with Content-Encoding %q cannot be decoded, so it cannot be matched. Error: %v\n\tEither send a validly encoded body, or disable Mock.DecodeRequestBodies.\n", received.Method, received.URL.String(), received.Header.Get("Content-Encoding"), decodeErr)
+		}
+		if response == nil {
+			m.fail("\nassert: httpmock: The body of the request %s %s exceeds the max body buffer of %d bytes, so it cannot be matched.\n\tEither increase Mock.MaxBodyBuffer, or reduce the size of the body.\n", received.Method, received.URL.String(), limit)
+		}
+		return response, false
+	}
+
+	found, expected := func() (int, *Request) {
+		defer m.unlockOnPanic()
+		return m.findExpectedRequest(received)
+	}()
+	if found < 0 && expected != nil && expected.exhaustedResponse != nil {
+		response := expected.exhaustedResponse
+		m.history[call].Matched = true
+		m.history[call].request = expected
+		m.mutex.Unlock()
+
+		return response, false
+	}
+	if found < 0 && forwardHost {
+		m.history[call].passthrough = true
+		m.mutex.Unlock()
+
+		return nil, true
+	}
+	if found < 0 && m.passthroughResponse != nil {
+		response := m.passthroughResponse
+		m.history[call].passthrough = true
+		m.mutex.Unlock()
+
+		return response, false
+	}
+	if found < 0 && m.defaultResponse != nil {
+		response := m.defaultResponse
+		m.mutex.Unlock()
 
-	found, expected := m.findExpectedRequest(received)
+		return response, false
+	}
 	if found < 0 {
 		// Expected request found, but has already been requested with repeatable times
 		if expected != nil {
 			m.mutex.Unlock()
-			m.fail("\nassert: httpmock: The request has been called over %d times.\n\tEither do one more Mock.On(%q, %q), or remove extra request.", expected.totalRequests, received.Method, received.URL.String())
+			m.fail("\nassert: httpmock: The request %s has been called over %d times.\n\tEither do one more Mock.On(%q, %q), or remove extra request.", expected.summary(), expected.totalRequests, received.Method, received.URL.String())
 		}
 		// We have to fail here - because we don't know what to do for the
 		// response. This is becuase:
@@ -160,7 +770,10 @@ func (m *Mock) Requested(received *http.Request) *Response {
 		//	a) This is a totally unexpected request
 		//	b) The arguments are not what was expected, or
 		//	c) The deveoper has forgotten to add an accompanying On...Respond pair
-		closest, mismatch := m.findClosestRequest(received)
+		closest, mismatch := func() (*Request, string) {
+			defer m.unlockOnPanic()
+			return m.findClosestRequest(received)
+		}()
 		m.mutex.Unlock()
 
 		if closest != nil {
@@ -191,16 +804,85 @@ func (m *Mock) Requested(received *http.Request) *Response {
 	}
 	expected.totalRequests++
 
+	if expected.capture != nil {
+		if err := json.Unmarshal(receivedBody, expected.capture); err != nil {
+			m.mutex.Unlock()
+			m.fail("\nassert: httpmock: Failed to capture requested body. Error: %v", err)
+		}
+	}
+
+	// If no response was configured, default to a 200 with an empty body
+	response := expected.response
+	if response == nil {
+		response = newResponse(expected, http.StatusOK, nil)
+	}
+
 	// Add a clean request to received request list
 	newRequest := newRequest(m, received.Method, received.URL, receivedBody)
-	if expected.response != nil {
-		newResponse := *expected.response
-		newRequest.response = &newResponse
-	}
+	newResponse := *response
+	newRequest.response = &newResponse
 	m.Requests = append(m.Requests, *newRequest)
+	m.history[call].Matched = true
+	m.history[call].request = expected
+	expected.lastMatched = m.history[call].Time
 	m.mutex.Unlock()
 
-	return expected.response
+	return response, false
+}
+
+// unlockOnPanic unlocks the mutex of the [Mock] if a panic occurs while it is
+// held, such as in a [RequestMatcher], and then continues panicking. This
+// allows the panic to be recovered without leaving the [Mock] locked. It must
+// be deferred directly.
+func (m *Mock) unlockOnPanic() {
+	if rc := recover(); rc != nil {
+		m.mutex.Unlock()
+		panic(rc)
+	}
+}
+
+// ServeHTTP implements [http.Handler], so that the [Mock] can be called
+// directly with an [httptest.ResponseRecorder], without starting a [Server].
+// This is the fastest way to drive matching in unit tests. The received
+// request is matched and its response is written, as with the handler of a
+// [Server]. Requests are counted towards [Mock.MaxConcurrentCalls].
+//
+// By default, panics are caught and printed to stdout, and a 404 is written,
+// as with a [Server]. Use [Mock.NotRecoverable] to allow them to propagate
+// instead. Settings of a [Server], such as
+// [Server.ResponseMiddleware] and [Server.MatchTimeout], do not apply.
+//
+//	recorder := httptest.NewRecorder()
+//	Mock.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/some/path", http.NoBody))
+//	resp := recorder.Result()
+func (m *Mock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	defer m.trackCall()()
+
+	defer func() {
+		if rc := recover(); rc != nil {
+			if !m.IsRecoverable() {
+				panic(rc)
+			}
+			fmt.Printf("%v\n", rc)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}()
+
+	m.serve(w, r, nil)
+}
+
+// serve matches a received request and writes its response. Each middleware
+// is run after the request is matched and before the response is written. It
+// is shared by [Mock.ServeHTTP] and the handler of a [Server].
+func (m *Mock) serve(w http.ResponseWriter, r *http.Request, middlewares []func(w http.ResponseWriter, r *http.Request)) *Response {
+	response := m.Requested(r)
+	for _, middleware := range middlewares {
+		middleware(w, r)
+	}
+	if _, err := response.Write(w, r); err != nil {
+		m.fail("failed to write response for request:\n%s\nwith error: %v", response.parent.String(), err)
+	}
+	return response
 }
 
 // matchCandidate holds details about possible [Request] matches for a received
@@ -265,6 +947,34 @@ func (m *Mock) AssertExpectations(t mock.TestingT) bool {
 	return failedExpectations == 0
 }
 
+// UnmetExpectations returns the expected [Request]'s that have not been
+// satisfied, in the order they were registered, which are the ones that
+// [Mock.AssertExpectations] would report. Unlike [Mock.AssertExpectations], it
+// does not need a [mock.TestingT], so it can be used to build custom reports,
+// such as with the labels set with [Request.Describe].
+//
+// Each returned [Request] is a snapshot taken while the [Mock] is locked, so
+// its [Request.NumberOfRequests] does not change as more requests are
+// received, and it does not affect the [Mock] if it is modified. It is safe to
+// call UnmetExpectations while requests are being received, although requests
+// that are in-flight may or may not be counted.
+//
+//	for _, r := range Mock.UnmetExpectations() {
+//		fmt.Printf("unmet: %s\n", r)
+//	}
+func (m *Mock) UnmetExpectations() []*Request {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var unmet []*Request
+	for _, er := range m.expectedRequests() {
+		if satisfied, _ := m.checkExpectation(er); !satisfied {
+			unmet = append(unmet, er.snapshot())
+		}
+	}
+	return unmet
+}
+
 // AssertNumberOfRequests asserts that the request was made expectedRequests times.
 //
 // This assertion behaves a bit differently than other assertions. There are a few
@@ -311,6 +1021,83 @@ func (m *Mock) AssertNumberOfRequests(t mock.TestingT, method string, path strin
 	return assert.Equal(t, expectedRequests, actualRequests)
 }
 
+// AssertNumberOfRequestsFor asserts that the expected [Request] was matched
+// expectedRequests times.
+//
+//	submit := Mock.On(http.MethodPost, "/submit", nil)
+//	submit.RespondOK(nil)
+//	...
+//	Mock.AssertNumberOfRequestsFor(t, submit, 3)
+func (m *Mock) AssertNumberOfRequestsFor(t mock.TestingT, expected *Request, expectedRequests int) bool {
+	if th, ok := t.(tHelper); ok {
+		th.Helper()
+	}
+
+	actualRequests := expected.NumberOfRequests()
+	if actualRequests != expectedRequests {
+		m.mutex.Lock()
+		v := "\t" + strings.Join(strings.Split(expected.String(), "\n"), "\n\t")
+		m.mutex.Unlock()
+
+		return assert.Fail(
+			t,
+			"Should have been requested the expected number of times",
+			fmt.Sprintf("Expected\n%v\nto have been requested %d time(s), but it was requested %d time(s)", v, expectedRequests, actualRequests),
+		)
+	}
+	return true
+}
+
+// MaxConcurrentCalls returns the most requests that have been handled at once,
+// which corresponds to the most requests that were in-flight at once. Requests
+// are counted from when they are received by the [Server] or [Mock.RoundTripper]
+// until their response has been written, including any delays. This is useful
+// for verifying connection limits and semaphores of a client.
+func (m *Mock) MaxConcurrentCalls() int {
+	return int(m.maxInFlight.Load())
+}
+
+// AssertMaxConcurrency asserts that no more than max requests were handled at
+// once, as reported by [Mock.MaxConcurrentCalls].
+//
+//	var wg sync.WaitGroup
+//	for range 10 {
+//		wg.Add(1)
+//		go func() {
+//			defer wg.Done()
+//			_, _ = pooledClient.Get(Server.URLf("/some/path"))
+//		}()
+//	}
+//	wg.Wait()
+//	Mock.AssertMaxConcurrency(t, 4)
+func (m *Mock) AssertMaxConcurrency(t mock.TestingT, max int) bool {
+	if th, ok := t.(tHelper); ok {
+		th.Helper()
+	}
+
+	actual := m.MaxConcurrentCalls()
+	if actual > max {
+		return assert.Fail(t,
+			"Too many concurrent requests",
+			fmt.Sprintf("Expected at most %d concurrent request(s), but %d were handled at once", max, actual),
+		)
+	}
+	return true
+}
+
+// trackCall counts a request as in-flight, updating the high-water mark of
+// [Mock.MaxConcurrentCalls], until the returned function is called.
+func (m *Mock) trackCall() (done func()) {
+	n := m.inFlight.Add(1)
+	for {
+		max := m.maxInFlight.Load()
+		if n <= max || m.maxInFlight.CompareAndSwap(max, n) {
+			break
+		}
+	}
+	return func() { m.inFlight.Add(-1) }
+}
+
 // AssertRequested asserts that the request was received.
 func (m *Mock) AssertRequested(t mock.TestingT, method string, path string, body []byte) bool {
 	if th, ok := t.(tHelper); ok {
@@ -366,10 +1153,18 @@ func (m *Mock) AssertNotRequested(t mock.TestingT, method string, path string, b
 // checkExpectation checks whether an expected [Request] was received,
 // whether it received the expected number of times.
 func (m *Mock) checkExpectation(expected *Request) (bool, string) {
+	summary := fmt.Sprintf("%s\n\t(%d) %s", expected.summary(), len(expected.body), trimBody(expected.body))
+	for i, fn := range expected.matchers {
+		summary += fmt.Sprintf("\n\tMatcher[%d]: %s", i, matcherName(fn))
+	}
+	if expected.repeatability > 0 {
+		summary += fmt.Sprintf("\n\tRequested: %d out of %d time(s)", expected.totalRequests, expected.totalRequests+expected.repeatability)
+	}
+
 	if (!m.checkWasRequested(expected.method, expected.url, expected.body) && expected.totalRequests == 0) || (expected.repeatability > 0) {
-		return false, fmt.Sprintf("FAIL:\t%s %s\n\t(%d) %s", expected.method, expected.url, len(expected.body), trimBody(expected.body))
+		return false, "FAIL:\t" + summary
 	}
-	return true, fmt.Sprintf("PASS:\t%s %s\n\t(%d) %s", expected.method, expected.url, len(expected.body), trimBody(expected.body))
+	return true, "PASS:\t" + summary
 }
 
 // checkWasRequested checks whether a set of [Request] parameters was received.
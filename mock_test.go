@@ -1,14 +1,18 @@
 package httpmock
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -70,6 +74,89 @@ func TestMock_fail_NoTestingT(t *testing.T) {
 	m.fail("I failed...%s %v", "badly!", errors.New("some error"))
 }
 
+func TestMock_fail_FailHandler(t *testing.T) {
+	// Setup
+	var successfulCall int
+	var gotFailures []string
+
+	mockT := new(MockTestingT)
+	m := new(Mock).Test(mockT)
+
+	// Test
+	got := m.SetFailHandler(func(format string, args ...any) {
+		gotFailures = append(gotFailures, fmt.Sprintf(format, args...))
+	})
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("Did not expect to get here")
+		}
+		// Assertions
+		assert.Equal(t, m, got)
+		assert.Equal(t, "I failed...badly! some error", r.(string))
+		assert.Equal(t, []string{"I failed...badly! some error"}, gotFailures)
+		assert.Zero(t, mockT.errorfCount)
+		assert.Zero(t, mockT.failNowCount)
+		assert.Zero(t, successfulCall)
+	}()
+
+	m.fail("I failed...%s %v", "badly!", errors.New("some error"))
+	successfulCall++
+}
+
+func TestMock_Clone_FailHandler(t *testing.T) {
+	// Setup
+	var gotFailures []string
+
+	mockT := new(MockTestingT)
+	m := new(Mock).Test(mockT).SetFailHandler(func(format string, args ...any) {
+		gotFailures = append(gotFailures, fmt.Sprintf(format, args...))
+	})
+
+	// Test
+	c := m.Clone()
+
+	defer func() {
+		r := recover()
+		// Assertions
+		assert.Equal(t, "I failed", r)
+		assert.Equal(t, []string{"I failed"}, gotFailures)
+		assert.Zero(t, mockT.failNowCount)
+	}()
+
+	c.fail("I failed")
+	t.Fatal("Did not expect to get here")
+}
+
+func TestMock_SetFailHandler_Reset(t *testing.T) {
+	// Setup
+	var successfulCall int
+
+	mockT := new(MockTestingT)
+	m := new(Mock).Test(mockT).SetFailHandler(func(string, ...any) {
+		t.Fatal("Did not expect the fail handler to be called")
+	})
+
+	// Test
+	m.SetFailHandler(nil)
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("Did not expect to get here")
+		}
+		// Assertions
+		assert.Equal(t, "FailNow was called", r.(string))
+		assert.Equal(t, 1, mockT.errorfCount)
+		assert.Equal(t, 1, mockT.failNowCount)
+		assert.Zero(t, successfulCall)
+	}()
+
+	m.fail("I failed")
+	successfulCall++
+}
+
 func TestMock_On_BadURL(t *testing.T) {
 	// Setup
 	var successfulRequestedCall int
@@ -115,6 +202,483 @@ func TestMock_On(t *testing.T) {
 	assert.Equal(t, want, m.ExpectedRequests[0])
 }
 
+func TestMock_OnE(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		url     string
+		wantErr string
+	}{
+		{
+			name:   "valid",
+			method: http.MethodGet,
+			url:    "https://test.com/foo",
+		},
+		{
+			name:   "any-method",
+			method: AnyMethod,
+			url:    AnyURL,
+		},
+		{
+			name:   "extension-method",
+			method: "PROPFIND",
+			url:    "/foo",
+		},
+		{
+			name:    "empty-method",
+			method:  "",
+			url:     "/foo",
+			wantErr: `invalid expected request: invalid method ""`,
+		},
+		{
+			name:    "invalid-method",
+			method:  "GET /foo",
+			url:     "/foo",
+			wantErr: `invalid expected request: invalid method "GET /foo"`,
+		},
+		{
+			name:    "invalid-url",
+			method:  http.MethodGet,
+			url:     "\r",
+			wantErr: "invalid expected request: failed to parse url: parse \"\\r\": net/url: invalid control character in URL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			m := new(Mock)
+
+			// Test
+			got, gotErr := m.OnE(tt.method, tt.url, nil)
+
+			// Assertions
+			if tt.wantErr != "" {
+				assert.ErrorIs(t, gotErr, ErrInvalidRequest)
+				assert.EqualError(t, gotErr, tt.wantErr)
+				assert.Nil(t, got)
+				assert.Empty(t, m.ExpectedRequests)
+				return
+			}
+			assert.NoError(t, gotErr)
+			assert.Equal(t, tt.method, got.method)
+			assert.Equal(t, []*Request{got}, m.ExpectedRequests)
+		})
+	}
+}
+
+func TestMock_On_BadMethod(t *testing.T) {
+	// Setup
+	var successfulRequestedCall int
+
+	mockT := new(MockTestingT)
+	m := new(Mock).Test(mockT)
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("Did not expect to get here")
+		}
+		// Assertions
+		assert.Equal(t, "FailNow was called", r.(string))
+		assert.Equal(t, 1, mockT.failNowCount)
+		assert.Zero(t, successfulRequestedCall)
+		assert.Empty(t, m.ExpectedRequests)
+	}()
+
+	// Test
+	m.On("GET /foo", "/foo", nil)
+	successfulRequestedCall++
+}
+
+func TestMock_OnString(t *testing.T) {
+	// Setup
+	m := new(Mock)
+
+	// Test
+	got := m.OnString(http.MethodPost, "https://test.com/foo", testBody)
+
+	// Assertions
+	assert.Len(t, m.ExpectedRequests, 1)
+	assert.Equal(t, http.MethodPost, got.method)
+	assert.Equal(t, "https://test.com/foo", got.url.String())
+	assert.Equal(t, []byte(testBody), got.body)
+}
+
+func TestMock_OnReader(t *testing.T) {
+	// Setup
+	m := new(Mock)
+
+	// Test
+	got := m.OnReader(http.MethodPost, "https://test.com/foo", strings.NewReader(testBody))
+
+	// Assertions
+	assert.Len(t, m.ExpectedRequests, 1)
+	assert.Equal(t, http.MethodPost, got.method)
+	assert.Equal(t, "https://test.com/foo", got.url.String())
+	assert.Equal(t, []byte(testBody), got.body)
+}
+
+func TestMock_OnReader_FailToRead(t *testing.T) {
+	// Setup
+	var successfulOnCall int
+
+	mockT := new(MockTestingT)
+	m := new(Mock).Test(mockT)
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("Did not expect to get here")
+		}
+		// Assertions
+		assert.Equal(t, "FailNow was called", r.(string))
+		assert.Equal(t, 1, mockT.failNowCount)
+		assert.Zero(t, successfulOnCall)
+		assert.Empty(t, m.ExpectedRequests)
+	}()
+
+	// Test
+	m.OnReader(http.MethodPost, "https://test.com/foo", &badReader{})
+	successfulOnCall++
+}
+
+func TestMock_InOrder(t *testing.T) {
+	// Setup
+	m := new(Mock).Test(t)
+	first := m.On(http.MethodPost, "/login", AnyBody)
+	second := m.On(http.MethodGet, "/profile", nil)
+	third := m.On(http.MethodPost, "/logout", AnyBody)
+
+	// Test
+	got := m.InOrder(first, second, third)
+
+	// Assertions
+	assert.Equal(t, m, got)
+	assert.Empty(t, first.requires)
+	assert.Equal(t, []*Request{first}, second.requires)
+	assert.Equal(t, []*Request{second}, third.requires)
+}
+
+func TestMock_Requested_InOrder(t *testing.T) {
+	// Setup
+	m := new(Mock).Test(t)
+	login := m.On(http.MethodPost, "/login", AnyBody).RespondNoContent().Once()
+	profile := m.On(http.MethodGet, "/profile", nil)
+	profile.RespondOK([]byte(testBody))
+	m.On(http.MethodGet, "/health", nil).RespondOK(nil)
+	m.InOrder(login, profile)
+
+	// Test
+	m.Requested(mustNewRequest(http.NewRequest(http.MethodGet, "/health", http.NoBody)))
+	m.Requested(mustNewRequest(http.NewRequest(http.MethodPost, "/login", http.NoBody)))
+	m.Requested(mustNewRequest(http.NewRequest(http.MethodGet, "/profile", http.NoBody)))
+	m.Requested(mustNewRequest(http.NewRequest(http.MethodGet, "/profile", http.NoBody)))
+
+	// Assertions
+	assert.Equal(t, 1, login.totalRequests)
+	assert.Equal(t, 2, profile.totalRequests)
+	m.AssertExpectations(t)
+}
+
+func TestMock_Requested_MatchAfter(t *testing.T) {
+	// Setup
+	m := new(Mock).Test(t)
+	expected := m.On(http.MethodGet, "/retry", nil).MatchAfter(50 * time.Millisecond)
+	expected.RespondOK(nil)
+	m.RespondDefault(http.StatusTooManyRequests, nil)
+	received := mustNewRequest(http.NewRequest(http.MethodGet, "/retry", http.NoBody))
+
+	// Test
+	gotEarly := m.Requested(received)
+	_, gotEarlyOutput := m.findClosestRequest(received)
+	time.Sleep(50 * time.Millisecond)
+	gotLate := m.Requested(received)
+	gotTooSoon := m.Requested(received)
+	time.Sleep(50 * time.Millisecond)
+	gotLateAgain := m.Requested(received)
+
+	// Assertions
+	assert.Equal(t, http.StatusTooManyRequests, gotEarly.statusCode)
+	assert.Regexp(t, `3: FAIL:  after: \S+ since registered < 50ms`, gotEarlyOutput)
+	assert.Equal(t, http.StatusOK, gotLate.statusCode)
+	assert.Equal(t, http.StatusTooManyRequests, gotTooSoon.statusCode)
+	assert.Equal(t, http.StatusOK, gotLateAgain.statusCode)
+	assert.Equal(t, 2, expected.totalRequests)
+}
+
+func TestMock_Requested_OutOfOrder(t *testing.T) {
+	// Setup
+	var successfulRequestedCall int
+
+	mockT := &MockTestingT{}
+	m := new(Mock).Test(mockT)
+	login := m.On(http.MethodPost, "/login", AnyBody).RespondNoContent().Once()
+	profile := m.On(http.MethodGet, "/profile", nil)
+	profile.RespondOK([]byte(testBody))
+	m.InOrder(login, profile)
+
+	received := mustNewRequest(http.NewRequest(http.MethodGet, "/profile", http.NoBody))
+	_, gotMismatch := m.findClosestRequest(received)
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("Did not expect to get here")
+		}
+		// Assertions
+		assert.Equal(t, "FailNow was called", r.(string))
+		assert.Equal(t, 1, mockT.failNowCount)
+		assert.Contains(t, gotMismatch, "FAIL:  order: expected POST /login to be requested first")
+		assert.Zero(t, profile.totalRequests)
+		assert.Zero(t, successfulRequestedCall)
+	}()
+
+	// Test
+	m.Requested(received)
+	successfulRequestedCall++
+}
+
+func TestMock_SniffContentType(t *testing.T) {
+	// Setup
+	m := new(Mock)
+
+	// Test
+	got := m.SniffContentType(true)
+
+	// Assertions
+	assert.Equal(t, m, got)
+	assert.True(t, m.sniffContentType)
+}
+
+func TestMock_DecodeRequestBodies(t *testing.T) {
+	// Setup
+	m := new(Mock)
+
+	// Test
+	got := m.DecodeRequestBodies(false)
+
+	// Assertions
+	assert.Equal(t, m, got)
+	assert.True(t, m.keepRequestEncoding)
+
+	m.DecodeRequestBodies(true)
+	assert.False(t, m.keepRequestEncoding)
+}
+
+func TestMock_SetRand(t *testing.T) {
+	// Setup
+	m := new(Mock)
+	r := rand.New(rand.NewSource(1))
+
+	// Test
+	got := m.SetRand(r)
+
+	// Assertions
+	assert.Equal(t, m, got)
+	assert.Same(t, r, m.rand)
+	assert.Nil(t, m.Clone().rand)
+
+	m.SetRand(nil)
+	assert.Nil(t, m.rand)
+}
+
+func TestMock_NotRecoverable(t *testing.T) {
+	// Setup
+	m := new(Mock)
+	assert.True(t, m.IsRecoverable())
+
+	// Test
+	got := m.NotRecoverable()
+
+	// Assertions
+	assert.Equal(t, m, got)
+	assert.False(t, m.IsRecoverable())
+	assert.False(t, m.Clone().IsRecoverable())
+}
+
+func TestMock_ResponseHook(t *testing.T) {
+	// Setup
+	m := new(Mock)
+	var calls []string
+	first := func(*Response, *http.Request) { calls = append(calls, "first") }
+	second := func(*Response, *http.Request) { calls = append(calls, "second") }
+
+	// Test
+	got := m.ResponseHook(first).ResponseHook(second)
+
+	// Assertions
+	assert.Equal(t, m, got)
+	assert.Len(t, m.responseHooks, 2)
+	for _, hook := range m.responseHooks {
+		hook(nil, nil)
+	}
+	assert.Equal(t, []string{"first", "second"}, calls)
+}
+
+func TestMock_MultipartMaxMemory(t *testing.T) {
+	// Setup
+	m := new(Mock)
+
+	// Test
+	got := m.MultipartMaxMemory(1 << 20)
+
+	// Assertions
+	assert.Equal(t, m, got)
+	assert.Equal(t, int64(1<<20), m.multipartMaxMemory)
+}
+
+func TestMock_MaxBodyBuffer(t *testing.T) {
+	tests := []struct {
+		name      string
+		n         int64
+		wantLimit int64
+	}{
+		{
+			name:      "limit",
+			n:         1 << 20,
+			wantLimit: 1 << 20,
+		},
+		{
+			name:      "unlimited",
+			n:         0,
+			wantLimit: -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			m := new(Mock)
+			assert.Equal(t, DefaultMaxBodyBuffer, m.maxBodyBufferSize())
+
+			// Test
+			got := m.MaxBodyBuffer(tt.n)
+
+			// Assertions
+			assert.Equal(t, m, got)
+			assert.Equal(t, tt.wantLimit, m.maxBodyBufferSize())
+		})
+	}
+}
+
+func TestMock_Reset(t *testing.T) {
+	// Setup
+	m := new(Mock).Test(t)
+	m.On(http.MethodGet, "https://test.com/foo", nil).RespondOK(nil)
+	m.RespondDefault(http.StatusNotFound, nil)
+	m.Requested(mustNewRequest(http.NewRequest(http.MethodGet, "https://test.com/foo", http.NoBody)))
+
+	// Test
+	m.Reset()
+
+	// Assertions
+	assert.Empty(t, m.ExpectedRequests)
+	assert.Empty(t, m.Requests)
+	assert.Nil(t, m.defaultResponse)
+	assert.Equal(t, t, m.test)
+
+	expected := m.On(http.MethodGet, "https://test.com/foo", nil)
+	m.Requested(mustNewRequest(http.NewRequest(http.MethodGet, "https://test.com/foo", http.NoBody)))
+	assert.Equal(t, 1, expected.totalRequests)
+	assert.Len(t, m.Requests, 1)
+}
+
+func TestMock_Clone(t *testing.T) {
+	// Setup
+	m := new(Mock).Test(t).SniffContentType(true)
+	first := m.On(http.MethodGet, "https://test.com/foo", nil).Describe("first")
+	first.Times(2).RespondOK([]byte("foo")).Header("X-Foo", "bar")
+	second := m.On(http.MethodGet, "https://test.com/bar", nil)
+	second.RespondOK(nil)
+	m.InOrder(first, second)
+	m.RespondDefault(http.StatusNotFound, nil)
+	m.Requested(mustNewRequest(http.NewRequest(http.MethodGet, "https://test.com/foo", http.NoBody)))
+
+	// Test
+	c := m.Clone()
+
+	// Assertions
+	assert.NotSame(t, m, c)
+	assert.Equal(t, t, c.test)
+	assert.True(t, c.sniffContentType)
+	assert.Empty(t, c.Requests)
+	assert.Len(t, c.ExpectedRequests, 2)
+
+	gotFirst := c.ExpectedRequests[0]
+	gotSecond := c.ExpectedRequests[1]
+	assert.NotSame(t, first, gotFirst)
+	assert.Same(t, c, gotFirst.parent)
+	assert.Equal(t, "first", gotFirst.label)
+	assert.Equal(t, 2, gotFirst.repeatability)
+	assert.Zero(t, gotFirst.totalRequests)
+	assert.Same(t, gotFirst, gotFirst.response.parent)
+	assert.Equal(t, []*Request{gotFirst}, gotSecond.requires)
+	assert.Same(t, c, c.defaultResponse.parent.parent)
+
+	// Changes to the clone do not affect the original
+	gotFirst.response.Header("X-Foo", "baz")
+	c.On(http.MethodGet, "https://test.com/baz", nil)
+	c.Requested(mustNewRequest(http.NewRequest(http.MethodGet, "https://test.com/foo", http.NoBody)))
+	assert.Len(t, m.ExpectedRequests, 2)
+	assert.Len(t, m.Requests, 1)
+	assert.Equal(t, 1, first.totalRequests)
+	assert.Equal(t, "bar", first.response.header.Get("X-Foo"))
+	assert.Equal(t, 1, gotFirst.totalRequests)
+}
+
+func TestMock_Clone_Exhausted(t *testing.T) {
+	// Setup
+	m := new(Mock).Test(t)
+	expected := m.On(http.MethodGet, "https://test.com/foo", nil).Once()
+	expected.RespondOK(nil)
+	expected.RespondExhausted(http.StatusGone, nil)
+	received := mustNewRequest(http.NewRequest(http.MethodGet, "https://test.com/foo", http.NoBody))
+	m.Requested(received)
+
+	// Test
+	c := m.Clone()
+
+	// Assertions
+	assert.Equal(t, -1, expected.repeatability)
+	gotExpected := c.ExpectedRequests[0]
+	assert.Equal(t, 1, gotExpected.repeatability)
+	assert.Same(t, gotExpected, gotExpected.exhaustedResponse.parent)
+	assert.Equal(t, http.StatusOK, c.Requested(received).statusCode)
+	assert.Equal(t, http.StatusGone, c.Requested(received).statusCode)
+}
+
+func TestMock_Clone_Parallel(t *testing.T) {
+	// Setup
+	base := new(Mock)
+	base.On(http.MethodGet, "/foo", nil).Once().RespondOK([]byte(testBody))
+
+	for _, name := range []string{"first", "second"} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s := NewServer()
+			s.Mock = base.Clone().Test(t)
+			defer s.Close()
+
+			// Test
+			resp, err := s.Client().Get(s.URLf("/foo"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			got, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Assertions
+			assert.Equal(t, testBody, string(got))
+			s.Mock.AssertExpectations(t)
+		})
+	}
+}
+
 func TestMock_findExpectedRequest_Fail(t *testing.T) {
 	requestMatcherRequireNextToken := func(received *http.Request) (output string, differences int) {
 		if ok := received.URL.Query().Has("next"); !ok {
@@ -214,6 +778,70 @@ func TestMock_findExpectedRequest_TooManyRepeats(t *testing.T) {
 	assert.NotNil(t, gotExpectedResult)
 }
 
+func TestMock_findExpectedRequest_AnyMethodPrecedence(t *testing.T) {
+	tests := []struct {
+		name      string
+		mock      func() *Mock
+		wantIndex int
+	}{
+		{
+			name: "specific-after-any",
+			mock: func() *Mock {
+				m := new(Mock)
+				m.OnAny("https://test.com/bars/1234", nil)
+				m.On(http.MethodGet, "https://test.com/bars/1234", nil)
+				return m
+			},
+			wantIndex: 1,
+		},
+		{
+			name: "specific-before-any",
+			mock: func() *Mock {
+				m := new(Mock)
+				m.On(http.MethodGet, "https://test.com/bars/1234", nil)
+				m.OnAny("https://test.com/bars/1234", nil)
+				return m
+			},
+			wantIndex: 0,
+		},
+		{
+			name: "specific-exhausted",
+			mock: func() *Mock {
+				m := new(Mock)
+				m.OnAny("https://test.com/bars/1234", nil)
+				m.On(http.MethodGet, "https://test.com/bars/1234", nil).Times(-1)
+				return m
+			},
+			wantIndex: 0,
+		},
+		{
+			name: "specific-mismatch",
+			mock: func() *Mock {
+				m := new(Mock)
+				m.OnAny("https://test.com/bars/1234", nil)
+				m.On(http.MethodPost, "https://test.com/bars/1234", nil)
+				return m
+			},
+			wantIndex: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			m := tt.mock()
+			test := mustNewRequest(http.NewRequest(http.MethodGet, "https://test.com/bars/1234", http.NoBody))
+
+			// Test
+			gotIndex, gotExpectedRequest := m.findExpectedRequest(test)
+
+			// Assertions
+			assert.NotNil(t, gotExpectedRequest)
+			assert.Equal(t, tt.wantIndex, gotIndex)
+		})
+	}
+}
+
 func TestMock_findExpectedRequest(t *testing.T) {
 	requestMatcherLimitAtLeastTwo := func(received *http.Request) (output string, differences int) {
 		if ok := received.URL.Query().Has("limit"); !ok {
@@ -355,33 +983,145 @@ func TestMock_findClosestRequest(t *testing.T) {
 		},
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			// Setup
-			m := tt.mock()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			m := tt.mock()
+
+			// Test
+			gotRequest, gotMismatch := m.findClosestRequest(tt.test)
+
+			// Assertions
+			if tt.wantRequest != nil {
+				tt.wantRequest.parent = m
+			}
+			assert.Equal(t, tt.wantRequest, gotRequest)
+			assert.Equal(t, tt.wantMismatch, gotMismatch != "")
+		})
+	}
+}
+
+func TestMock_Requested_FailToReadRequestBody(t *testing.T) {
+	// Setup
+	var successfulRequestedCall int
+
+	mockT := &MockTestingT{}
+	m := new(Mock).Test(mockT)
+	m.On(http.MethodGet, "https://test.com/foo", nil).RespondOK(nil)
+
+	received := mustNewRequest(http.NewRequest(http.MethodPut, "https://test.com/foo", io.NopCloser(&badReader{})))
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("Did not expect to get here")
+		}
+		// Assertions
+		assert.Equal(t, "FailNow was called", r.(string))
+		assert.Equal(t, 1, mockT.failNowCount)
+		assert.Zero(t, successfulRequestedCall)
+	}()
+
+	// Test
+	m.Requested(received)
+	successfulRequestedCall++
+}
+
+func TestMock_Requested_FailBodyTooLarge(t *testing.T) {
+	// Setup
+	var successfulRequestedCall int
+
+	mockT := &MockTestingT{}
+	m := new(Mock).Test(mockT).MaxBodyBuffer(5)
+	m.On(http.MethodPost, "https://test.com/foo", AnyBody).RespondOK(nil)
+
+	received := mustNewRequest(http.NewRequest(http.MethodPost, "https://test.com/foo", strings.NewReader(testBody)))
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("Did not expect to get here")
+		}
+		// Assertions
+		assert.Equal(t, "FailNow was called", r.(string))
+		assert.Equal(t, 1, mockT.failNowCount)
+		assert.Zero(t, successfulRequestedCall)
+		assert.Zero(t, m.ExpectedRequests[0].totalRequests)
+		assert.Equal(t, []byte("Hello"), m.history[0].Body)
+		assert.False(t, m.history[0].Matched)
+	}()
+
+	// Test
+	m.Requested(received)
+	successfulRequestedCall++
+}
+
+func TestMock_Requested_BodyTooLargeDefault(t *testing.T) {
+	// Setup
+	m := new(Mock).Test(t).MaxBodyBuffer(5)
+	expected := m.On(http.MethodPost, "https://test.com/foo", AnyBody)
+	expected.RespondOK(nil)
+	wantDefault := m.RespondDefault(http.StatusRequestEntityTooLarge, nil)
+
+	// Test
+	got := m.Requested(mustNewRequest(http.NewRequest(http.MethodPost, "https://test.com/foo", strings.NewReader(testBody))))
+
+	// Assertions
+	assert.Equal(t, wantDefault, got)
+	assert.Zero(t, expected.totalRequests)
+}
+
+func TestMock_Requested_DecodeRequestBodies(t *testing.T) {
+	// Setup
+	m := new(Mock).Test(t)
+	expected := m.On(http.MethodPost, "https://test.com/foo", []byte(testBody))
+	expected.RespondOK(nil)
+
+	encoded := mustEncode("gzip", []byte(testBody))
+	received := mustNewRequest(http.NewRequest(http.MethodPost, "https://test.com/foo", bytes.NewReader(encoded)))
+	received.Header.Set("Content-Encoding", "gzip")
+
+	// Test
+	got := m.Requested(received)
+
+	// Assertions
+	assert.Equal(t, expected.response, got)
+	assert.Equal(t, 1, expected.totalRequests)
+	assert.Equal(t, []byte(testBody), m.history[0].Body)
+	gotBody, err := io.ReadAll(received.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, encoded, gotBody)
+}
+
+func TestMock_Requested_DecodeRequestBodiesDisabled(t *testing.T) {
+	// Setup
+	encoded := mustEncode("gzip", []byte(testBody))
 
-			// Test
-			gotRequest, gotMismatch := m.findClosestRequest(tt.test)
+	m := new(Mock).Test(t).DecodeRequestBodies(false)
+	expected := m.On(http.MethodPost, "https://test.com/foo", encoded)
+	expected.RespondOK(nil)
 
-			// Assertions
-			if tt.wantRequest != nil {
-				tt.wantRequest.parent = m
-			}
-			assert.Equal(t, tt.wantRequest, gotRequest)
-			assert.Equal(t, tt.wantMismatch, gotMismatch != "")
-		})
-	}
+	received := mustNewRequest(http.NewRequest(http.MethodPost, "https://test.com/foo", bytes.NewReader(encoded)))
+	received.Header.Set("Content-Encoding", "gzip")
+
+	// Test
+	got := m.Requested(received)
+
+	// Assertions
+	assert.Equal(t, expected.response, got)
+	assert.Equal(t, encoded, m.history[0].Body)
 }
 
-func TestMock_Requested_FailToReadRequestBody(t *testing.T) {
+func TestMock_Requested_FailMalformedEncodedBody(t *testing.T) {
 	// Setup
 	var successfulRequestedCall int
 
 	mockT := &MockTestingT{}
 	m := new(Mock).Test(mockT)
-	m.On(http.MethodGet, "https://test.com/foo", nil).RespondOK(nil)
+	m.On(http.MethodPost, "https://test.com/foo", AnyBody).RespondOK(nil)
 
-	received := mustNewRequest(http.NewRequest(http.MethodPut, "https://test.com/foo", io.NopCloser(&badReader{})))
+	received := mustNewRequest(http.NewRequest(http.MethodPost, "https://test.com/foo", strings.NewReader(testBody)))
+	received.Header.Set("Content-Encoding", "gzip")
 
 	defer func() {
 		r := recover()
@@ -392,6 +1132,8 @@ func TestMock_Requested_FailToReadRequestBody(t *testing.T) {
 		assert.Equal(t, "FailNow was called", r.(string))
 		assert.Equal(t, 1, mockT.failNowCount)
 		assert.Zero(t, successfulRequestedCall)
+		assert.Zero(t, m.ExpectedRequests[0].totalRequests)
+		assert.False(t, m.history[0].Matched)
 	}()
 
 	// Test
@@ -399,6 +1141,24 @@ func TestMock_Requested_FailToReadRequestBody(t *testing.T) {
 	successfulRequestedCall++
 }
 
+func TestMock_Requested_MalformedEncodedBodyDefault(t *testing.T) {
+	// Setup
+	m := new(Mock).Test(t)
+	expected := m.On(http.MethodPost, "https://test.com/foo", AnyBody)
+	expected.RespondOK(nil)
+	wantDefault := m.RespondDefault(http.StatusBadRequest, nil)
+
+	received := mustNewRequest(http.NewRequest(http.MethodPost, "https://test.com/foo", strings.NewReader(testBody)))
+	received.Header.Set("Content-Encoding", "gzip")
+
+	// Test
+	got := m.Requested(received)
+
+	// Assertions
+	assert.Equal(t, wantDefault, got)
+	assert.Zero(t, expected.totalRequests)
+}
+
 func TestMock_Requested_FailToFindAnyMatch(t *testing.T) {
 	// Setup
 	var successfulRequestedCall int
@@ -478,6 +1238,130 @@ func TestMock_Requested_FailToFindClosestRequest(t *testing.T) {
 	successfulRequestedCall++
 }
 
+func TestMock_RespondDefault(t *testing.T) {
+	// Setup
+	m := new(Mock)
+
+	// Test
+	got := m.RespondDefault(http.StatusNotFound, []byte(testBody))
+
+	// Assertions
+	assert.Equal(t, got, m.defaultResponse)
+	assert.Equal(t, http.StatusNotFound, got.statusCode)
+	assert.Equal(t, []byte(testBody), got.body)
+	assert.Equal(t, m, got.parent.parent)
+	assert.Empty(t, m.ExpectedRequests)
+}
+
+func TestMock_Requested_Default(t *testing.T) {
+	// Setup
+	m := new(Mock).Test(t)
+	expected := m.On(http.MethodGet, "https://test.com/foo", nil).Once()
+	expected.RespondOK(nil)
+	wantDefault := m.RespondDefault(http.StatusTeapot, nil)
+
+	// Test
+	gotUnexpected := m.Requested(mustNewRequest(http.NewRequest(http.MethodPut, "https://test.com/foo", http.NoBody)))
+	gotExpected := m.Requested(mustNewRequest(http.NewRequest(http.MethodGet, "https://test.com/foo", http.NoBody)))
+	gotExhausted := m.Requested(mustNewRequest(http.NewRequest(http.MethodGet, "https://test.com/foo", http.NoBody)))
+
+	// Assertions
+	assert.Equal(t, wantDefault, gotUnexpected)
+	assert.Equal(t, expected.response, gotExpected)
+	assert.Equal(t, wantDefault, gotExhausted)
+	assert.Equal(t, 1, expected.totalRequests)
+	assert.Len(t, m.Requests, 1)
+	assert.True(t, m.AssertExpectations(t))
+}
+
+func TestMock_Requested_Exhausted(t *testing.T) {
+	// Setup
+	m := new(Mock).Test(t)
+	m.On(http.MethodGet, "https://test.com/foo", nil).Once()
+	expected := m.On(http.MethodGet, "https://test.com/foo", nil).RespondOK(nil).Once()
+	wantExhausted := expected.RespondExhausted(http.StatusTooManyRequests, nil)
+	m.RespondDefault(http.StatusTeapot, nil)
+
+	// Test
+	m.Requested(mustNewRequest(http.NewRequest(http.MethodGet, "https://test.com/foo", http.NoBody)))
+	gotExpected := m.Requested(mustNewRequest(http.NewRequest(http.MethodGet, "https://test.com/foo", http.NoBody)))
+	gotExhausted := m.Requested(mustNewRequest(http.NewRequest(http.MethodGet, "https://test.com/foo", http.NoBody)))
+
+	// Assertions
+	assert.Equal(t, expected.response, gotExpected)
+	assert.Equal(t, wantExhausted, gotExhausted)
+	assert.Equal(t, 1, expected.totalRequests)
+	assert.Len(t, m.Requests, 2)
+	assert.True(t, m.AssertExpectations(t))
+}
+
+func TestMock_ServeHTTP(t *testing.T) {
+	// Setup
+	m := new(Mock).Test(t)
+	m.On(http.MethodPost, "/foo", []byte(testBody)).RespondJSON(http.StatusCreated, map[string]string{"id": "1234"})
+	recorder := httptest.NewRecorder()
+
+	// Test
+	m.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/foo", strings.NewReader(testBody)))
+
+	// Assertions
+	got := recorder.Result()
+	assert.Equal(t, http.StatusCreated, got.StatusCode)
+	assert.Equal(t, "application/json", got.Header.Get("Content-Type"))
+	assert.Equal(t, `{"id":"1234"}`, recorder.Body.String())
+	assert.Equal(t, 1, m.MaxConcurrentCalls())
+	m.AssertExpectations(t)
+}
+
+func TestMock_ServeHTTP_CanonicalHeaderKeys(t *testing.T) {
+	// Setup
+	m := new(Mock).Test(t)
+	m.On(http.MethodGet, "/admin", nil).RespondUnauthorized("admin")
+	m.On(http.MethodGet, "/foo", nil).RespondOK(nil)
+	m.ExpectedRequests[1].response.header["x-foo"] = []string{"bar"}
+	unauthorized, ok := httptest.NewRecorder(), httptest.NewRecorder()
+
+	// Test
+	m.ServeHTTP(unauthorized, httptest.NewRequest(http.MethodGet, "/admin", http.NoBody))
+	m.ServeHTTP(ok, httptest.NewRequest(http.MethodGet, "/foo", http.NoBody))
+
+	// Assertions
+	assert.Equal(t, `Basic realm="admin"`, unauthorized.Result().Header.Get("WWW-Authenticate"))
+	assert.Equal(t, "bar", ok.Result().Header.Get("X-Foo"))
+	assert.NotContains(t, ok.Result().Header, "x-foo")
+}
+
+func TestMock_ServeHTTP_Recovered(t *testing.T) {
+	// Setup
+	m := new(Mock)
+	m.On(http.MethodGet, "/foo", nil).RespondOK(nil)
+	recorder := httptest.NewRecorder()
+
+	// Test
+	m.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/bar", http.NoBody))
+
+	// Assertions
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+	assert.Zero(t, m.inFlight.Load())
+}
+
+func TestMock_ServeHTTP_NotRecoverable(t *testing.T) {
+	// Setup
+	m := new(Mock).NotRecoverable()
+	m.On(http.MethodGet, "/foo", nil).RespondOK(nil)
+
+	defer func() {
+		r := recover()
+		// Assertions
+		assert.NotNil(t, r)
+		assert.Zero(t, m.inFlight.Load())
+	}()
+
+	// Test
+	m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/bar", http.NoBody))
+	t.Fatal("Did not expect to get here")
+}
+
 func TestMock_Requested(t *testing.T) {
 	// Setup
 	m := new(Mock).Test(t)
@@ -495,6 +1379,80 @@ func TestMock_Requested(t *testing.T) {
 	assert.Equal(t, 1, got.parent.totalRequests)
 }
 
+func TestMock_Requested_FailToCapture(t *testing.T) {
+	// Setup
+	var successfulRequestedCall int
+
+	mockT := &MockTestingT{}
+	m := new(Mock).Test(mockT)
+	var target struct{ Name string }
+	m.On(http.MethodPost, "https://test.com/foo", AnyBody).CaptureJSON(&target).RespondNoContent()
+
+	received := mustNewRequest(http.NewRequest(http.MethodPost, "https://test.com/foo", strings.NewReader(testBody)))
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("Did not expect to get here")
+		}
+		// Assertions
+		assert.Equal(t, "FailNow was called", r.(string))
+		assert.Equal(t, 1, mockT.failNowCount)
+		assert.Zero(t, successfulRequestedCall)
+	}()
+
+	// Test
+	m.Requested(received)
+	successfulRequestedCall++
+}
+
+func TestMock_Requested_Capture(t *testing.T) {
+	// Setup
+	m := new(Mock).Test(t)
+	var target struct{ Name string }
+	m.On(http.MethodPost, "https://test.com/foo", AnyBody).CaptureJSON(&target).RespondNoContent()
+
+	first := mustNewRequest(http.NewRequest(http.MethodPost, "https://test.com/foo", strings.NewReader(`{"Name": "foo"}`)))
+	second := mustNewRequest(http.NewRequest(http.MethodPost, "https://test.com/foo", strings.NewReader(`{"Name": "bar"}`)))
+
+	// Test
+	m.Requested(first)
+	gotFirst := target.Name
+	m.Requested(second)
+
+	// Assertions
+	assert.Equal(t, "foo", gotFirst)
+	assert.Equal(t, "bar", target.Name)
+
+	// Body should still be readable after capturing
+	gotBody, err := io.ReadAll(second.Body)
+	if err != nil {
+		t.Fatalf("unexpected error reading body: %v", err)
+	}
+	assert.Equal(t, `{"Name": "bar"}`, string(gotBody))
+}
+
+func TestMock_Requested_NoResponse(t *testing.T) {
+	// Setup
+	m := new(Mock).Test(t)
+	wantReq := m.On(http.MethodGet, "https://test.com/foo", nil)
+
+	received := mustNewRequest(http.NewRequest(http.MethodGet, "https://test.com/foo", http.NoBody))
+
+	// Test
+	got := m.Requested(received)
+
+	// Assertions
+	want := &Response{
+		parent:     wantReq,
+		statusCode: http.StatusOK,
+		header:     http.Header{},
+	}
+	assert.Equal(t, want, got)
+	assert.Nil(t, wantReq.response)
+	assert.Equal(t, 1, got.parent.totalRequests)
+}
+
 func TestMock_RequestedOnce(t *testing.T) {
 	// Setup
 	m := new(Mock).Test(t)
@@ -752,6 +1710,130 @@ func TestMock_AssertExpectations_Repeatability(t *testing.T) {
 	assert.True(t, m.AssertExpectations(mockT))
 }
 
+func TestMock_UnmetExpectations(t *testing.T) {
+	// Setup
+	m := new(Mock).Test(t)
+	m.On(http.MethodGet, "https://test.com/foo", nil).RespondOK(nil)
+	m.On(http.MethodGet, "https://test.com/bar", nil).Describe("fetch bar").RespondOK(nil).Twice()
+	m.On(http.MethodGet, "https://test.com/baz", nil).RespondOK(nil)
+
+	m.Requested(mustNewRequest(http.NewRequest(http.MethodGet, "https://test.com/foo", http.NoBody)))
+	bar := mustNewRequest(http.NewRequest(http.MethodGet, "https://test.com/bar", http.NoBody))
+	m.Requested(bar)
+
+	// Test
+	got := m.UnmetExpectations()
+
+	// Assertions
+	if assert.Len(t, got, 2) {
+		assert.Equal(t, "fetch bar", got[0].label)
+		assert.Equal(t, 1, got[0].NumberOfRequests())
+		assert.Equal(t, "/baz", got[1].url.Path)
+		assert.Zero(t, got[1].NumberOfRequests())
+	}
+
+	// The snapshot does not change as more requests are received
+	m.Requested(bar)
+	assert.Equal(t, 1, got[0].NumberOfRequests())
+	assert.Equal(t, 2, m.ExpectedRequests[1].NumberOfRequests())
+	got[1].Describe("changed")
+	assert.Empty(t, m.ExpectedRequests[2].label)
+	assert.Len(t, m.UnmetExpectations(), 1)
+}
+
+func TestMock_UnmetExpectations_AllMet(t *testing.T) {
+	// Setup
+	m := new(Mock).Test(t)
+	m.On(http.MethodGet, "https://test.com/foo", nil).RespondOK(nil)
+	m.Requested(mustNewRequest(http.NewRequest(http.MethodGet, "https://test.com/foo", http.NoBody)))
+
+	// Test
+	got := m.UnmetExpectations()
+
+	// Assertions
+	assert.Empty(t, got)
+}
+
+func TestMock_checkExpectation(t *testing.T) {
+	tests := []struct {
+		name          string
+		setup         func(m *Mock) *Request
+		requests      int
+		wantSatisfied bool
+		wantReason    string
+	}{
+		{
+			name: "not-requested",
+			setup: func(m *Mock) *Request {
+				return m.On(http.MethodGet, "test.com/foo", nil)
+			},
+			wantSatisfied: false,
+			wantReason:    "FAIL:\tGET test.com/foo\n\t(0) (Missing)",
+		},
+		{
+			name: "requested",
+			setup: func(m *Mock) *Request {
+				return m.On(http.MethodGet, "test.com/foo", nil)
+			},
+			requests:      1,
+			wantSatisfied: true,
+			wantReason:    "PASS:\tGET test.com/foo\n\t(0) (Missing)",
+		},
+		{
+			name: "matchers",
+			setup: func(m *Mock) *Request {
+				return m.On(http.MethodGet, "test.com/foo", nil).Matches(testRequestMatcherAlwaysPass)
+			},
+			wantSatisfied: false,
+			wantReason:    "FAIL:\tGET test.com/foo\n\t(0) (Missing)\n\tMatcher[0]: github.com/shawalli/httpmock.testRequestMatcherAlwaysPass",
+		},
+		{
+			name: "described",
+			setup: func(m *Mock) *Request {
+				return m.On(http.MethodGet, "test.com/foo", nil).Describe("fetch foo")
+			},
+			wantSatisfied: false,
+			wantReason:    "FAIL:\t\"fetch foo\" (GET test.com/foo)\n\t(0) (Missing)",
+		},
+		{
+			name: "times-not-met",
+			setup: func(m *Mock) *Request {
+				return m.On(http.MethodGet, "test.com/foo", nil).Times(3)
+			},
+			requests:      2,
+			wantSatisfied: false,
+			wantReason:    "FAIL:\tGET test.com/foo\n\t(0) (Missing)\n\tRequested: 2 out of 3 time(s)",
+		},
+		{
+			name: "times-met",
+			setup: func(m *Mock) *Request {
+				return m.On(http.MethodGet, "test.com/foo", nil).Times(3)
+			},
+			requests:      3,
+			wantSatisfied: true,
+			wantReason:    "PASS:\tGET test.com/foo\n\t(0) (Missing)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			m := new(Mock).Test(t)
+			expected := tt.setup(m)
+			for i := 0; i < tt.requests; i++ {
+				m.Requested(mustNewRequest(http.NewRequest(http.MethodGet, "test.com/foo", http.NoBody)))
+			}
+
+			// Test
+			gotSatisfied, gotReason := m.checkExpectation(expected)
+
+			// Assertions
+			assert.Equal(t, tt.wantSatisfied, gotSatisfied)
+			assert.Equal(t, tt.wantReason, gotReason)
+		})
+	}
+}
+
 func TestMock_AssertNumberOfRequests_FailToParsePath(t *testing.T) {
 	// Setup
 	mockT := new(MockTestingT)
@@ -947,6 +2029,108 @@ func TestMock_AssertNumberOfRequests(t *testing.T) {
 	}
 }
 
+func TestMock_AssertNumberOfRequestsFor_Mismatch(t *testing.T) {
+	// Setup
+	m := new(Mock)
+	expected := m.On(http.MethodPost, "test.com/submit", nil)
+	m.On(http.MethodPost, "test.com/other", nil)
+
+	received := mustNewRequest(http.NewRequest(http.MethodPost, "test.com/submit", http.NoBody))
+	m.Requested(received)
+	m.Requested(received)
+
+	mockT := new(MockTestingT)
+
+	// Test
+	got := m.AssertNumberOfRequestsFor(mockT, expected, 3)
+
+	// Assertions
+	assert.False(t, got)
+	assert.Equal(t, 1, mockT.errorfCount)
+}
+
+func TestMock_AssertNumberOfRequestsFor(t *testing.T) {
+	// Setup
+	m := new(Mock)
+	expected := m.On(http.MethodPost, "test.com/submit", nil)
+	other := m.On(http.MethodPost, "test.com/other", nil)
+
+	received := mustNewRequest(http.NewRequest(http.MethodPost, "test.com/submit", http.NoBody))
+	m.Requested(received)
+	m.Requested(received)
+	m.Requested(received)
+
+	mockT := new(MockTestingT)
+
+	// Test and Assertions
+	assert.True(t, m.AssertNumberOfRequestsFor(mockT, expected, 3))
+	assert.True(t, m.AssertNumberOfRequestsFor(mockT, other, 0))
+	assert.Zero(t, mockT.errorfCount)
+}
+
+func TestMock_trackCall(t *testing.T) {
+	// Setup
+	m := new(Mock)
+
+	// Test
+	doneA := m.trackCall()
+	doneB := m.trackCall()
+	doneA()
+	doneC := m.trackCall()
+	doneB()
+	doneC()
+	m.trackCall()()
+
+	// Assertions
+	assert.Equal(t, 2, m.MaxConcurrentCalls())
+	assert.Zero(t, m.inFlight.Load())
+}
+
+func TestMock_AssertMaxConcurrency(t *testing.T) {
+	tests := []struct {
+		name        string
+		max         int
+		want        bool
+		wantErrorfs int
+	}{
+		{
+			name: "under",
+			max:  3,
+			want: true,
+		},
+		{
+			name: "equal",
+			max:  2,
+			want: true,
+		},
+		{
+			name:        "over",
+			max:         1,
+			want:        false,
+			wantErrorfs: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			m := new(Mock)
+			done := m.trackCall()
+			m.trackCall()()
+			done()
+
+			mockT := new(MockTestingT)
+
+			// Test
+			got := m.AssertMaxConcurrency(mockT, tt.max)
+
+			// Assertions
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantErrorfs, mockT.errorfCount)
+		})
+	}
+}
+
 func TestMock_AssertRequested_FailToParsePath(t *testing.T) {
 	// Setup
 	mockT := new(MockTestingT)
@@ -0,0 +1,100 @@
+package httpmock
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var ErrPassthrough = errors.New("error passing request through to upstream")
+
+// Passthrough configures the [Mock] to forward received requests that do not
+// match any expected [Request] to an upstream server, instead of failing. The
+// method, path, query, headers, and body of the received request are
+// preserved, and the upstream's status code, headers, and body are returned to
+// the client. Requests are forwarded with [http.DefaultTransport].
+//
+// Passthrough takes precedence over [Mock.RespondDefault]. Requests that are
+// passed through are not matched against an expected [Request], so they do not
+// count towards [Mock.AssertExpectations] or the other request assertions.
+//
+//	Mock.Passthrough("https://api.example.com")
+func (m *Mock) Passthrough(upstream string) *Mock {
+	upstreamURL, err := url.Parse(upstream)
+	if err != nil {
+		m.fail("failed to parse passthrough url. Error: %v\n", err)
+	}
+
+	// The passthrough response is not owned by an expected request, but
+	// responses require a parent to access the mock
+	parent := newRequest(m, AnyMethod, &url.URL{}, AnyBody)
+	resp := &Response{
+		parent: parent,
+		writer: passthroughWriter(http.DefaultTransport, upstreamURL),
+	}
+	parent.response = resp
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.passthroughResponse = resp
+	return m
+}
+
+// passthroughWriter creates a [ResponseWriter] that forwards a received
+// [http.Request] to an upstream server using the given transport, and writes
+// the upstream's response.
+func passthroughWriter(transport http.RoundTripper, upstream *url.URL) ResponseWriter {
+	fn := func(w http.ResponseWriter, r *http.Request) (int, error) {
+		resp, err := forwardRequest(transport, upstream, r)
+		if err != nil {
+			return 0, err
+		}
+		defer resp.Body.Close()
+
+		h := w.Header()
+		for key, values := range resp.Header {
+			h[key] = values
+		}
+		w.WriteHeader(resp.StatusCode)
+
+		n, err := io.Copy(w, resp.Body)
+		if err != nil {
+			return int(n), ErrWriteReturnBody
+		}
+		return int(n), nil
+	}
+
+	return fn
+}
+
+// forwardRequest sends a copy of a received [http.Request] to an upstream
+// server using the given transport, preserving its method, path, query,
+// headers, and body. The caller must close the body of the upstream's response.
+func forwardRequest(transport http.RoundTripper, upstream *url.URL, r *http.Request) (*http.Response, error) {
+	body, err := SafeReadBody(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPassthrough, err)
+	}
+
+	target := *upstream
+	target.Path = strings.TrimSuffix(upstream.Path, "/") + r.URL.Path
+	target.RawPath = ""
+	target.RawQuery = r.URL.RawQuery
+
+	forward, err := http.NewRequestWithContext(r.Context(), r.Method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPassthrough, err)
+	}
+	forward.Header = r.Header.Clone()
+
+	resp, err := transport.RoundTrip(forward)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPassthrough, err)
+	}
+	return resp, nil
+}
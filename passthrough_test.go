@@ -0,0 +1,174 @@
+package httpmock
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMock_Passthrough_BadURL(t *testing.T) {
+	// Setup
+	var successfulPassthroughCall int
+
+	mockT := new(MockTestingT)
+	m := new(Mock).Test(mockT)
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("Did not expect to get here")
+		}
+		// Assertions
+		assert.Equal(t, "FailNow was called", r.(string))
+		assert.Equal(t, 1, mockT.failNowCount)
+		assert.Zero(t, successfulPassthroughCall)
+	}()
+
+	// Test
+	m.Passthrough("\r")
+	successfulPassthroughCall++
+}
+
+func TestMock_Passthrough(t *testing.T) {
+	// Setup
+	m := new(Mock)
+
+	// Test
+	got := m.Passthrough("https://test.com")
+
+	// Assertions
+	assert.Equal(t, m, got)
+	assert.NotNil(t, m.passthroughResponse)
+	assert.NotNil(t, m.passthroughResponse.writer)
+	assert.Equal(t, m, m.passthroughResponse.parent.parent)
+	assert.Empty(t, m.ExpectedRequests)
+}
+
+func Test_passthroughWriter_FailToReadBody(t *testing.T) {
+	// Setup
+	upstream, _ := url.Parse("https://test.com")
+	fn := passthroughWriter(http.DefaultTransport, upstream)
+
+	// Test
+	gotN, gotErr := fn(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/foo", &badReader{}))
+
+	// Assertions
+	assert.Zero(t, gotN)
+	assert.ErrorIs(t, gotErr, ErrPassthrough)
+}
+
+func Test_passthroughWriter_FailToRoundTrip(t *testing.T) {
+	// Setup
+	upstream := httptest.NewServer(http.NotFoundHandler())
+	upstreamURL, _ := url.Parse(upstream.URL)
+	upstream.Close()
+	fn := passthroughWriter(http.DefaultTransport, upstreamURL)
+
+	// Test
+	gotN, gotErr := fn(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/foo", http.NoBody))
+
+	// Assertions
+	assert.Zero(t, gotN)
+	assert.ErrorIs(t, gotErr, ErrPassthrough)
+}
+
+func Test_passthroughWriter(t *testing.T) {
+	// Setup
+	var gotUpstreamRequest *http.Request
+	var gotUpstreamBody []byte
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUpstreamRequest = r
+		gotUpstreamBody, _ = io.ReadAll(r.Body)
+
+		w.Header().Set("X-Upstream", "true")
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(testBody))
+	}))
+	defer upstream.Close()
+
+	upstreamURL, _ := url.Parse(upstream.URL + "/api/")
+	fn := passthroughWriter(http.DefaultTransport, upstreamURL)
+
+	received := httptest.NewRequest(http.MethodPut, "/foo/1234?force=true", strings.NewReader(`{"foo": "bar"}`))
+	received.Header.Set("X-Request-Id", "5678")
+	recorder := httptest.NewRecorder()
+
+	// Test
+	gotN, gotErr := fn(recorder, received)
+
+	// Assertions
+	assert.NoError(t, gotErr)
+	assert.Equal(t, len(testBody), gotN)
+	assert.Equal(t, http.StatusAccepted, recorder.Code)
+	assert.Equal(t, "true", recorder.Header().Get("X-Upstream"))
+	assert.Equal(t, testBody, recorder.Body.String())
+
+	assert.Equal(t, http.MethodPut, gotUpstreamRequest.Method)
+	assert.Equal(t, "/api/foo/1234", gotUpstreamRequest.URL.Path)
+	assert.Equal(t, "force=true", gotUpstreamRequest.URL.RawQuery)
+	assert.Equal(t, "5678", gotUpstreamRequest.Header.Get("X-Request-Id"))
+	assert.Equal(t, `{"foo": "bar"}`, string(gotUpstreamBody))
+}
+
+func TestServer_defaultHandler_Passthrough(t *testing.T) {
+	// Setup
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		_, _ = fmt.Fprintf(w, "upstream %s %s", r.Method, r.URL.Path)
+	}))
+	defer upstream.Close()
+
+	s := NewServer()
+	defer s.Close()
+	s.On(http.MethodGet, "/foo/1234", nil).RespondOK([]byte(testBody))
+	s.Mock.Passthrough(upstream.URL)
+
+	tests := []struct {
+		name           string
+		method         string
+		wantStatusCode int
+		wantBody       string
+	}{
+		{
+			name:           "matched",
+			method:         http.MethodGet,
+			wantStatusCode: http.StatusOK,
+			wantBody:       testBody,
+		},
+		{
+			name:           "passthrough",
+			method:         http.MethodDelete,
+			wantStatusCode: http.StatusTeapot,
+			wantBody:       "upstream DELETE /foo/1234",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Test
+			test := mustNewRequest(http.NewRequest(tt.method, fmt.Sprintf("%s/foo/1234", s.URL), http.NoBody))
+			got, err := s.Client().Do(test)
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotBody, err := io.ReadAll(got.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer got.Body.Close()
+
+			// Assertions
+			assert.Equal(t, tt.wantStatusCode, got.StatusCode)
+			assert.Equal(t, tt.wantBody, string(gotBody))
+		})
+	}
+
+	s.Mock.AssertNumberOfRequests(t, http.MethodGet, "/foo/1234", 1)
+	s.Mock.AssertNotRequested(t, http.MethodDelete, "/foo/1234", nil)
+}
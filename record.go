@@ -0,0 +1,206 @@
+package httpmock
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+var ErrRecording = errors.New("error saving or loading recording")
+
+// recording is the persisted form of the interactions captured by
+// [Mock.Record], as written by [Mock.SaveRecording].
+type recording struct {
+	Interactions []interaction `json:"interactions"`
+}
+
+// interaction is a received request and the upstream response that was
+// recorded for it.
+type interaction struct {
+	// The HTTP method that was requested.
+	Method string `json:"method"`
+
+	// The URL that was requested, which is usually only a path and query.
+	URL string `json:"url"`
+
+	// The upstream's response.
+	Response recordedResponse `json:"response"`
+}
+
+// recordedResponse is an upstream response captured by [Mock.Record]. The body
+// is base64-encoded when persisted, so binary bodies are preserved.
+type recordedResponse struct {
+	// The HTTP status code.
+	Status int `json:"status"`
+
+	// The response headers.
+	Header http.Header `json:"header,omitempty"`
+
+	// The response body.
+	Body []byte `json:"body,omitempty"`
+}
+
+// Record configures the [Mock] to forward received requests that do not match
+// any expected [Request] to an upstream server, like [Mock.Passthrough], and to
+// record the upstream's response. Each recorded response is registered as an
+// expected [Request] for the same method and URL, with any body, so that it is
+// replayed for later identical requests. The recorded [Request] counts as
+// received once.
+//
+// Recorded interactions can be persisted with [Mock.SaveRecording], and
+// replayed without network access with [Mock.LoadRecording].
+//
+//	Mock.Record("https://api.example.com")
+func (m *Mock) Record(upstream string) *Mock {
+	upstreamURL, err := url.Parse(upstream)
+	if err != nil {
+		m.fail("failed to parse record url. Error: %v\n", err)
+	}
+
+	// The record response is not owned by an expected request, but responses
+	// require a parent to access the mock
+	parent := newRequest(m, AnyMethod, &url.URL{}, AnyBody)
+	resp := &Response{
+		parent: parent,
+		writer: recordWriter(m, http.DefaultTransport, upstreamURL),
+	}
+	parent.response = resp
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.passthroughResponse = resp
+	return m
+}
+
+// recordWriter creates a [ResponseWriter] that forwards a received
+// [http.Request] to an upstream server using the given transport, writes the
+// upstream's response, and records it on the [Mock].
+func recordWriter(m *Mock, transport http.RoundTripper, upstream *url.URL) ResponseWriter {
+	fn := func(w http.ResponseWriter, r *http.Request) (int, error) {
+		resp, err := forwardRequest(transport, upstream, r)
+		if err != nil {
+			return 0, err
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %v", ErrPassthrough, err)
+		}
+
+		recorded := interaction{
+			Method: r.Method,
+			URL:    r.URL.String(),
+			Response: recordedResponse{
+				Status: resp.StatusCode,
+				Header: resp.Header.Clone(),
+				Body:   body,
+			},
+		}
+		expected, err := recorded.register(m)
+		if err != nil {
+			return 0, err
+		}
+
+		// The request was received before it was expected, so count it now
+		m.mutex.Lock()
+		expected.totalRequests++
+		received := newRequest(m, r.Method, r.URL, ReadBody(r))
+		receivedResponse := *expected.response
+		received.response = &receivedResponse
+		m.Requests = append(m.Requests, *received)
+		m.recordings = append(m.recordings, recorded)
+		m.mutex.Unlock()
+
+		h := w.Header()
+		for key, values := range resp.Header {
+			h[key] = values
+		}
+		w.WriteHeader(resp.StatusCode)
+
+		n, err := w.Write(body)
+		if err != nil {
+			return n, ErrWriteReturnBody
+		}
+		return n, nil
+	}
+
+	return fn
+}
+
+// register adds the interaction to a [Mock] as an expected [Request] for its
+// method and URL, which responds with the recorded response.
+func (i interaction) register(m *Mock) (*Request, error) {
+	r, err := m.OnE(i.Method, i.URL, AnyBody)
+	if err != nil {
+		return nil, err
+	}
+	resp := r.Respond(i.Response.Status, i.Response.Body)
+	for key, values := range i.Response.Header {
+		if len(values) > 0 {
+			resp.Header(key, values[0], values[1:]...)
+		}
+	}
+	return r, nil
+}
+
+// SaveRecording writes the interactions recorded with [Mock.Record] to a JSON
+// file at path, so that they can be replayed with [Mock.LoadRecording].
+// Response bodies are base64-encoded.
+//
+//	Mock.SaveRecording("testdata/users.json")
+func (m *Mock) SaveRecording(path string) error {
+	m.mutex.Lock()
+	rec := recording{Interactions: append([]interaction{}, m.recordings...)}
+	m.mutex.Unlock()
+
+	data, err := json.MarshalIndent(rec, "", "\t")
+	if err != nil {
+		return fmt.Errorf("%w %q: %v", ErrRecording, path, err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("%w %q: %v", ErrRecording, path, err)
+	}
+	return nil
+}
+
+// LoadRecording registers the interactions in a file written by
+// [Mock.SaveRecording] as expected [Request]'s, so that they are replayed
+// without network access. Each [Request] matches the recorded method and URL,
+// with any body.
+//
+//	Mock.LoadRecording("testdata/users.json")
+func (m *Mock) LoadRecording(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("%w %q: %v", ErrRecording, path, err)
+	}
+
+	var rec recording
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&rec); err != nil {
+		return fmt.Errorf("%w %q: %v", ErrRecording, path, err)
+	}
+	for i, recorded := range rec.Interactions {
+		if recorded.Method != AnyMethod && !validMethod(recorded.Method) {
+			return fmt.Errorf("%w %q: interaction %d: invalid method %q", ErrRecording, path, i, recorded.Method)
+		}
+		if _, err := url.Parse(recorded.URL); err != nil {
+			return fmt.Errorf("%w %q: interaction %d: failed to parse url: %v", ErrRecording, path, i, err)
+		}
+	}
+
+	for i, recorded := range rec.Interactions {
+		if _, err := recorded.register(m); err != nil {
+			return fmt.Errorf("%w %q: interaction %d: %v", ErrRecording, path, i, err)
+		}
+	}
+	return nil
+}
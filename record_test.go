@@ -0,0 +1,220 @@
+package httpmock
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMock_Record_BadURL(t *testing.T) {
+	// Setup
+	var successfulRecordCall int
+
+	mockT := new(MockTestingT)
+	m := new(Mock).Test(mockT)
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("Did not expect to get here")
+		}
+		// Assertions
+		assert.Equal(t, "FailNow was called", r.(string))
+		assert.Equal(t, 1, mockT.failNowCount)
+		assert.Zero(t, successfulRecordCall)
+	}()
+
+	// Test
+	m.Record("://bad")
+	successfulRecordCall++
+}
+
+func TestServer_Record(t *testing.T) {
+	// Setup
+	var upstreamCalls atomic.Int64
+	binaryBody := []byte{0x00, 0xff, 0x10, 0x80}
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamCalls.Add(1)
+		if r.URL.Path == "/binary" {
+			w.Header().Set("Content-Type", "application/octet-stream")
+			_, _ = w.Write(binaryBody)
+			return
+		}
+		w.Header().Set("X-Upstream", "true")
+		w.WriteHeader(http.StatusTeapot)
+		_, _ = fmt.Fprintf(w, "upstream %s %s", r.Method, r.URL.RequestURI())
+	}))
+	defer upstream.Close()
+
+	s := NewServer().Record(upstream.URL)
+	defer s.Close()
+
+	get := func(path string) (*http.Response, []byte) {
+		t.Helper()
+
+		resp, err := s.Client().Get(s.URLf(path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, body
+	}
+
+	// Test
+	gotFirst, gotFirstBody := get("/foo?id=1234")
+	gotReplay, gotReplayBody := get("/foo?id=1234")
+	_, gotBinaryBody := get("/binary")
+
+	// Assertions
+	assert.Equal(t, int64(2), upstreamCalls.Load())
+	assert.Equal(t, http.StatusTeapot, gotFirst.StatusCode)
+	assert.Equal(t, "true", gotFirst.Header.Get("X-Upstream"))
+	assert.Equal(t, "upstream GET /foo?id=1234", string(gotFirstBody))
+	assert.Equal(t, http.StatusTeapot, gotReplay.StatusCode)
+	assert.Equal(t, "true", gotReplay.Header.Get("X-Upstream"))
+	assert.Equal(t, gotFirstBody, gotReplayBody)
+	assert.Equal(t, binaryBody, gotBinaryBody)
+	s.Mock.AssertExpectations(t)
+	s.Mock.AssertNumberOfRequests(t, http.MethodGet, "/foo?id=1234", 2)
+
+	// Save and replay without the upstream
+	path := filepath.Join(t.TempDir(), "recording.json")
+	assert.NoError(t, s.Mock.SaveRecording(path))
+	upstream.Close()
+
+	replay := NewServer()
+	defer replay.Close()
+	assert.NoError(t, replay.Mock.LoadRecording(path))
+
+	gotLoaded, err := replay.Client().Get(replay.URLf("/foo?id=1234"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gotLoaded.Body.Close()
+	gotLoadedBody, err := io.ReadAll(gotLoaded.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusTeapot, gotLoaded.StatusCode)
+	assert.Equal(t, gotFirstBody, gotLoadedBody)
+
+	gotLoadedBinary, err := replay.Client().Get(replay.URLf("/binary"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gotLoadedBinary.Body.Close()
+	gotLoadedBinaryBody, err := io.ReadAll(gotLoadedBinary.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, binaryBody, gotLoadedBinaryBody)
+	assert.Equal(t, "application/octet-stream", gotLoadedBinary.Header.Get("Content-Type"))
+}
+
+func TestMock_SaveRecording_Format(t *testing.T) {
+	// Setup
+	m := new(Mock)
+	m.recordings = []interaction{
+		{
+			Method:   http.MethodGet,
+			URL:      "/binary",
+			Response: recordedResponse{Status: http.StatusOK, Body: []byte{0x00, 0xff}},
+		},
+	}
+	path := filepath.Join(t.TempDir(), "recording.json")
+
+	// Test
+	gotErr := m.SaveRecording(path)
+
+	// Assertions
+	assert.NoError(t, gotErr)
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+	"interactions": [
+		{
+			"method": "GET",
+			"url": "/binary",
+			"response": {
+				"status": 200,
+				"body": "AP8="
+			}
+		}
+	]
+}
+`
+	assert.Equal(t, want, string(got))
+}
+
+func TestMock_LoadRecording_Error(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		wantErr  string
+	}{
+		{
+			name:     "invalid-json",
+			contents: `{`,
+			wantErr:  "unexpected EOF",
+		},
+		{
+			name:     "unknown-field",
+			contents: `{"interactions": [{"method": "GET", "url": "/foo", "status": 200}]}`,
+			wantErr:  `json: unknown field "status"`,
+		},
+		{
+			name:     "bad-url",
+			contents: `{"interactions": [{"method": "GET", "url": "/foo"}, {"method": "GET", "url": "://bad"}]}`,
+			wantErr:  "interaction 1: failed to parse url",
+		},
+		{
+			name:     "empty-method",
+			contents: `{"interactions": [{"method": "GET", "url": "/foo"}, {"method": "", "url": "/bar"}]}`,
+			wantErr:  `interaction 1: invalid method ""`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			path := filepath.Join(t.TempDir(), "recording.json")
+			if err := os.WriteFile(path, []byte(tt.contents), 0o600); err != nil {
+				t.Fatalf("unexpected error writing test file: %v", err)
+			}
+			m := new(Mock)
+
+			// Test
+			gotErr := m.LoadRecording(path)
+
+			// Assertions
+			assert.ErrorIs(t, gotErr, ErrRecording)
+			assert.ErrorContains(t, gotErr, tt.wantErr)
+			assert.Empty(t, m.ExpectedRequests)
+		})
+	}
+}
+
+func TestMock_LoadRecording_MissingFile(t *testing.T) {
+	// Setup
+	m := new(Mock)
+
+	// Test
+	gotErr := m.LoadRecording(filepath.Join(t.TempDir(), "missing.json"))
+
+	// Assertions
+	assert.ErrorIs(t, gotErr, ErrRecording)
+	assert.ErrorContains(t, gotErr, "no such file or directory")
+}
@@ -2,23 +2,33 @@ package httpmock
 
 import (
 	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"os"
 	"reflect"
 	"runtime"
+	"slices"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
 var (
-	ErrReadBody = errors.New("error reading body")
+	ErrReadBody   = errors.New("error reading body")
+	ErrDecodeBody = errors.New("error decoding body")
 
 	AnyMethod = "httpmock.AnyMethod"
+	AnyURL    = "httpmock.AnyURL"
 	AnyBody   = []byte("httpmock.AnyBody")
 
 	cmpoptSortMaps                  = cmpopts.SortMaps(func(a, b string) bool { return a < b })
@@ -27,6 +37,7 @@ var (
 	cmpoptIgnoreURLUnexportedFields = cmpopts.IgnoreUnexported(url.URL{})
 
 	fmtAnyBody  = "(AnyBody)"
+	fmtAnyURL   = "(AnyURL)"
 	fmtMissing  = "(Missing)"
 	fmtNotEqual = "!="
 	fmtEqual    = "=="
@@ -64,6 +75,43 @@ type Request struct {
 
 	// Amount of times this request has been received.
 	totalRequests int
+
+	// Response to return when this request is received after its
+	// repeatability has been used up.
+	exhaustedResponse *Response
+
+	// Requests that must be satisfied before this request will match.
+	requires []*Request
+
+	// Pointer that the JSON body of a matching request is decoded into.
+	capture any
+
+	// Human-readable label that identifies the request in diagnostics.
+	label string
+
+	// Minimum amount of time since the request was registered, or last
+	// matched, before it will match again. 0 means there is no minimum.
+	after time.Duration
+
+	// When MatchAfter was called, which is the earliest time that after is
+	// measured from.
+	registered time.Time
+
+	// When this request was last matched by a received request.
+	lastMatched time.Time
+
+	// Conditional responses that override the status code and body of the
+	// response, in the order they were added.
+	conditions []responseCondition
+}
+
+// responseCondition is a status code and body to respond with when a received
+// request has a header with the given value.
+type responseCondition struct {
+	key        string
+	value      string
+	statusCode int
+	body       []byte
 }
 
 func newRequest(parent *Mock, method string, URL *url.URL, body []byte) *Request {
@@ -75,6 +123,69 @@ func newRequest(parent *Mock, method string, URL *url.URL, body []byte) *Request
 	}
 }
 
+// clone returns a copy of a [Request] that belongs to the given [Mock], with
+// its request count reset. The mutex of the original [Request]'s [Mock] must be
+// held.
+func (r *Request) clone(parent *Mock) *Request {
+	u := *r.url
+	c := &Request{
+		parent:        parent,
+		method:        r.method,
+		url:           &u,
+		body:          slices.Clone(r.body),
+		matchers:      slices.Clone(r.matchers),
+		repeatability: r.configuredTimes(),
+		requires:      slices.Clone(r.requires),
+		capture:       r.capture,
+		label:         r.label,
+		after:         r.after,
+		conditions:    slices.Clone(r.conditions),
+	}
+	if r.after > 0 {
+		c.registered = time.Now()
+	}
+	if r.response != nil {
+		c.response = r.response.clone(c)
+	}
+	if r.exhaustedResponse != nil {
+		c.exhaustedResponse = r.exhaustedResponse.clone(c)
+	}
+	return c
+}
+
+// snapshot returns a copy of a [Request] with its current request count, which
+// does not change as more requests are received. The copy belongs to the same
+// [Mock]. The mutex of the [Mock] must be held.
+func (r *Request) snapshot() *Request {
+	c := *r
+	u := *r.url
+	c.url = &u
+	c.body = slices.Clone(r.body)
+	c.matchers = slices.Clone(r.matchers)
+	c.requires = slices.Clone(r.requires)
+	c.conditions = slices.Clone(r.conditions)
+	if r.response != nil {
+		c.response = r.response.clone(&c)
+	}
+	if r.exhaustedResponse != nil {
+		c.exhaustedResponse = r.exhaustedResponse.clone(&c)
+	}
+	return &c
+}
+
+// configuredTimes returns the number of times that the [Request] was configured
+// to be received with [Request.Times], before any requests were received. 0
+// means it is not limited.
+func (r *Request) configuredTimes() int {
+	switch {
+	case r.repeatability > 0:
+		return r.repeatability + r.totalRequests
+	case r.repeatability < 0:
+		return r.totalRequests
+	}
+	return 0
+}
+
 // lock is a convenience method to lock the parent [Mock]'s mutex.
 func (r *Request) lock() {
 	r.parent.mutex.Lock()
@@ -118,6 +229,287 @@ func (r *Request) RespondNoContent() *Response {
 	return r.Respond(http.StatusNoContent, nil)
 }
 
+// RespondStatus is a convenience method that sets the status code, and writes
+// only the status line and headers, with no body. For 204 No Content and 304
+// Not Modified, which must not have a body, no Content-Length header is sent
+// either. If a body is configured anyway, such as with [Response.SetBody] or a
+// hook added with [Mock.ResponseHook], it is not written, and the test fails
+// if the status is 204, 205, or 304.
+//
+//	Mock.On(http.MethodDelete, "/some/path/1234", nil).RespondStatus(http.StatusNoContent)
+func (r *Request) RespondStatus(statusCode int) *Response {
+	resp := r.Respond(statusCode, nil)
+
+	r.lock()
+	defer r.unlock()
+
+	resp.statusOnly = true
+	return resp
+}
+
+// RespondRedirect is a convenience method that sets a 3xx status code and the
+// Location header, with an empty body. The location is used verbatim, so it
+// may be relative or absolute. The test fails if the status code is not a
+// redirect.
+//
+//	Mock.On(http.MethodGet, "/old/path", nil).RespondRedirect(http.StatusFound, "/new/path")
+func (r *Request) RespondRedirect(statusCode int, location string) *Response {
+	if statusCode < 300 || statusCode > 399 {
+		r.parent.fail("invalid redirect status code %d for request %s\n", statusCode, r.summary())
+	}
+
+	return r.Respond(statusCode, nil).Header("Location", location)
+}
+
+// RespondUnauthorized is a convenience method that sets the status code as 401
+// and a WWW-Authenticate header that challenges the client for basic auth in
+// the given realm.
+//
+//	Mock.On(http.MethodGet, "/some/path", nil).RespondUnauthorized("admin")
+func (r *Request) RespondUnauthorized(realm string) *Response {
+	return r.Respond(http.StatusUnauthorized, nil).Header(http.CanonicalHeaderKey("WWW-Authenticate"), fmt.Sprintf("Basic realm=%q", realm))
+}
+
+// RespondJSON is a convenience method that sets the status code and a body
+// containing the JSON encoding of v. Unless a Content-Type header is set on
+// the [Response], it is written as "application/json".
+//
+//	Mock.On(http.GetMethod, "/some/path").RespondJSON(http.StatusOK, map[string]string{"foo": "bar"})
+func (r *Request) RespondJSON(statusCode int, v any) *Response {
+	body, err := json.Marshal(v)
+	if err != nil {
+		r.parent.fail("failed to marshal JSON response for request %s. Error: %v\n", r.summary(), err)
+	}
+
+	resp := r.Respond(statusCode, body)
+
+	r.lock()
+	defer r.unlock()
+
+	resp.contentType = "application/json"
+
+	return resp
+}
+
+// ErrorEnvelope builds the value that [Request.RespondError] encodes as the JSON
+// body of an error response. By default, it returns {"error": message}. It may
+// be replaced to match the error conventions of the service being mocked, and
+// is evaluated when [Request.RespondError] is called.
+//
+//	httpmock.ErrorEnvelope = func(statusCode int, message string) any {
+//		return map[string]any{"error": message, "code": statusCode}
+//	}
+var ErrorEnvelope = func(statusCode int, message string) any {
+	return map[string]string{"error": message}
+}
+
+// RespondError is a convenience method that sets the status code and a JSON
+// body containing an error message, as built by [ErrorEnvelope]. Like
+// [Request.RespondJSON], it is written as "application/json".
+//
+//	Mock.On(http.MethodGet, "/some/path", nil).RespondError(http.StatusBadRequest, "invalid id")
+func (r *Request) RespondError(statusCode int, message string) *Response {
+	return r.RespondJSON(statusCode, ErrorEnvelope(statusCode, message))
+}
+
+// RespondFile is a convenience method that sets the status code and a body
+// read from the file at path. Relative paths are resolved against the current
+// working directory, which for tests is the package directory. Unless a
+// Content-Type header is set on the [Response], it is detected from the file
+// contents with [http.DetectContentType].
+//
+//	Mock.On(http.GetMethod, "/some/path").RespondFile(http.StatusOK, "testdata/response.json")
+func (r *Request) RespondFile(statusCode int, path string) *Response {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		r.parent.fail("failed to read response file %q for request %s. Error: %v\n", path, r.summary(), err)
+	}
+
+	resp := r.Respond(statusCode, body)
+
+	r.lock()
+	defer r.unlock()
+
+	resp.contentType = http.DetectContentType(body)
+
+	return resp
+}
+
+// RespondTemplate is a convenience method that sets the status code and a body
+// rendered from a [text/template] when the response is written. The template
+// is executed with a [TemplateData] describing the received request, so it
+// may reference the request's method, URL, query, headers, and body.
+//
+//	Mock.On(http.GetMethod, "/some/path").RespondTemplate(http.StatusOK, `{"id": "{{.Query.Get "id"}}"}`)
+func (r *Request) RespondTemplate(statusCode int, tmpl string) *Response {
+	t, err := template.New(fmt.Sprintf("%s %s", r.method, r.url)).Parse(tmpl)
+	if err != nil {
+		r.parent.fail("failed to parse response template for request %s. Error: %v\n", r.summary(), err)
+	}
+
+	resp := r.Respond(statusCode, nil)
+
+	r.lock()
+	defer r.unlock()
+
+	resp.template = t
+
+	return resp
+}
+
+// RespondEcho is a convenience method that sets the status code and a body
+// that is the body of the received request, with the received request's
+// Content-Type. The received body is read once and shared with any
+// [RequestMatcher]'s that read it. A compressed body is echoed decoded, as it
+// was matched, unless decoding is disabled with [Mock.DecodeRequestBodies] or
+// its Content-Encoding is not supported, in which case it is echoed as it was
+// received, along with its Content-Encoding.
+//
+//	Mock.On(http.MethodPost, "/echo", AnyBody).RespondEcho(http.StatusOK)
+func (r *Request) RespondEcho(statusCode int) *Response {
+	resp := r.Respond(statusCode, nil)
+
+	r.lock()
+	defer r.unlock()
+
+	resp.echo = true
+
+	return resp
+}
+
+// RespondETag is a convenience method that sets a status code of 200, the body,
+// and an ETag header, and that responds with a 304 Not Modified without a body
+// when the received request's If-None-Match header matches the ETag of a GET or
+// HEAD request. This verifies a client's cache revalidation. For other methods,
+// a matching If-None-Match responds with a 412 Precondition Failed instead, as
+// required by RFC 9110. The ETag is quoted if it is not already, and may be a
+// weak "W/" ETag, in which case the part after "W/" is quoted. If-None-Match is
+// compared with the weak comparison of RFC 9110, so "W/" prefixes are ignored,
+// and "*" matches any ETag.
+//
+// Since the body is the same for every request, conditional requests are
+// answered the same way every time. Headers set on the returned [Response] are
+// sent with both the 200 and the 304.
+//
+//	Mock.On(http.MethodGet, "/users/1234", nil).RespondETag(`"v1"`, []byte(`{"id": 1234}`))
+func (r *Request) RespondETag(etag string, body []byte) *Response {
+	resp := r.Respond(http.StatusOK, body)
+
+	r.lock()
+	defer r.unlock()
+
+	// Only the opaque part is quoted, so that a weak ETag stays weak
+	opaque, weak := strings.CutPrefix(etag, "W/")
+	if !strings.HasPrefix(opaque, `"`) || !strings.HasSuffix(opaque, `"`) || len(opaque) < 2 {
+		opaque = `"` + opaque + `"`
+	}
+	if weak {
+		opaque = "W/" + opaque
+	}
+	resp.etag = opaque
+
+	return resp
+}
+
+// RespondLastModified is a convenience method that sets a status code of 200,
+// the body, and a Last-Modified header of t, and that responds with a 304 Not
+// Modified without a body when the received request's If-Modified-Since header
+// is not before t. Since HTTP dates have a resolution of one second, t is
+// truncated to the second. Like [http.ServeContent], If-Modified-Since is
+// only used for GET and HEAD requests, and is ignored if the request has an
+// If-None-Match header and the [Response] has an ETag.
+//
+//	Mock.On(http.MethodGet, "/report", nil).RespondLastModified(modified, []byte("a,b,c"))
+func (r *Request) RespondLastModified(t time.Time, body []byte) *Response {
+	resp := r.Respond(http.StatusOK, body)
+
+	r.lock()
+	defer r.unlock()
+
+	resp.lastModified = t.Truncate(time.Second)
+
+	return resp
+}
+
+// RespondGate is a convenience method that sets the status code and body, and
+// returns a channel that holds each received request open until it is
+// released. [Response.Write] blocks until it receives a value from the channel,
+// so each send releases exactly one request, and closing the channel releases
+// every current and future request. If the request's context is canceled
+// first, such as when a client times out, nothing is written.
+//
+// The channel may be used from any goroutine. Since a send blocks until a
+// request is waiting for it, a test should only send once it knows a request
+// was received, such as with [Mock.WaitForCalls], or send from another
+// goroutine.
+//
+//	resp, release := Mock.On(http.MethodPost, "/jobs", AnyBody).RespondGate(http.StatusAccepted, nil)
+//	go client.SubmitJob()
+//	Mock.WaitForCalls(1, time.Second)
+//	// ...
+//	release <- struct{}{}
+func (r *Request) RespondGate(statusCode int, body []byte) (*Response, chan<- struct{}) {
+	resp := r.Respond(statusCode, body)
+	gate := make(chan struct{})
+
+	r.lock()
+	defer r.unlock()
+
+	resp.gate = gate
+
+	return resp, gate
+}
+
+// RespondStream is a convenience method that sets the status code and a body
+// that is streamed to the client in chunks. Each chunk is written and flushed,
+// waiting interval between chunks. If the client disconnects, the stream
+// stops.
+//
+//	Mock.On(http.GetMethod, "/events").RespondStream(http.StatusOK, [][]byte{[]byte("data: 1\n\n"), []byte("data: 2\n\n")}, time.Second)
+func (r *Request) RespondStream(statusCode int, chunks [][]byte, interval time.Duration) *Response {
+	resp := r.Respond(statusCode, nil)
+
+	r.lock()
+	defer r.unlock()
+
+	resp.chunks = chunks
+	if resp.chunks == nil {
+		resp.chunks = [][]byte{}
+	}
+	resp.interval = interval
+
+	return resp
+}
+
+// RespondNDJSON is a convenience method that sets the status code and streams
+// the JSON encoding of each item as newline-delimited JSON (NDJSON), like
+// [Request.RespondStream]. Each item is written on its own line and flushed,
+// waiting interval between items, which is useful for testing clients that
+// parse a stream incrementally. If the client disconnects, the stream stops.
+// Unless a Content-Type header is set on the [Response], it is written as
+// "application/x-ndjson". The test fails if an item cannot be encoded.
+//
+//	Mock.On(http.MethodGet, "/events", nil).RespondNDJSON(http.StatusOK, []any{event1, event2}, time.Second)
+func (r *Request) RespondNDJSON(statusCode int, items []any, interval time.Duration) *Response {
+	chunks := make([][]byte, 0, len(items))
+	for i, item := range items {
+		line, err := json.Marshal(item)
+		if err != nil {
+			r.parent.fail("failed to marshal NDJSON item %d for request %s. Error: %v\n", i, r.summary(), err)
+		}
+		chunks = append(chunks, append(line, '\n'))
+	}
+
+	resp := r.RespondStream(statusCode, chunks, interval)
+
+	r.lock()
+	defer r.unlock()
+
+	resp.contentType = "application/x-ndjson"
+
+	return resp
+}
+
 // RespondUsing overrides the [Request.Respond] functionality by allowing a
 // custom writer to be invoked instead of the typical writing functionality.
 //
@@ -137,6 +529,25 @@ func (r *Request) RespondUsing(writer ResponseWriter) *Response {
 	return resp
 }
 
+// RespondFunc is a convenience method for [Request.RespondUsing], which allows a
+// standard [http.HandlerFunc] to write the entire response. The [Mock] still
+// counts the request and enforces [Request.Times]. Panics in fn are handled by
+// the [Server] in the same manner as unexpected requests.
+//
+// Since fn does not report the number of bytes it wrote, [Response.Write]
+// returns 0 for the number of bytes written.
+//
+//	Mock.On(http.MethodGet, "/some/path", nil).RespondFunc(func(w http.ResponseWriter, r *http.Request) {
+//		w.Header().Set("X-Request-Id", r.Header.Get("X-Request-Id"))
+//		w.WriteHeader(http.StatusAccepted)
+//	})
+func (r *Request) RespondFunc(fn http.HandlerFunc) *Response {
+	return r.RespondUsing(func(w http.ResponseWriter, received *http.Request) (int, error) {
+		fn(w, received)
+		return 0, nil
+	})
+}
+
 // Once indicates that the [Mock] should only return the response once.
 //
 //	Mock.On(http.MethodDelete, "/some/path/1234").Once()
@@ -163,6 +574,136 @@ func (r *Request) Times(i int) *Request {
 	return r
 }
 
+// RespondExhausted specifies a response to return when the [Request] matches a
+// received [http.Request] after the number of times set with [Request.Times]
+// has been used up, rather than failing the test. This is useful for modeling
+// servers that enforce a quota. Requests that receive the exhausted response
+// do not count towards [Request.NumberOfRequests] or the request assertions.
+// If it is not set, exhausted requests fall through as usual.
+//
+//	Mock.On(http.MethodPost, "/some/path", AnyBody).RespondOK(nil).Once().RespondExhausted(http.StatusTooManyRequests, nil)
+func (r *Request) RespondExhausted(statusCode int, body []byte) *Response {
+	resp := newResponse(
+		r,
+		statusCode,
+		body,
+	)
+
+	r.lock()
+	defer r.unlock()
+
+	r.exhaustedResponse = resp
+
+	return resp
+}
+
+// RespondWhenHeader adds a conditional response to the [Request], which
+// responds with statusCode and body instead of the base response when the
+// received request has a header with the given value. The header key is
+// canonicalized. It can be called multiple times, and the conditions are
+// evaluated in the order they were added, so the first matching condition is
+// used. If no condition matches, the base response set with [Request.Respond]
+// or a similar method is used, which may be set before or after the
+// conditions. Without a base response, a 200 with an empty body is used.
+//
+// A matching condition takes precedence over the status code and body of the
+// base response, including those chosen by [Request.RespondSequence],
+// [Request.RespondByAccept], [Request.RespondTemplate], [Request.RespondEcho],
+// and [Request.RespondStream], and does not advance a sequence. The headers and
+// modifiers of the base response, such as [Response.Header] and
+// [Response.Delay], still apply. Conditions are ignored by
+// [Request.RespondUsing] and the response set with [Request.RespondExhausted].
+//
+//	Mock.On(http.MethodGet, "/feature", nil).
+//		RespondWhenHeader("X-Feature", "on", http.StatusOK, []byte("enabled")).
+//		Respond(http.StatusForbidden, nil)
+func (r *Request) RespondWhenHeader(key string, value string, statusCode int, body []byte) *Request {
+	r.lock()
+	defer r.unlock()
+
+	r.conditions = append(r.conditions, responseCondition{
+		key:        http.CanonicalHeaderKey(key),
+		value:      value,
+		statusCode: statusCode,
+		body:       body,
+	})
+	return r
+}
+
+// matchCondition returns the first of the conditions that matches the received
+// request, or nil if none match.
+func matchCondition(conditions []responseCondition, received *http.Request) *responseCondition {
+	for i, condition := range conditions {
+		if slices.Contains(received.Header.Values(condition.key), condition.value) {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+// Describe attaches a human-readable label to the [Request], which is included
+// in diagnostics, such as unexpected request failures and
+// [Mock.AssertExpectations]. This makes it easier to tell apart [Request]'s with
+// similar methods and URLs. Without a label, they are identified by their
+// method and URL.
+//
+//	Mock.On(http.MethodPost, "/users", AnyBody).Describe("create admin user").MatchJSONBody(admin)
+func (r *Request) Describe(label string) *Request {
+	r.lock()
+	defer r.unlock()
+
+	r.label = label
+	return r
+}
+
+// MatchAfter indicates that the [Request] only matches a received
+// [http.Request] that arrives at least d after MatchAfter is called, which is
+// usually when the [Request] is registered, or after the previous received
+// request that it matched. For a [Mock.Clone], d is measured from when the
+// clone was made. This verifies the timing of a client's retries or backoff
+// without sleeping in the test. A request that arrives too early does not
+// match, and the diagnostic includes the time that had elapsed.
+//
+// Times are measured with the system clock when requests are received, so
+// scheduling delays and clock adjustments affect the result. Use a d that is
+// comfortably shorter than the expected delay.
+//
+//	Mock.On(http.MethodGet, "/some/path", nil).Once().Respond(http.StatusServiceUnavailable, nil)
+//	Mock.On(http.MethodGet, "/some/path", nil).MatchAfter(time.Second).RespondOK(nil)
+func (r *Request) MatchAfter(d time.Duration) *Request {
+	r.lock()
+	defer r.unlock()
+
+	r.after = d
+	r.registered = time.Now()
+	return r
+}
+
+// NumberOfRequests returns the number of times the Request has been matched
+// by a received [http.Request].
+func (r *Request) NumberOfRequests() int {
+	r.lock()
+	defer r.unlock()
+
+	return r.totalRequests
+}
+
+// CaptureJSON decodes the JSON body of each received [http.Request] that
+// matches the Request into target, which must be a pointer. If the Request is
+// matched more than once, target holds the body of the most recent match. The
+// decode happens under the [Mock]'s lock, so target should only be read after
+// the requests have completed. If the body cannot be decoded, the test fails.
+//
+//	var user User
+//	Mock.On(http.MethodPost, "/users", AnyBody).CaptureJSON(&user).RespondNoContent()
+func (r *Request) CaptureJSON(target any) *Request {
+	r.lock()
+	defer r.unlock()
+
+	r.capture = target
+	return r
+}
+
 // Matches adds one or more [RequestMatcher]'s to the Request.
 // [RequestMatcher]'s are called in FIFO order after the HTTP method, URL, and
 // body have been matched.
@@ -216,6 +757,111 @@ func SafeReadBody(received *http.Request) ([]byte, error) {
 	return body, nil
 }
 
+// readLimitedBody reads the body of a [http.Request] and resets it, like
+// [SafeReadBody], but buffers at most limit bytes. If the body is larger,
+// tooLarge is true, the first limit bytes are returned, and the body is reset
+// to stream the bytes that were read followed by the remainder, so that it may
+// still be forwarded. A limit of 0 or less does not limit the body.
+func readLimitedBody(received *http.Request, limit int64) (body []byte, tooLarge bool, err error) {
+	if limit <= 0 {
+		body, err = SafeReadBody(received)
+		return body, false, err
+	}
+
+	// Read one byte past the limit to detect a larger body
+	original := received.Body
+	body, err = io.ReadAll(io.LimitReader(original, limit+1))
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %v", ErrReadBody, err)
+	}
+	if int64(len(body)) <= limit {
+		original.Close()
+		received.Body = io.NopCloser(bytes.NewBuffer(body))
+		return body, false, nil
+	}
+
+	received.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), original), original}
+	return body[:limit], true, nil
+}
+
+// decodeBody decodes a body that was compressed with the given
+// Content-Encoding, which may list several encodings in the order they were
+// applied. At most limit bytes of the decoded body are returned, and tooLarge
+// is true if it is larger. A limit of 0 or less does not limit the body. If the
+// body is not encoded, or uses an encoding that is not supported, nil is
+// returned.
+func decodeBody(encoding string, body []byte, limit int64) (decoded []byte, tooLarge bool, err error) {
+	var encodings []string
+	for _, e := range strings.Split(encoding, ",") {
+		e = strings.ToLower(strings.TrimSpace(e))
+		switch e {
+		case "", "identity":
+		case "gzip", "x-gzip", "deflate":
+			encodings = append(encodings, e)
+		default:
+			return nil, false, nil
+		}
+	}
+	if len(encodings) == 0 {
+		return nil, false, nil
+	}
+
+	decoded = body
+	for i := len(encodings) - 1; i >= 0; i-- {
+		var reader io.ReadCloser
+		switch encodings[i] {
+		case "gzip", "x-gzip":
+			reader, err = gzip.NewReader(bytes.NewReader(decoded))
+		case "deflate":
+			// Deflate should be zlib-wrapped, but some clients send raw
+			// deflate data
+			reader, err = zlib.NewReader(bytes.NewReader(decoded))
+			if err != nil {
+				reader, err = flate.NewReader(bytes.NewReader(decoded)), nil
+			}
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("%w with %s: %v", ErrDecodeBody, encodings[i], err)
+		}
+
+		var r io.Reader = reader
+		if limit > 0 {
+			// Read one byte past the limit to detect a larger body
+			r = io.LimitReader(reader, limit+1)
+		}
+		decoded, err = io.ReadAll(r)
+		reader.Close()
+		if err != nil {
+			return nil, false, fmt.Errorf("%w with %s: %v", ErrDecodeBody, encodings[i], err)
+		}
+		if limit > 0 && int64(len(decoded)) > limit {
+			return decoded[:limit], true, nil
+		}
+	}
+	return decoded, false, nil
+}
+
+// ReadBody reads the body of a [http.Request] and resets the [http.Request]'s
+// body so that it may be read again afterward, like [SafeReadBody]. If the body
+// cannot be read, nil is returned. It is intended for use in functions passed
+// to [Request.MatchFunc], where errors cannot be returned.
+func ReadBody(received *http.Request) []byte {
+	body, err := SafeReadBody(received)
+	if err != nil {
+		return nil
+	}
+	return body
+}
+
+// matcherName returns the fully-qualified function name of a
+// [RequestMatcher].
+func matcherName(fn RequestMatcher) string {
+	return runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+}
+
 // diffMissing is a convenience function to provide a standard string if a
 // string is found to be empty.
 func diffMissing(v string) (string, bool) {
@@ -323,6 +969,12 @@ func (r *Request) diffURL(received *http.Request) (string, int) {
 
 	expected, eok := diffMissing(r.url.String())
 	actual, aok := diffMissing(received.URL.String())
+	if expected == AnyURL && aok {
+		output = fmt.Sprintf("\t%d: PASS:  %s == %s\n", 1, actual, fmtAnyURL)
+		return output, differences
+	} else if expected == AnyURL {
+		expected = fmtAnyURL
+	}
 	if !eok || !aok {
 		output = fmt.Sprintf("\t%d: FAIL:  %s == %s\n", 1, actual, expected)
 		differences++
@@ -451,34 +1103,125 @@ func (r *Request) diff(received *http.Request) (string, int) {
 	output := "\n"
 	var differences int
 
+	for _, result := range r.results(received) {
+		output += result.raw
+		differences += result.Differences
+	}
+
+	return output, differences
+}
+
+// results compares a [Request] to a received [http.Request] and responds with
+// the result of each comparison, in the order they appear in the diff.
+func (r *Request) results(received *http.Request) []MatcherResult {
+	var results []MatcherResult
+	add := func(name string, index int, raw string, differences int) {
+		output := strings.TrimPrefix(raw, fmt.Sprintf("\t%d: ", index))
+		results = append(results, MatcherResult{
+			Index:       index,
+			Name:        name,
+			Passed:      differences == 0,
+			Differences: differences,
+			Output:      strings.TrimRight(output, "\n"),
+			raw:         raw,
+		})
+	}
+
 	o, d := r.diffMethod(received)
-	output += o
-	differences += d
+	add("method", 0, o, d)
 
 	o, d = r.diffURL(received)
-	output += o
-	differences += d
+	add("url", 1, o, d)
 
 	o, d = r.diffBody(received)
-	output += o
-	differences += d
+	add("body", 2, o, d)
 
 	// 0, 1, and 2 are reserved for HTTP method, URL, and body
 	baseMatchIndex := 3
 	for i, fn := range r.matchers {
 		o, d := fn(received)
+		add("matcher", baseMatchIndex+i, fmt.Sprintf("\t%d: %s\n", (baseMatchIndex+i), o), d)
+	}
 
-		output += fmt.Sprintf("\t%d: %s\n", (baseMatchIndex + i), o)
-		differences += d
+	if len(r.requires) > 0 {
+		o, d = r.diffOrder()
+		index := baseMatchIndex + len(r.matchers)
+		add("order", index, fmt.Sprintf("\t%d: %s\n", index, o), d)
 	}
 
-	return output, differences
+	if r.after > 0 {
+		o, d = r.diffAfter()
+		index := len(results)
+		add("after", index, fmt.Sprintf("\t%d: %s\n", index, o), d)
+	}
+
+	return results
+}
+
+// satisfied reports whether a [Request] has been received at least once and,
+// if it was limited with [Request.Times], the configured number of times.
+func (r *Request) satisfied() bool {
+	return r.totalRequests > 0 && r.repeatability <= 0
+}
+
+// diffOrder detects whether the requests that must precede a [Request] have
+// been satisfied. It responds with a formatted string naming the first
+// unsatisfied request and the number of unsatisfied requests.
+func (r *Request) diffOrder() (string, int) {
+	var output string
+	var differences int
+
+	for _, required := range r.requires {
+		if required.satisfied() {
+			continue
+		}
+		if differences == 0 {
+			output = fmt.Sprintf("FAIL:  order: expected %s to be requested first", required.summary())
+		}
+		differences++
+	}
+	if differences > 0 {
+		return output, differences
+	}
+
+	last := r.requires[len(r.requires)-1]
+	return fmt.Sprintf("PASS:  order: after %s", last.summary()), 0
+}
+
+// diffAfter detects whether enough time has elapsed since a [Request] was
+// registered, or last matched, for it to match again. It responds with a
+// formatted string of the elapsed time and the number of differences.
+func (r *Request) diffAfter() (string, int) {
+	since, reference := r.registered, "registered"
+	if !r.lastMatched.IsZero() {
+		since, reference = r.lastMatched, "previous call"
+	}
+
+	elapsed := time.Since(since).Round(time.Microsecond)
+	if elapsed < r.after {
+		return fmt.Sprintf("FAIL:  after: %s since %s < %s", elapsed, reference, r.after), 1
+	}
+	return fmt.Sprintf("PASS:  after: %s since %s >= %s", elapsed, reference, r.after), 0
+}
+
+// summary returns a one-line description of a [Request] for diagnostics,
+// which is its method and URL, preceded by its label if it was set with
+// [Request.Describe].
+func (r *Request) summary() string {
+	if r.label == "" {
+		return fmt.Sprintf("%s %s", r.method, r.url)
+	}
+	return fmt.Sprintf("%q (%s %s)", r.label, r.method, r.url)
 }
 
 // String computes a formatted string representing a [Request].
 func (r *Request) String() string {
 	var output []string
 
+	if r.label != "" {
+		output = append(output, fmt.Sprintf("Description: %s", r.label))
+	}
+
 	e := r.method
 	if r.method == "" {
 		e = fmtMissing
@@ -489,6 +1232,8 @@ func (r *Request) String() string {
 
 	if e = r.url.String(); e == "" {
 		output = append(output, fmt.Sprintf("URL: %s", fmtMissing))
+	} else if e == AnyURL {
+		output = append(output, fmt.Sprintf("URL: %s", fmtAnyURL))
 	} else {
 		output = append(output, fmt.Sprintf("URL: %s", e))
 
@@ -531,8 +1276,11 @@ func (r *Request) String() string {
 	}
 
 	for i, fn := range r.matchers {
-		fnName := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
-		output = append(output, fmt.Sprintf("Matcher[%d]: %s", i, fnName))
+		output = append(output, fmt.Sprintf("Matcher[%d]: %s", i, matcherName(fn)))
+	}
+
+	for _, required := range r.requires {
+		output = append(output, fmt.Sprintf("After: %s", required.summary()))
 	}
 
 	return strings.Join(output, "\n")
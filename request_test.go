@@ -2,12 +2,19 @@ package httpmock
 
 import (
 	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -228,6 +235,520 @@ func TestRequest_RespondNoContent(t *testing.T) {
 	assert.Equal(t, got, r.response)
 }
 
+func TestRequest_RespondStatus(t *testing.T) {
+	// Setup
+	r := &Request{parent: new(Mock)}
+
+	// Test
+	got := r.RespondStatus(http.StatusNotModified)
+
+	// Assertions
+	want := &Response{
+		parent:     r,
+		header:     http.Header{},
+		statusCode: http.StatusNotModified,
+		statusOnly: true,
+	}
+	assert.Equal(t, want, got)
+	assert.Equal(t, got, r.response)
+}
+
+func TestRequest_RespondRedirect_BadStatusCode(t *testing.T) {
+	// Setup
+	var successfulRespondCall int
+
+	mockT := new(MockTestingT)
+	r := &Request{parent: new(Mock).Test(mockT), url: &url.URL{Path: "/foo"}}
+
+	defer func() {
+		rc := recover()
+		if rc == nil {
+			t.Fatal("Did not expect to get here")
+		}
+		// Assertions
+		assert.Equal(t, "FailNow was called", rc.(string))
+		assert.Equal(t, 1, mockT.failNowCount)
+		assert.Zero(t, successfulRespondCall)
+		assert.Nil(t, r.response)
+	}()
+
+	// Test
+	r.RespondRedirect(http.StatusOK, "/bar")
+	successfulRespondCall++
+}
+
+func TestRequest_RespondRedirect(t *testing.T) {
+	// Setup
+	r := &Request{parent: new(Mock)}
+
+	// Test
+	got := r.RespondRedirect(http.StatusFound, "../bar?baz=1")
+
+	// Assertions
+	want := &Response{
+		parent:     r,
+		header:     http.Header{"Location": []string{"../bar?baz=1"}},
+		statusCode: http.StatusFound,
+	}
+	assert.Equal(t, want, got)
+	assert.Equal(t, got, r.response)
+}
+
+func TestRequest_RespondUnauthorized(t *testing.T) {
+	// Setup
+	r := &Request{parent: new(Mock)}
+
+	// Test
+	got := r.RespondUnauthorized("admin")
+
+	// Assertions
+	want := &Response{
+		parent:     r,
+		header:     http.Header{"Www-Authenticate": []string{`Basic realm="admin"`}},
+		statusCode: http.StatusUnauthorized,
+	}
+	assert.Equal(t, want, got)
+	assert.Equal(t, got, r.response)
+}
+
+func TestRequest_RespondUnauthorized_Client(t *testing.T) {
+	// Setup
+	s := NewServer()
+	defer s.Close()
+	s.On(http.MethodGet, "/admin", nil).RespondUnauthorized("admin")
+
+	m := new(Mock).Test(t)
+	m.On(http.MethodGet, "/admin", nil).RespondUnauthorized("admin")
+
+	tests := []struct {
+		name   string
+		client *http.Client
+		url    string
+	}{
+		{
+			name:   "server",
+			client: s.Client(),
+			url:    s.URLf("/admin"),
+		},
+		{
+			name:   "round-tripper",
+			client: m.Client(),
+			url:    "https://test.com/admin",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Test
+			got, err := tt.client.Get(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer got.Body.Close()
+
+			// Assertions
+			assert.Equal(t, http.StatusUnauthorized, got.StatusCode)
+			assert.Equal(t, `Basic realm="admin"`, got.Header.Get("WWW-Authenticate"))
+		})
+	}
+}
+
+func TestRequest_RespondJSON_FailToMarshal(t *testing.T) {
+	// Setup
+	var successfulRespondCall int
+
+	mockT := new(MockTestingT)
+	m := new(Mock).Test(mockT)
+	r := m.On(http.MethodGet, "https://test.com/foo", nil)
+
+	defer func() {
+		rc := recover()
+		if rc == nil {
+			t.Fatal("Did not expect to get here")
+		}
+		// Assertions
+		assert.Equal(t, "FailNow was called", rc.(string))
+		assert.Equal(t, 1, mockT.failNowCount)
+		assert.Zero(t, successfulRespondCall)
+		assert.Nil(t, r.response)
+	}()
+
+	// Test
+	r.RespondJSON(http.StatusOK, make(chan int))
+	successfulRespondCall++
+}
+
+func TestRequest_RespondJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    any
+		wantBody []byte
+	}{
+		{
+			name:     "nil",
+			value:    nil,
+			wantBody: []byte(`null`),
+		},
+		{
+			name:     "map",
+			value:    map[string]string{"foo": "bar"},
+			wantBody: []byte(`{"foo":"bar"}`),
+		},
+		{
+			name: "struct",
+			value: struct {
+				ID   int    `json:"id"`
+				Name string `json:"name"`
+			}{ID: 1234, Name: "foo"},
+			wantBody: []byte(`{"id":1234,"name":"foo"}`),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			r := &Request{parent: new(Mock).Test(t)}
+
+			// Test
+			got := r.RespondJSON(http.StatusCreated, tt.value)
+
+			// Assertions
+			want := &Response{
+				parent:      r,
+				statusCode:  http.StatusCreated,
+				header:      http.Header{},
+				body:        tt.wantBody,
+				contentType: "application/json",
+			}
+			assert.Equal(t, want, got)
+			assert.Equal(t, got, r.response)
+		})
+	}
+}
+
+func TestRequest_RespondError(t *testing.T) {
+	// Setup
+	r := &Request{parent: new(Mock).Test(t)}
+
+	// Test
+	got := r.RespondError(http.StatusBadRequest, "invalid id")
+
+	// Assertions
+	want := &Response{
+		parent:      r,
+		statusCode:  http.StatusBadRequest,
+		header:      http.Header{},
+		body:        []byte(`{"error":"invalid id"}`),
+		contentType: "application/json",
+	}
+	assert.Equal(t, want, got)
+	assert.Equal(t, got, r.response)
+}
+
+func TestRequest_RespondError_ErrorEnvelope(t *testing.T) {
+	// Setup
+	defaultEnvelope := ErrorEnvelope
+	defer func() { ErrorEnvelope = defaultEnvelope }()
+	ErrorEnvelope = func(statusCode int, message string) any {
+		return map[string]any{"error": message, "code": statusCode}
+	}
+
+	r := &Request{parent: new(Mock).Test(t)}
+
+	// Test
+	got := r.RespondError(http.StatusNotFound, "not found")
+
+	// Assertions
+	assert.Equal(t, []byte(`{"code":404,"error":"not found"}`), got.body)
+}
+
+func TestRequest_RespondFile_FailToRead(t *testing.T) {
+	// Setup
+	var successfulRespondCall int
+
+	mockT := new(MockTestingT)
+	m := new(Mock).Test(mockT)
+	r := m.On(http.MethodGet, "https://test.com/foo", nil)
+
+	defer func() {
+		rc := recover()
+		if rc == nil {
+			t.Fatal("Did not expect to get here")
+		}
+		// Assertions
+		assert.Equal(t, "FailNow was called", rc.(string))
+		assert.Equal(t, 1, mockT.failNowCount)
+		assert.Zero(t, successfulRespondCall)
+		assert.Nil(t, r.response)
+	}()
+
+	// Test
+	r.RespondFile(http.StatusOK, filepath.Join(t.TempDir(), "missing.json"))
+	successfulRespondCall++
+}
+
+func TestRequest_RespondFile(t *testing.T) {
+	tests := []struct {
+		name            string
+		contents        []byte
+		wantContentType string
+	}{
+		{
+			name:            "text",
+			contents:        []byte(testBody),
+			wantContentType: "text/plain; charset=utf-8",
+		},
+		{
+			name:            "html",
+			contents:        []byte(`<html><body>Hello World!</body></html>`),
+			wantContentType: "text/html; charset=utf-8",
+		},
+		{
+			name:            "binary",
+			contents:        []byte{0x00, 0x01, 0x02, 0x03},
+			wantContentType: "application/octet-stream",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			r := &Request{parent: new(Mock).Test(t)}
+
+			path := filepath.Join(t.TempDir(), "response")
+			if err := os.WriteFile(path, tt.contents, 0o600); err != nil {
+				t.Fatalf("unexpected error writing test file: %v", err)
+			}
+
+			// Test
+			got := r.RespondFile(http.StatusOK, path)
+
+			// Assertions
+			want := &Response{
+				parent:      r,
+				statusCode:  http.StatusOK,
+				header:      http.Header{},
+				body:        tt.contents,
+				contentType: tt.wantContentType,
+			}
+			assert.Equal(t, want, got)
+			assert.Equal(t, got, r.response)
+		})
+	}
+}
+
+func TestRequest_RespondTemplate_FailToParse(t *testing.T) {
+	// Setup
+	var successfulRespondCall int
+
+	mockT := new(MockTestingT)
+	m := new(Mock).Test(mockT)
+	r := m.On(http.MethodGet, "https://test.com/foo", nil)
+
+	defer func() {
+		rc := recover()
+		if rc == nil {
+			t.Fatal("Did not expect to get here")
+		}
+		// Assertions
+		assert.Equal(t, "FailNow was called", rc.(string))
+		assert.Equal(t, 1, mockT.failNowCount)
+		assert.Zero(t, successfulRespondCall)
+		assert.Nil(t, r.response)
+	}()
+
+	// Test
+	r.RespondTemplate(http.StatusOK, `{{.Method`)
+	successfulRespondCall++
+}
+
+func TestRequest_RespondTemplate(t *testing.T) {
+	// Setup
+	r := &Request{parent: new(Mock).Test(t), method: http.MethodGet, url: &url.URL{Path: "/foo"}}
+
+	// Test
+	got := r.RespondTemplate(http.StatusOK, `{{.Method}}`)
+
+	// Assertions
+	assert.Equal(t, got, r.response)
+	assert.Equal(t, http.StatusOK, got.statusCode)
+	assert.Nil(t, got.body)
+	assert.NotNil(t, got.template)
+}
+
+func TestRequest_RespondEcho(t *testing.T) {
+	// Setup
+	r := &Request{parent: new(Mock).Test(t), method: http.MethodPost, url: &url.URL{Path: "/foo"}}
+
+	// Test
+	got := r.RespondEcho(http.StatusCreated)
+
+	// Assertions
+	assert.Equal(t, got, r.response)
+	assert.Equal(t, http.StatusCreated, got.statusCode)
+	assert.Nil(t, got.body)
+	assert.True(t, got.echo)
+}
+
+func TestRequest_RespondETag(t *testing.T) {
+	tests := []struct {
+		name     string
+		etag     string
+		wantETag string
+	}{
+		{
+			name:     "quoted",
+			etag:     `"v1"`,
+			wantETag: `"v1"`,
+		},
+		{
+			name:     "unquoted",
+			etag:     "v1",
+			wantETag: `"v1"`,
+		},
+		{
+			name:     "weak",
+			etag:     `W/"v1"`,
+			wantETag: `W/"v1"`,
+		},
+		{
+			name:     "weak-unquoted",
+			etag:     "W/v1",
+			wantETag: `W/"v1"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			r := &Request{parent: new(Mock).Test(t), method: http.MethodGet, url: &url.URL{Path: "/foo"}}
+
+			// Test
+			got := r.RespondETag(tt.etag, []byte(testBody))
+
+			// Assertions
+			assert.Equal(t, got, r.response)
+			assert.Equal(t, http.StatusOK, got.statusCode)
+			assert.Equal(t, []byte(testBody), got.body)
+			assert.Equal(t, tt.wantETag, got.etag)
+		})
+	}
+}
+
+func TestRequest_RespondLastModified(t *testing.T) {
+	// Setup
+	r := &Request{parent: new(Mock).Test(t), method: http.MethodGet, url: &url.URL{Path: "/foo"}}
+	modified := time.Date(2024, time.May, 1, 12, 30, 15, 500, time.UTC)
+
+	// Test
+	got := r.RespondLastModified(modified, []byte(testBody))
+
+	// Assertions
+	assert.Equal(t, got, r.response)
+	assert.Equal(t, http.StatusOK, got.statusCode)
+	assert.Equal(t, []byte(testBody), got.body)
+	assert.Equal(t, time.Date(2024, time.May, 1, 12, 30, 15, 0, time.UTC), got.lastModified)
+}
+
+func TestRequest_RespondGate(t *testing.T) {
+	// Setup
+	r := &Request{parent: new(Mock).Test(t), method: http.MethodPost, url: &url.URL{Path: "/foo"}}
+
+	// Test
+	got, gotRelease := r.RespondGate(http.StatusAccepted, []byte(testBody))
+
+	// Assertions
+	assert.Equal(t, got, r.response)
+	assert.Equal(t, http.StatusAccepted, got.statusCode)
+	assert.Equal(t, []byte(testBody), got.body)
+	assert.NotNil(t, gotRelease)
+	assert.NotNil(t, got.gate)
+}
+
+func TestRequest_RespondStream(t *testing.T) {
+	tests := []struct {
+		name       string
+		chunks     [][]byte
+		wantChunks [][]byte
+	}{
+		{
+			name:       "chunks",
+			chunks:     [][]byte{[]byte("foo"), []byte("bar")},
+			wantChunks: [][]byte{[]byte("foo"), []byte("bar")},
+		},
+		{
+			name:       "nil-chunks",
+			wantChunks: [][]byte{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			r := &Request{parent: new(Mock).Test(t)}
+
+			// Test
+			got := r.RespondStream(http.StatusOK, tt.chunks, time.Second)
+
+			// Assertions
+			want := &Response{
+				parent:     r,
+				statusCode: http.StatusOK,
+				header:     http.Header{},
+				chunks:     tt.wantChunks,
+				interval:   time.Second,
+			}
+			assert.Equal(t, want, got)
+			assert.Equal(t, got, r.response)
+		})
+	}
+}
+
+func TestRequest_RespondNDJSON(t *testing.T) {
+	// Setup
+	r := &Request{parent: new(Mock).Test(t)}
+
+	// Test
+	got := r.RespondNDJSON(http.StatusOK, []any{map[string]int{"id": 1}, "two", nil}, time.Second)
+
+	// Assertions
+	want := &Response{
+		parent:      r,
+		statusCode:  http.StatusOK,
+		header:      http.Header{},
+		contentType: "application/x-ndjson",
+		chunks:      [][]byte{[]byte("{\"id\":1}\n"), []byte("\"two\"\n"), []byte("null\n")},
+		interval:    time.Second,
+	}
+	assert.Equal(t, want, got)
+	assert.Equal(t, got, r.response)
+}
+
+func TestRequest_RespondNDJSON_FailToMarshal(t *testing.T) {
+	// Setup
+	var successfulRespondCall int
+
+	mockT := new(MockTestingT)
+	m := new(Mock).Test(mockT)
+	r := m.On(http.MethodGet, "https://test.com/foo", nil)
+
+	defer func() {
+		rc := recover()
+		if rc == nil {
+			t.Fatal("Did not expect to get here")
+		}
+		// Assertions
+		assert.Equal(t, "FailNow was called", rc.(string))
+		assert.Equal(t, 1, mockT.failNowCount)
+		assert.Zero(t, successfulRespondCall)
+		assert.Nil(t, r.response)
+	}()
+
+	// Test
+	r.RespondNDJSON(http.StatusOK, []any{"one", make(chan int)}, 0)
+	successfulRespondCall++
+}
+
 func TestRequest_RespondUsing(t *testing.T) {
 	// Setup
 	r := &Request{parent: new(Mock)}
@@ -248,19 +769,43 @@ func TestRequest_RespondUsing(t *testing.T) {
 	assert.Empty(t, got.body)
 	assert.NotNil(t, got.writer)
 
-	// Instead of comparing function pointers, run the function and assert its
-	// output.
+	// Instead of comparing function pointers, run the function and assert its
+	// output.
+	recorder := httptest.NewRecorder()
+	gotN, gotErr := got.writer(recorder, &http.Request{})
+	assert.Equal(t, 13, gotN)
+	assert.Nil(t, gotErr)
+	gotResult := recorder.Result()
+	gotResultBody, err := io.ReadAll(gotResult.Body)
+	if err != nil {
+		t.Fatalf("unexpected error reading response: %v", err)
+	}
+	defer gotResult.Body.Close()
+	assert.Equal(t, "And stay out!", string(gotResultBody))
+}
+
+func TestRequest_RespondFunc(t *testing.T) {
+	// Setup
+	r := &Request{parent: new(Mock)}
+
+	testFunc := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(r.URL.Path))
+	}
+
+	// Test
+	got := r.RespondFunc(testFunc)
+
+	// Assertions
+	assert.Equal(t, got, r.response)
+	assert.NotNil(t, got.writer)
+
 	recorder := httptest.NewRecorder()
-	gotN, gotErr := got.writer(recorder, &http.Request{})
-	assert.Equal(t, 13, gotN)
+	gotN, gotErr := got.writer(recorder, httptest.NewRequest(http.MethodGet, "/foo", http.NoBody))
+	assert.Zero(t, gotN)
 	assert.Nil(t, gotErr)
-	gotResult := recorder.Result()
-	gotResultBody, err := io.ReadAll(gotResult.Body)
-	if err != nil {
-		t.Fatalf("unexpected error reading response: %v", err)
-	}
-	defer gotResult.Body.Close()
-	assert.Equal(t, "And stay out!", string(gotResultBody))
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	assert.Equal(t, "/foo", recorder.Body.String())
 }
 
 func TestRequest_Once(t *testing.T) {
@@ -296,6 +841,82 @@ func TestRequest_Times(t *testing.T) {
 	assert.Equal(t, 4, r.repeatability)
 }
 
+func TestRequest_RespondExhausted(t *testing.T) {
+	// Setup
+	r := &Request{parent: new(Mock)}
+
+	// Test
+	got := r.RespondExhausted(http.StatusTooManyRequests, []byte(testBody))
+
+	// Assertions
+	want := &Response{
+		parent:     r,
+		header:     http.Header{},
+		statusCode: http.StatusTooManyRequests,
+		body:       []byte(testBody),
+	}
+	assert.Equal(t, want, got)
+	assert.Equal(t, got, r.exhaustedResponse)
+	assert.Nil(t, r.response)
+}
+
+func TestRequest_RespondWhenHeader(t *testing.T) {
+	// Setup
+	r := &Request{parent: new(Mock)}
+
+	// Test
+	got := r.RespondWhenHeader("x-feature", "on", http.StatusOK, []byte(testBody)).
+		RespondWhenHeader("X-Feature", "beta", http.StatusAccepted, nil)
+
+	// Assertions
+	want := []responseCondition{
+		{key: "X-Feature", value: "on", statusCode: http.StatusOK, body: []byte(testBody)},
+		{key: "X-Feature", value: "beta", statusCode: http.StatusAccepted},
+	}
+	assert.Equal(t, r, got)
+	assert.Equal(t, want, r.conditions)
+	assert.Nil(t, r.response)
+}
+
+func TestRequest_Describe(t *testing.T) {
+	// Setup
+	r := &Request{parent: new(Mock), method: http.MethodGet, url: &url.URL{Path: "/foo"}}
+
+	// Test
+	gotSummary := r.summary()
+	got := r.Describe("fetch foo")
+
+	// Assertions
+	assert.Equal(t, r, got)
+	assert.Equal(t, "fetch foo", r.label)
+	assert.Equal(t, "GET /foo", gotSummary)
+	assert.Equal(t, `"fetch foo" (GET /foo)`, r.summary())
+}
+
+func TestRequest_NumberOfRequests(t *testing.T) {
+	// Setup
+	r := Request{parent: new(Mock), totalRequests: 3}
+
+	// Test
+	got := r.NumberOfRequests()
+
+	// Assertions
+	assert.Equal(t, 3, got)
+}
+
+func TestRequest_CaptureJSON(t *testing.T) {
+	// Setup
+	r := &Request{parent: new(Mock)}
+	var target map[string]any
+
+	// Test
+	got := r.CaptureJSON(&target)
+
+	// Assertions
+	assert.Equal(t, r, got)
+	assert.Equal(t, &target, r.capture)
+}
+
 func TestRequest_Matches(t *testing.T) {
 	// Setup
 	r := Request{parent: new(Mock)}
@@ -336,6 +957,239 @@ func TestRequest_Matches(t *testing.T) {
 	}
 }
 
+func TestReadBody(t *testing.T) {
+	// Setup
+	received := mustNewRequest(http.NewRequest(http.MethodPost, "https://test.com/foo", strings.NewReader(testBody)))
+
+	// Test
+	got := ReadBody(received)
+	gotAgain := ReadBody(received)
+
+	// Assertions
+	assert.Equal(t, []byte(testBody), got)
+	assert.Equal(t, []byte(testBody), gotAgain)
+}
+
+func TestReadBody_FailToReadBody(t *testing.T) {
+	// Setup
+	received := mustNewRequest(http.NewRequest(http.MethodPost, "https://test.com/foo", &badReader{}))
+
+	// Test
+	got := ReadBody(received)
+
+	// Assertions
+	assert.Nil(t, got)
+}
+
+func Test_readLimitedBody(t *testing.T) {
+	tests := []struct {
+		name         string
+		limit        int64
+		wantBody     []byte
+		wantTooLarge bool
+	}{
+		{
+			name:     "unlimited",
+			limit:    0,
+			wantBody: []byte(testBody),
+		},
+		{
+			name:     "under-limit",
+			limit:    100,
+			wantBody: []byte(testBody),
+		},
+		{
+			name:     "at-limit",
+			limit:    int64(len(testBody)),
+			wantBody: []byte(testBody),
+		},
+		{
+			name:         "over-limit",
+			limit:        5,
+			wantBody:     []byte("Hello"),
+			wantTooLarge: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			received := mustNewRequest(http.NewRequest(http.MethodPost, "https://test.com/foo", strings.NewReader(testBody)))
+
+			// Test
+			gotBody, gotTooLarge, gotErr := readLimitedBody(received, tt.limit)
+
+			// Assertions
+			assert.NoError(t, gotErr)
+			assert.Equal(t, tt.wantBody, gotBody)
+			assert.Equal(t, tt.wantTooLarge, gotTooLarge)
+
+			// The whole body can still be read
+			gotAgain, err := io.ReadAll(received.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, testBody, string(gotAgain))
+		})
+	}
+}
+
+func Test_readLimitedBody_FailToReadBody(t *testing.T) {
+	// Setup
+	received := mustNewRequest(http.NewRequest(http.MethodPost, "https://test.com/foo", &badReader{}))
+
+	// Test
+	gotBody, gotTooLarge, gotErr := readLimitedBody(received, 5)
+
+	// Assertions
+	assert.ErrorIs(t, gotErr, ErrReadBody)
+	assert.Nil(t, gotBody)
+	assert.False(t, gotTooLarge)
+}
+
+// mustEncode is a convenience test helper that compresses body with the given
+// Content-Encoding, which is either "gzip", "deflate", or "raw-deflate". It
+// panics if an error occurs.
+func mustEncode(encoding string, body []byte) []byte {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	case "raw-deflate":
+		var err error
+		if w, err = flate.NewWriter(&buf, flate.DefaultCompression); err != nil {
+			panic(err)
+		}
+	default:
+		panic("unknown encoding " + encoding)
+	}
+	if _, err := w.Write(body); err != nil {
+		panic(err)
+	}
+	if err := w.Close(); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+func Test_decodeBody(t *testing.T) {
+	tests := []struct {
+		name         string
+		encoding     string
+		body         []byte
+		limit        int64
+		wantBody     []byte
+		wantTooLarge bool
+	}{
+		{
+			name:     "gzip",
+			encoding: "gzip",
+			body:     mustEncode("gzip", []byte(testBody)),
+			wantBody: []byte(testBody),
+		},
+		{
+			name:     "x-gzip",
+			encoding: "X-Gzip",
+			body:     mustEncode("gzip", []byte(testBody)),
+			wantBody: []byte(testBody),
+		},
+		{
+			name:     "deflate",
+			encoding: "deflate",
+			body:     mustEncode("deflate", []byte(testBody)),
+			wantBody: []byte(testBody),
+		},
+		{
+			name:     "raw-deflate",
+			encoding: "deflate",
+			body:     mustEncode("raw-deflate", []byte(testBody)),
+			wantBody: []byte(testBody),
+		},
+		{
+			name:     "multiple",
+			encoding: "deflate, gzip",
+			body:     mustEncode("gzip", mustEncode("deflate", []byte(testBody))),
+			wantBody: []byte(testBody),
+		},
+		{
+			name:     "not-encoded",
+			encoding: "",
+			body:     []byte(testBody),
+			wantBody: nil,
+		},
+		{
+			name:     "identity",
+			encoding: "identity",
+			body:     []byte(testBody),
+			wantBody: nil,
+		},
+		{
+			name:     "unsupported",
+			encoding: "gzip, br",
+			body:     []byte(testBody),
+			wantBody: nil,
+		},
+		{
+			name:         "too-large",
+			encoding:     "gzip",
+			body:         mustEncode("gzip", []byte(testBody)),
+			limit:        5,
+			wantBody:     []byte("Hello"),
+			wantTooLarge: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Test
+			gotBody, gotTooLarge, gotErr := decodeBody(tt.encoding, tt.body, tt.limit)
+
+			// Assertions
+			assert.NoError(t, gotErr)
+			assert.Equal(t, tt.wantBody, gotBody)
+			assert.Equal(t, tt.wantTooLarge, gotTooLarge)
+		})
+	}
+}
+
+func Test_decodeBody_Malformed(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+	}{
+		{
+			name:     "gzip-header",
+			encoding: "gzip",
+			body:     []byte(testBody),
+		},
+		{
+			name:     "gzip-truncated",
+			encoding: "gzip",
+			body:     mustEncode("gzip", []byte(testBody))[:15],
+		},
+		{
+			name:     "deflate",
+			encoding: "deflate",
+			body:     []byte{0xff, 0xff, 0xff},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Test
+			gotBody, gotTooLarge, gotErr := decodeBody(tt.encoding, tt.body, 0)
+
+			// Assertions
+			assert.ErrorIs(t, gotErr, ErrDecodeBody)
+			assert.ErrorContains(t, gotErr, "with "+tt.encoding)
+			assert.Nil(t, gotBody)
+			assert.False(t, gotTooLarge)
+		})
+	}
+}
+
 func TestRequest_diffMethod(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -462,6 +1316,18 @@ func TestRequest_diffURL(t *testing.T) {
 			received:        &http.Request{URL: &url.URL{}},
 			wantDifferences: true,
 		},
+		{
+			name:            "any-url",
+			request:         &Request{url: &url.URL{Path: AnyURL}},
+			received:        &http.Request{URL: &url.URL{Scheme: "https", Host: "test.com", Path: "/foo", RawQuery: "limit=1"}},
+			wantDifferences: false,
+		},
+		{
+			name:            "any-url-missing-received-url",
+			request:         &Request{url: &url.URL{Path: AnyURL}},
+			received:        &http.Request{URL: &url.URL{}},
+			wantDifferences: true,
+		},
 		{
 			name:            "missing-request-scheme",
 			request:         &Request{url: &url.URL{}},
@@ -918,6 +1784,123 @@ func TestRequest_diff(t *testing.T) {
 	}
 }
 
+func TestRequest_diffOrder(t *testing.T) {
+	tests := []struct {
+		name            string
+		requires        []*Request
+		wantOutput      string
+		wantDifferences int
+	}{
+		{
+			name: "satisfied",
+			requires: []*Request{
+				{method: http.MethodPost, url: &url.URL{Path: "/login"}, totalRequests: 1},
+				{method: http.MethodGet, url: &url.URL{Path: "/token"}, totalRequests: 2, repeatability: -1},
+			},
+			wantOutput:      "PASS:  order: after GET /token",
+			wantDifferences: 0,
+		},
+		{
+			name: "not-requested",
+			requires: []*Request{
+				{method: http.MethodPost, url: &url.URL{Path: "/login"}},
+			},
+			wantOutput:      "FAIL:  order: expected POST /login to be requested first",
+			wantDifferences: 1,
+		},
+		{
+			name: "not-requested-enough",
+			requires: []*Request{
+				{method: http.MethodPost, url: &url.URL{Path: "/login"}, totalRequests: 1, repeatability: 1},
+			},
+			wantOutput:      "FAIL:  order: expected POST /login to be requested first",
+			wantDifferences: 1,
+		},
+		{
+			name: "multiple-not-requested",
+			requires: []*Request{
+				{method: http.MethodPost, url: &url.URL{Path: "/login"}, totalRequests: 1},
+				{method: http.MethodGet, url: &url.URL{Path: "/token"}},
+				{method: http.MethodGet, url: &url.URL{Path: "/refresh"}},
+			},
+			wantOutput:      "FAIL:  order: expected GET /token to be requested first",
+			wantDifferences: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			request := &Request{requires: tt.requires}
+
+			// Test
+			gotOutput, gotDifferences := request.diffOrder()
+
+			// Assertions
+			assert.Equal(t, tt.wantOutput, gotOutput)
+			assert.Equal(t, tt.wantDifferences, gotDifferences)
+		})
+	}
+}
+
+func TestRequest_diffAfter(t *testing.T) {
+	tests := []struct {
+		name            string
+		registered      time.Duration
+		lastMatched     time.Duration
+		wantOutput      string
+		wantDifferences int
+	}{
+		{
+			name:            "since-registered",
+			registered:      2 * time.Minute,
+			wantOutput:      "PASS:  after: 2m0s since registered >= 1m0s",
+			wantDifferences: 0,
+		},
+		{
+			name:            "too-early-since-registered",
+			registered:      30 * time.Second,
+			wantOutput:      "FAIL:  after: 30s since registered < 1m0s",
+			wantDifferences: 1,
+		},
+		{
+			name:            "since-previous-call",
+			registered:      time.Hour,
+			lastMatched:     2 * time.Minute,
+			wantOutput:      "PASS:  after: 2m0s since previous call >= 1m0s",
+			wantDifferences: 0,
+		},
+		{
+			name:            "too-early-since-previous-call",
+			registered:      time.Hour,
+			lastMatched:     30 * time.Second,
+			wantOutput:      "FAIL:  after: 30s since previous call < 1m0s",
+			wantDifferences: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			now := time.Now()
+			request := &Request{after: time.Minute, registered: now.Add(-tt.registered)}
+			if tt.lastMatched > 0 {
+				request.lastMatched = now.Add(-tt.lastMatched)
+			}
+
+			// Test
+			gotOutput, gotDifferences := request.diffAfter()
+
+			// Assertions
+			// Elapsed time is measured after the setup, so only compare to the
+			// second
+			re := regexp.MustCompile(`\.\d+s`)
+			assert.Equal(t, tt.wantOutput, re.ReplaceAllString(gotOutput, "s"))
+			assert.Equal(t, tt.wantDifferences, gotDifferences)
+		})
+	}
+}
+
 func TestRequest_String(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -975,6 +1958,32 @@ URL: https://test.com/foo?limit=1#back
 	Path: /foo
 	Query: limit=1
 	Fragment: back
+Body: (12) Hello World!`,
+		},
+		{
+			name: "described",
+			request: &Request{
+				method: http.MethodGet,
+				url:    &url.URL{Path: AnyURL},
+				body:   []byte(testBody),
+				label:  "fetch anything",
+			},
+			want: `
+Description: fetch anything
+Method: GET
+URL: (AnyURL)
+Body: (12) Hello World!`,
+		},
+		{
+			name: "any-url",
+			request: &Request{
+				method: http.MethodGet,
+				url:    &url.URL{Path: AnyURL},
+				body:   []byte(testBody),
+			},
+			want: `
+Method: GET
+URL: (AnyURL)
 Body: (12) Hello World!`,
 		},
 		{
@@ -1233,6 +2242,27 @@ Body: (12) Hello World!
 Matcher[0]: github.com/shawalli/httpmock.testRequestMatcherAlwaysPass
 Matcher[1]: github.com/shawalli/httpmock.testRequestMatcherAlwaysFail`,
 		},
+		{
+			name: "requires",
+			request: &Request{
+				method: http.MethodGet,
+				url:    &url.URL{Path: "/foo"},
+				body:   []byte(testBody),
+				requires: []*Request{
+					{method: http.MethodPost, url: &url.URL{Path: "/login"}},
+				},
+			},
+			want: `
+Method: GET
+URL: /foo
+	Scheme: (Missing)
+	Host: (Missing)
+	Path: /foo
+	Query: (Missing)
+	Fragment: (Missing)
+Body: (12) Hello World!
+After: POST /login`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,393 @@
+package httpmock
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Responder bundles the status code, headers, and body of a response, so that
+// it can be built once and reused by many [Request]'s with [Request.Use] or
+// [Request.RespondSequence]. It is created with [NewResponder]. Each [Request]
+// gets its own copy, so later changes to the [Responder] do not affect
+// [Request]'s that already use it.
+//
+//	unavailable := httpmock.NewResponder().Status(http.StatusServiceUnavailable).JSON(map[string]string{"error": "unavailable"})
+type Responder struct {
+	// The HTTP status code that should be used in a response.
+	statusCode int
+
+	// Headers that should be used in a response.
+	header http.Header
+
+	// Body that should be used in a response.
+	body []byte
+
+	// Content-Type to use in a response if one is not explicitly set in the
+	// headers.
+	contentType string
+
+	// Error that occurred while building the response, which fails the test
+	// when the [Responder] is used.
+	err error
+}
+
+// NewResponder creates a [Responder] that writes a 200 with an empty body.
+func NewResponder() *Responder {
+	return &Responder{
+		statusCode: http.StatusOK,
+		header:     http.Header{},
+	}
+}
+
+// Status sets the status code of the response.
+func (r *Responder) Status(statusCode int) *Responder {
+	r.statusCode = statusCode
+	return r
+}
+
+// Header sets the value or values for a response header. Any prior values that
+// have already been set for a header with the same key will be overridden. The
+// key is canonicalized, as with [Response.Header].
+func (r *Responder) Header(key string, value string, values ...string) *Responder {
+	r.header[http.CanonicalHeaderKey(key)] = append([]string{value}, values...)
+	return r
+}
+
+// Body sets the body of the response.
+func (r *Responder) Body(body []byte) *Responder {
+	r.body = body
+	return r
+}
+
+// JSON sets the body of the response to the JSON encoding of v. Like
+// [Request.RespondJSON], it is written as "application/json" unless a
+// Content-Type header is set. If v cannot be encoded, the test fails when the
+// [Responder] is used.
+func (r *Responder) JSON(v any) *Responder {
+	body, err := json.Marshal(v)
+	if err != nil {
+		r.err = fmt.Errorf("failed to marshal JSON response: %w", err)
+		return r
+	}
+
+	r.body = body
+	r.contentType = "application/json"
+	return r
+}
+
+// Use specifies that the response for the expectation is built from a
+// [Responder]. The [Responder] is copied, so it may be shared by reference
+// between many [Request]'s.
+//
+//	unavailable := httpmock.NewResponder().Status(http.StatusServiceUnavailable)
+//	Mock.On(http.MethodGet, "/some/path", nil).Use(unavailable)
+//	Mock.On(http.MethodGet, "/other/path", nil).Use(unavailable)
+func (r *Request) Use(responder *Responder) *Response {
+	if responder.err != nil {
+		r.parent.fail("invalid responder for request %s. Error: %v\n", r.summary(), responder.err)
+	}
+
+	c := responder.clone()
+	resp := r.Respond(c.statusCode, c.body)
+
+	r.lock()
+	defer r.unlock()
+
+	resp.header = c.header
+	resp.contentType = c.contentType
+
+	return resp
+}
+
+// RespondSequence specifies a different response for each successive request
+// that matches the [Request]. Once every [Responder] has been used, the last one
+// is repeated. Concurrent requests each receive a distinct [Responder], in the
+// order that their responses are written. The test fails if no responders are
+// given.
+//
+// Modifiers on the returned [Response], such as [Response.Header] and
+// [Response.Delay], apply to every response in the sequence. Headers set on a
+// [Responder] take precedence.
+//
+//	Mock.On(http.MethodGet, "/some/path", nil).RespondSequence(
+//		httpmock.NewResponder().Status(http.StatusServiceUnavailable),
+//		httpmock.NewResponder().Status(http.StatusServiceUnavailable),
+//		httpmock.NewResponder().Body([]byte(`{"id": 1234}`)),
+//	)
+func (r *Request) RespondSequence(responders ...*Responder) *Response {
+	if len(responders) == 0 {
+		r.parent.fail("no responders given for request %s\n", r.summary())
+	}
+	for _, responder := range responders {
+		if responder.err != nil {
+			r.parent.fail("invalid responder for request %s. Error: %v\n", r.summary(), responder.err)
+		}
+	}
+
+	resp := r.Respond(http.StatusOK, nil)
+
+	sequence := make([]*Responder, 0, len(responders))
+	for _, responder := range responders {
+		sequence = append(sequence, responder.clone())
+	}
+
+	r.lock()
+	defer r.unlock()
+
+	resp.sequence = sequence
+	resp.sequenceCalls = new(atomic.Int64)
+
+	return resp
+}
+
+// RespondByAccept specifies a different response for each media type that the
+// [Request] can produce, chosen by the received request's Accept header. The
+// [Responder] for the media type that the Accept header prefers is used, based
+// on its q-values, and its media type is written as the Content-Type unless one
+// is set explicitly. A missing Accept header accepts any media type. If no media
+// type is acceptable, the "*/*" [Responder] is used if it is given, and
+// otherwise a 406 is returned. The test fails if no responders are given.
+//
+// Modifiers on the returned [Response], such as [Response.Header] and
+// [Response.Delay], apply to every representation. Headers set on a
+// [Responder] take precedence.
+//
+//	Mock.On(http.MethodGet, "/users/1234", nil).RespondByAccept(map[string]*httpmock.Responder{
+//		"application/json": httpmock.NewResponder().Body([]byte(`{"id": 1234}`)),
+//		"application/xml":  httpmock.NewResponder().Body([]byte(`<user id="1234"/>`)),
+//	})
+func (r *Request) RespondByAccept(responders map[string]*Responder) *Response {
+	if len(responders) == 0 {
+		r.parent.fail("no responders given for request %s\n", r.summary())
+	}
+	negotiated := make(map[string]*Responder, len(responders))
+	for mediaType, responder := range responders {
+		if responder.err != nil {
+			r.parent.fail("invalid responder for media type %q for request %s. Error: %v\n", mediaType, r.summary(), responder.err)
+		}
+		negotiated[mediaType] = responder.clone()
+	}
+
+	resp := r.Respond(http.StatusOK, nil)
+
+	r.lock()
+	defer r.unlock()
+
+	resp.negotiated = negotiated
+
+	return resp
+}
+
+// WeightedResponder bundles a [Responder] with the relative weight that it is
+// chosen with by [Request.RespondWeighted].
+type WeightedResponder struct {
+	// The response to write when this entry is chosen.
+	Responder *Responder
+
+	// The relative weight of this entry. An entry with a weight of 0 is never
+	// chosen.
+	Weight int
+}
+
+// RespondWeighted specifies several responses for the [Request], one of which
+// is chosen at random for each matching request, with a probability
+// proportional to its weight. This is useful for testing the resilience of a
+// client to a flaky upstream. Each request counts as one matched call,
+// regardless of which response is chosen. Use [Mock.SetRand] to make the
+// choices deterministic. The test fails if no entries are given, if a weight is
+// negative, or if every weight is 0.
+//
+// Modifiers on the returned [Response], such as [Response.Header] and
+// [Response.Delay], apply to every response. Headers set on a [Responder] take
+// precedence.
+//
+//	Mock.On(http.MethodGet, "/some/path", nil).RespondWeighted(
+//		httpmock.WeightedResponder{Responder: httpmock.NewResponder().Body([]byte(`{"id": 1234}`)), Weight: 9},
+//		httpmock.WeightedResponder{Responder: httpmock.NewResponder().Status(http.StatusServiceUnavailable), Weight: 1},
+//	)
+func (r *Request) RespondWeighted(entries ...WeightedResponder) *Response {
+	if len(entries) == 0 {
+		r.parent.fail("no responders given for request %s\n", r.summary())
+	}
+	var total int
+	weighted := make([]WeightedResponder, 0, len(entries))
+	for i, entry := range entries {
+		if entry.Responder == nil {
+			r.parent.fail("missing responder %d for request %s\n", i, r.summary())
+		} else if entry.Responder.err != nil {
+			r.parent.fail("invalid responder %d for request %s. Error: %v\n", i, r.summary(), entry.Responder.err)
+		}
+		if entry.Weight < 0 {
+			r.parent.fail("invalid weight %d of responder %d for request %s\n", entry.Weight, i, r.summary())
+		}
+		total += entry.Weight
+		weighted = append(weighted, WeightedResponder{Responder: entry.Responder.clone(), Weight: entry.Weight})
+	}
+	if total <= 0 {
+		r.parent.fail("no responders with a positive weight given for request %s\n", r.summary())
+	}
+
+	resp := r.Respond(http.StatusOK, nil)
+
+	r.lock()
+	defer r.unlock()
+
+	resp.weighted = weighted
+
+	return resp
+}
+
+// chooseWeighted chooses the index of an entry at random, with a probability
+// proportional to its weight. The mutex of the [Mock] must be held.
+func (m *Mock) chooseWeighted(entries []WeightedResponder) int {
+	var total int
+	for _, entry := range entries {
+		total += entry.Weight
+	}
+
+	var n int
+	if m.rand != nil {
+		n = m.rand.Intn(total)
+	} else {
+		n = rand.Intn(total)
+	}
+	for i, entry := range entries {
+		if n < entry.Weight {
+			return i
+		}
+		n -= entry.Weight
+	}
+	return len(entries) - 1
+}
+
+// acceptRange is a media range from an Accept header.
+type acceptRange struct {
+	mediaType string
+	q         float64
+}
+
+// parseAccept parses the media ranges of an Accept header. Ranges that cannot
+// be parsed are ignored. A missing header accepts any media type.
+func parseAccept(header string) []acceptRange {
+	if strings.TrimSpace(header) == "" {
+		return []acceptRange{{mediaType: "*/*", q: 1}}
+	}
+
+	var ranges []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		ranges = append(ranges, acceptRange{mediaType: mediaType, q: q})
+	}
+	return ranges
+}
+
+// acceptQuality returns the q-value that a list of media ranges gives a media
+// type, using the most specific matching range, and the index of that range. If
+// no range matches, the q-value is 0.
+func acceptQuality(ranges []acceptRange, mediaType string) (q float64, index int) {
+	typ, _, _ := strings.Cut(mediaType, "/")
+
+	specificity := -1
+	for i, ar := range ranges {
+		var s int
+		switch {
+		case ar.mediaType == mediaType:
+			s = 2
+		case ar.mediaType == typ+"/*":
+			s = 1
+		case ar.mediaType == "*/*":
+			s = 0
+		default:
+			continue
+		}
+		if s > specificity {
+			specificity = s
+			q = ar.q
+			index = i
+		}
+	}
+	return q, index
+}
+
+// negotiate chooses the media type of the [Responder] to use for an Accept
+// header. Media types with a higher q-value are preferred, and ties are broken
+// by the order of the Accept header, and then alphabetically. If no media type
+// is acceptable, the "*/*" [Responder] is used if it exists. If there is no
+// acceptable [Responder], an empty string is returned.
+func negotiate(responders map[string]*Responder, accept string) string {
+	ranges := parseAccept(accept)
+
+	offers := make([]string, 0, len(responders))
+	for offer := range responders {
+		offers = append(offers, offer)
+	}
+	slices.Sort(offers)
+
+	var best string
+	var bestQ float64
+	var bestIndex int
+	for _, offer := range offers {
+		if offer == "*/*" {
+			continue
+		}
+		mediaType, _, err := mime.ParseMediaType(offer)
+		if err != nil {
+			continue
+		}
+		q, index := acceptQuality(ranges, mediaType)
+		if q > bestQ || (q == bestQ && q > 0 && index < bestIndex) {
+			best, bestQ, bestIndex = offer, q, index
+		}
+	}
+
+	if best == "" {
+		if _, ok := responders["*/*"]; ok {
+			return "*/*"
+		}
+	}
+	return best
+}
+
+// apply overrides the status code, headers, and body of a copy of a [Response]
+// with those of the [Responder]. Headers that are not set by the [Responder]
+// are kept.
+func (r *Responder) apply(resp *Response) {
+	resp.statusCode = r.statusCode
+	resp.body = r.body
+	if r.contentType != "" {
+		resp.contentType = r.contentType
+	}
+
+	header := resp.header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	for key, values := range r.header {
+		header[key] = slices.Clone(values)
+	}
+	resp.header = header
+}
+
+// clone returns a deep copy of the [Responder].
+func (r *Responder) clone() *Responder {
+	c := *r
+	c.header = r.header.Clone()
+	c.body = slices.Clone(r.body)
+	return &c
+}
@@ -0,0 +1,499 @@
+package httpmock
+
+import (
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewResponder(t *testing.T) {
+	// Test
+	got := NewResponder().
+		Status(http.StatusServiceUnavailable).
+		Header("Retry-After", "1").
+		Body([]byte(testBody))
+
+	// Assertions
+	want := &Responder{
+		statusCode: http.StatusServiceUnavailable,
+		header:     http.Header{"Retry-After": []string{"1"}},
+		body:       []byte(testBody),
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestResponder_JSON(t *testing.T) {
+	// Test
+	got := NewResponder().JSON(map[string]string{"foo": "bar"})
+
+	// Assertions
+	want := &Responder{
+		statusCode:  http.StatusOK,
+		header:      http.Header{},
+		body:        []byte(`{"foo":"bar"}`),
+		contentType: "application/json",
+	}
+	assert.Equal(t, want, got)
+}
+
+func TestResponder_JSON_FailToMarshal(t *testing.T) {
+	// Test
+	got := NewResponder().JSON(make(chan int))
+
+	// Assertions
+	assert.ErrorContains(t, got.err, "failed to marshal JSON response")
+	assert.Nil(t, got.body)
+}
+
+func TestRequest_Use(t *testing.T) {
+	// Setup
+	m := new(Mock).Test(t)
+	unavailable := NewResponder().
+		Status(http.StatusServiceUnavailable).
+		Header("Retry-After", "1").
+		JSON(map[string]string{"error": "unavailable"})
+
+	// Test
+	foo := m.On(http.MethodGet, "https://test.com/foo", nil)
+	foo.Use(unavailable).Header("X-Trace", "abc")
+	bar := m.On(http.MethodGet, "https://test.com/bar", nil)
+	bar.Use(unavailable)
+
+	// Modifying a responder after use does not change the responses
+	unavailable.Status(http.StatusTeapot).Header("Retry-After", "2")
+
+	// Assertions
+	assert.Equal(t, &Response{
+		parent:      foo,
+		statusCode:  http.StatusServiceUnavailable,
+		header:      http.Header{"Retry-After": []string{"1"}, "X-Trace": []string{"abc"}},
+		body:        []byte(`{"error":"unavailable"}`),
+		contentType: "application/json",
+	}, foo.response)
+	assert.Equal(t, &Response{
+		parent:      bar,
+		statusCode:  http.StatusServiceUnavailable,
+		header:      http.Header{"Retry-After": []string{"1"}},
+		body:        []byte(`{"error":"unavailable"}`),
+		contentType: "application/json",
+	}, bar.response)
+}
+
+func TestRequest_Use_InvalidResponder(t *testing.T) {
+	// Setup
+	var successfulUseCall int
+
+	mockT := new(MockTestingT)
+	r := &Request{parent: new(Mock).Test(mockT), url: &url.URL{Path: "/foo"}}
+
+	defer func() {
+		rc := recover()
+		if rc == nil {
+			t.Fatal("Did not expect to get here")
+		}
+		// Assertions
+		assert.Equal(t, "FailNow was called", rc.(string))
+		assert.Equal(t, 1, mockT.failNowCount)
+		assert.Zero(t, successfulUseCall)
+		assert.Nil(t, r.response)
+	}()
+
+	// Test
+	r.Use(NewResponder().JSON(make(chan int)))
+	successfulUseCall++
+}
+
+func TestRequest_RespondSequence(t *testing.T) {
+	// Setup
+	r := &Request{parent: new(Mock).Test(t)}
+	unavailable := NewResponder().Status(http.StatusServiceUnavailable).Header("Retry-After", "1")
+	r.RespondSequence(
+		unavailable,
+		unavailable,
+		NewResponder().Body([]byte(testBody)),
+	).Header("X-Trace", "abc")
+
+	// Modifying a responder after use does not change the sequence
+	unavailable.Status(http.StatusTeapot)
+
+	for i, want := range []struct {
+		statusCode int
+		retryAfter string
+		body       string
+	}{
+		{statusCode: http.StatusServiceUnavailable, retryAfter: "1"},
+		{statusCode: http.StatusServiceUnavailable, retryAfter: "1"},
+		{statusCode: http.StatusOK, body: testBody},
+		{statusCode: http.StatusOK, body: testBody},
+	} {
+		// Test
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/foo", http.NoBody)
+		_, gotErr := r.response.Write(recorder, req)
+
+		// Assertions
+		assert.NoError(t, gotErr, i)
+		assert.Equal(t, want.statusCode, recorder.Code, i)
+		assert.Equal(t, want.retryAfter, recorder.Header().Get("Retry-After"), i)
+		assert.Equal(t, "abc", recorder.Header().Get("X-Trace"), i)
+		assert.Equal(t, want.body, recorder.Body.String(), i)
+	}
+	assert.Equal(t, http.Header{"X-Trace": []string{"abc"}}, r.response.header)
+}
+
+func TestRequest_RespondSequence_Concurrent(t *testing.T) {
+	// Setup
+	var responders []*Responder
+	for i := range 50 {
+		responders = append(responders, NewResponder().Body([]byte(strconv.Itoa(i))))
+	}
+
+	r := &Request{parent: new(Mock).Test(t)}
+	r.RespondSequence(responders...)
+
+	// Test
+	var wg sync.WaitGroup
+	bodies := make([]string, len(responders))
+	for i := range responders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/foo", http.NoBody)
+			_, _ = r.response.Write(recorder, req)
+			bodies[i] = recorder.Body.String()
+		}()
+	}
+	wg.Wait()
+
+	// Assertions
+	for i := range responders {
+		assert.Contains(t, bodies, strconv.Itoa(i))
+	}
+}
+
+func TestRequest_RespondSequence_NoResponders(t *testing.T) {
+	// Setup
+	var successfulRespondCall int
+
+	mockT := new(MockTestingT)
+	r := &Request{parent: new(Mock).Test(mockT), url: &url.URL{Path: "/foo"}}
+
+	defer func() {
+		rc := recover()
+		if rc == nil {
+			t.Fatal("Did not expect to get here")
+		}
+		// Assertions
+		assert.Equal(t, "FailNow was called", rc.(string))
+		assert.Equal(t, 1, mockT.failNowCount)
+		assert.Zero(t, successfulRespondCall)
+		assert.Nil(t, r.response)
+	}()
+
+	// Test
+	r.RespondSequence()
+	successfulRespondCall++
+}
+
+func TestServer_defaultHandler_RespondSequence(t *testing.T) {
+	// Setup
+	s := NewServer()
+	defer s.Close()
+	s.On(http.MethodGet, "/foo", nil).RespondSequence(
+		NewResponder().Status(http.StatusServiceUnavailable),
+		NewResponder().Status(http.StatusServiceUnavailable),
+		NewResponder().Body([]byte(testBody)),
+	).Times(3)
+
+	// Test
+	var gotStatusCodes []int
+	for range 3 {
+		got, err := s.Client().Get(s.URLf("/foo"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got.Body.Close()
+		gotStatusCodes = append(gotStatusCodes, got.StatusCode)
+	}
+
+	// Assertions
+	assert.Equal(t, []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK}, gotStatusCodes)
+	s.Mock.AssertExpectations(t)
+}
+
+func Test_negotiate(t *testing.T) {
+	tests := []struct {
+		name       string
+		responders []string
+		accept     string
+		want       string
+	}{
+		{
+			name:       "missing-accept",
+			responders: []string{"application/xml", "application/json"},
+			accept:     "",
+			want:       "application/json",
+		},
+		{
+			name:       "exact",
+			responders: []string{"application/xml", "application/json"},
+			accept:     "application/xml",
+			want:       "application/xml",
+		},
+		{
+			name:       "q-values",
+			responders: []string{"application/xml", "application/json"},
+			accept:     "application/json;q=0.5, application/xml;q=0.9",
+			want:       "application/xml",
+		},
+		{
+			name:       "header-order",
+			responders: []string{"application/json", "application/xml"},
+			accept:     "application/xml, application/json",
+			want:       "application/xml",
+		},
+		{
+			name:       "most-specific-range",
+			responders: []string{"text/html", "text/plain"},
+			accept:     "text/*;q=0.5, text/html;q=0, */*;q=0.1",
+			want:       "text/plain",
+		},
+		{
+			name:       "wildcard-range",
+			responders: []string{"application/json"},
+			accept:     "*/*",
+			want:       "application/json",
+		},
+		{
+			name:       "fallback",
+			responders: []string{"application/json", "*/*"},
+			accept:     "text/csv",
+			want:       "*/*",
+		},
+		{
+			name:       "not-acceptable",
+			responders: []string{"application/json"},
+			accept:     "text/csv, application/json;q=0",
+			want:       "",
+		},
+		{
+			name:       "invalid-range",
+			responders: []string{"application/json"},
+			accept:     "application/json;q=2",
+			want:       "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			responders := map[string]*Responder{}
+			for _, mediaType := range tt.responders {
+				responders[mediaType] = NewResponder()
+			}
+
+			// Test
+			got := negotiate(responders, tt.accept)
+
+			// Assertions
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRequest_RespondByAccept(t *testing.T) {
+	tests := []struct {
+		name            string
+		accept          string
+		wantStatusCode  int
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name:            "json",
+			accept:          "application/json",
+			wantStatusCode:  http.StatusOK,
+			wantContentType: "application/json",
+			wantBody:        `{"id":1234}`,
+		},
+		{
+			name:            "xml",
+			accept:          "application/xml, application/json;q=0.5",
+			wantStatusCode:  http.StatusOK,
+			wantContentType: "text/xml",
+			wantBody:        `<user id="1234"/>`,
+		},
+		{
+			name:           "not-acceptable",
+			accept:         "text/csv",
+			wantStatusCode: http.StatusNotAcceptable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			r := &Request{parent: new(Mock).Test(t)}
+			r.RespondByAccept(map[string]*Responder{
+				"application/json": NewResponder().JSON(map[string]int{"id": 1234}),
+				"application/xml":  NewResponder().Header("Content-Type", "text/xml").Body([]byte(`<user id="1234"/>`)),
+			}).Header("X-Trace", "abc")
+
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/foo", http.NoBody)
+			req.Header.Set("Accept", tt.accept)
+
+			// Test
+			_, gotErr := r.response.Write(recorder, req)
+
+			// Assertions
+			assert.NoError(t, gotErr)
+			assert.Equal(t, tt.wantStatusCode, recorder.Code)
+			assert.Equal(t, tt.wantContentType, recorder.Header().Get("Content-Type"))
+			assert.Equal(t, "abc", recorder.Header().Get("X-Trace"))
+			assert.Equal(t, "Accept", recorder.Header().Get("Vary"))
+			assert.Equal(t, tt.wantBody, recorder.Body.String())
+			assert.Equal(t, http.Header{"X-Trace": []string{"abc"}}, r.response.header)
+		})
+	}
+}
+
+func TestRequest_RespondByAccept_NoResponders(t *testing.T) {
+	// Setup
+	var successfulRespondCall int
+
+	mockT := new(MockTestingT)
+	r := &Request{parent: new(Mock).Test(mockT), url: &url.URL{Path: "/foo"}}
+
+	defer func() {
+		rc := recover()
+		if rc == nil {
+			t.Fatal("Did not expect to get here")
+		}
+		// Assertions
+		assert.Equal(t, "FailNow was called", rc.(string))
+		assert.Equal(t, 1, mockT.failNowCount)
+		assert.Zero(t, successfulRespondCall)
+		assert.Nil(t, r.response)
+	}()
+
+	// Test
+	r.RespondByAccept(nil)
+	successfulRespondCall++
+}
+
+func TestRequest_RespondWeighted(t *testing.T) {
+	// Setup
+	serve := func(seed int64) []int {
+		m := new(Mock).Test(t).SetRand(rand.New(rand.NewSource(seed)))
+		expected := m.On(http.MethodGet, "/foo", nil)
+		expected.RespondWeighted(
+			WeightedResponder{Responder: NewResponder().Body([]byte(testBody)), Weight: 3},
+			WeightedResponder{Responder: NewResponder().Status(http.StatusTeapot), Weight: 0},
+			WeightedResponder{Responder: NewResponder().Status(http.StatusServiceUnavailable).Header("Retry-After", "1"), Weight: 1},
+		).Header("X-Trace", "abc")
+
+		var codes []int
+		for range 100 {
+			recorder := httptest.NewRecorder()
+			m.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/foo", http.NoBody))
+			assert.Equal(t, "abc", recorder.Header().Get("X-Trace"))
+			if recorder.Code == http.StatusOK {
+				assert.Equal(t, testBody, recorder.Body.String())
+			} else {
+				assert.Equal(t, "1", recorder.Header().Get("Retry-After"))
+			}
+			codes = append(codes, recorder.Code)
+		}
+		m.AssertNumberOfRequestsFor(t, expected, 100)
+		return codes
+	}
+
+	// Test
+	got := serve(1)
+	gotAgain := serve(1)
+
+	// Assertions
+	assert.Equal(t, got, gotAgain)
+	assert.Contains(t, got, http.StatusOK)
+	assert.Contains(t, got, http.StatusServiceUnavailable)
+	assert.NotContains(t, got, http.StatusTeapot)
+}
+
+func TestRequest_RespondWeighted_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []WeightedResponder
+	}{
+		{
+			name: "no-entries",
+		},
+		{
+			name:    "missing-responder",
+			entries: []WeightedResponder{{Weight: 1}},
+		},
+		{
+			name:    "invalid-responder",
+			entries: []WeightedResponder{{Responder: NewResponder().JSON(make(chan int)), Weight: 1}},
+		},
+		{
+			name:    "negative-weight",
+			entries: []WeightedResponder{{Responder: NewResponder(), Weight: 2}, {Responder: NewResponder(), Weight: -1}},
+		},
+		{
+			name:    "zero-weights",
+			entries: []WeightedResponder{{Responder: NewResponder()}, {Responder: NewResponder()}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			var successfulRespondCall int
+
+			mockT := new(MockTestingT)
+			r := &Request{parent: new(Mock).Test(mockT), url: &url.URL{Path: "/foo"}}
+
+			defer func() {
+				rc := recover()
+				if rc == nil {
+					t.Fatal("Did not expect to get here")
+				}
+				// Assertions
+				assert.Equal(t, "FailNow was called", rc.(string))
+				assert.Equal(t, 1, mockT.failNowCount)
+				assert.Zero(t, successfulRespondCall)
+				assert.Nil(t, r.response)
+			}()
+
+			// Test
+			r.RespondWeighted(tt.entries...)
+			successfulRespondCall++
+		})
+	}
+}
+
+func TestMock_chooseWeighted(t *testing.T) {
+	// Setup
+	m := new(Mock).SetRand(rand.New(rand.NewSource(1)))
+	entries := []WeightedResponder{{Weight: 1}, {Weight: 0}, {Weight: 2}}
+
+	// Test
+	got := make([]int, len(entries))
+	for range 300 {
+		got[m.chooseWeighted(entries)]++
+	}
+
+	// Assertions
+	assert.Zero(t, got[1])
+	assert.InDelta(t, 100, got[0], 30)
+	assert.InDelta(t, 200, got[2], 30)
+}
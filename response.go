@@ -1,11 +1,23 @@
 package httpmock
 
 import (
+	"bytes"
+	"compress/gzip"
 	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"text/template"
+	"time"
 )
 
-var ErrWriteReturnBody = errors.New("error writing return body")
+var (
+	ErrWriteReturnBody = errors.New("error writing return body")
+	ErrRenderTemplate  = errors.New("error rendering response template")
+)
 
 // ResponseWriter writes a [http.Response] and returns the number of bytes
 // written and whether or not the operation encountered an error.
@@ -25,14 +37,93 @@ type Response struct {
 	// Headers that should be used in a response.
 	header http.Header
 
+	// The trailers to send after the response body, keyed by their canonical
+	// names.
+	trailer http.Header
+
+	// Cookies that should be set in a response, in addition to any Set-Cookie
+	// headers.
+	cookies []*http.Cookie
+
 	// Body that should be used in a response.
 	body []byte
 
+	// Content-Type to use in a response if one is not explicitly set in the
+	// headers.
+	contentType string
+
+	// Template that is rendered against the received request to create the
+	// body of a response. Overrides body.
+	template *template.Template
+
+	// Amount of time to wait before writing a response.
+	delay time.Duration
+
+	// Channel to receive from before writing a response, once per response.
+	gate <-chan struct{}
+
+	// Whether the body of a response should be gzip-compressed.
+	gzip gzipMode
+
+	// Whether only the status line and headers are written, with no body.
+	statusOnly bool
+
+	// Whether the response should be written without a Content-Length, so that
+	// it uses chunked transfer encoding.
+	noContentLength bool
+
+	// Content-Length to declare for a truncated response, regardless of the
+	// length of the body.
+	truncated   bool
+	declaredLen int
+
+	// Whether the body and Content-Type of the received request are written
+	// back. Overrides body.
+	echo bool
+
+	// Validators that are sent as the ETag and Last-Modified headers, and that
+	// a conditional request is compared against to respond with a 304.
+	etag         string
+	lastModified time.Time
+
+	// Chunks of the body that are written and flushed one at a time, waiting
+	// interval between each. Overrides body.
+	chunks   [][]byte
+	interval time.Duration
+
+	// Responders that override statusCode, header, and body on successive
+	// writes, with the last one repeating once exhausted. The counter is shared
+	// by copies of the response.
+	sequence      []*Responder
+	sequenceCalls *atomic.Int64
+
+	// Responders, one of which is chosen at random by weight to override
+	// statusCode, header, and body on each write.
+	weighted []WeightedResponder
+
+	// Responders keyed by media type, one of which overrides statusCode,
+	// header, and body based on the Accept header of the received request.
+	negotiated map[string]*Responder
+
 	// Custom response writer that overrides statusCode, header, and body
 	// configurations.
 	writer ResponseWriter
 }
 
+// gzipMode indicates when the body of a [Response] should be gzip-compressed.
+type gzipMode int
+
+const (
+	// Do not compress the body.
+	gzipOff gzipMode = iota
+
+	// Compress the body if the received request accepts gzip.
+	gzipNegotiate
+
+	// Always compress the body.
+	gzipForce
+)
+
 func newResponse(parent *Request, statusCode int, body []byte) *Response {
 	return &Response{
 		parent:     parent,
@@ -42,6 +133,21 @@ func newResponse(parent *Request, statusCode int, body []byte) *Response {
 	}
 }
 
+// clone returns a copy of a [Response] that belongs to the given [Request].
+// Request counters, such as that of [Request.RespondSequence], are reset. The
+// mutex of the original [Response]'s [Mock] must be held.
+func (r *Response) clone(parent *Request) *Response {
+	c := *r
+	c.parent = parent
+	c.header = r.header.Clone()
+	c.trailer = r.trailer.Clone()
+	c.cookies = slices.Clone(r.cookies)
+	if r.sequenceCalls != nil {
+		c.sequenceCalls = new(atomic.Int64)
+	}
+	return &c
+}
+
 // lock is a convenience method to lock the grandparent [Mock]'s mutex.
 func (r *Response) lock() {
 	r.parent.parent.mutex.Lock()
@@ -63,6 +169,183 @@ func (r *Response) Header(key string, value string, values ...string) *Response
 	return r
 }
 
+// Trailer sets the value or values for a response trailer, which is sent after
+// the body, as some protocols like gRPC expect. Any prior values that have
+// already been set for a trailer with the same key are replaced. The trailers
+// are declared in the Trailer header before the body, and clients can read them
+// from [http.Response.Trailer] once the body has been read to the end.
+//
+// HTTP/1.1 trailers require chunked transfer encoding, so the Content-Length of
+// a response with trailers is omitted. Trailers also work with streamed
+// responses, such as with [Request.RespondStream], and with HTTP/2. They are not
+// sent by a [Server] configured with [Server.ForceHTTP10].
+//
+//	Mock.On(http.MethodPost, "/rpc", AnyBody).RespondOK(body).Trailer("Grpc-Status", "0")
+func (r *Response) Trailer(key string, value string, values ...string) *Response {
+	r.lock()
+	defer r.unlock()
+
+	if r.trailer == nil {
+		r.trailer = http.Header{}
+	}
+	r.trailer[http.CanonicalHeaderKey(key)] = append([]string{value}, values...)
+	return r
+}
+
+// Headers merges a set of headers into the response, which is convenient for
+// reusing a common set, such as CORS or caching headers. Keys are
+// canonicalized, and the values for each key replace any that were previously
+// set for it, including by an earlier call to Headers or by [Response.Header]
+// with a differently cased key. Like [Response.Header], an explicit
+// Content-Type takes precedence over one set automatically, such as by
+// [Request.RespondJSON].
+//
+//	cors := http.Header{"Access-Control-Allow-Origin": []string{"*"}}
+//	Mock.On(http.MethodGet, "/some/path", nil).RespondJSON(http.StatusOK, user).Headers(cors)
+func (r *Response) Headers(h http.Header) *Response {
+	r.lock()
+	defer r.unlock()
+
+	for key, values := range h {
+		key = http.CanonicalHeaderKey(key)
+		for existing := range r.header {
+			if strings.EqualFold(existing, key) {
+				delete(r.header, existing)
+			}
+		}
+		r.header[key] = append([]string{}, values...)
+	}
+	return r
+}
+
+// ContentType is a convenience method to set the Content-Type header of the
+// response. Any prior value will be overridden.
+//
+//	Mock.On(http.MethodGet, "/some/path", nil).RespondOK([]byte("a,b,c")).ContentType("text/csv")
+func (r *Response) ContentType(contentType string) *Response {
+	r.lock()
+	defer r.unlock()
+
+	r.header.Set("Content-Type", contentType)
+	return r
+}
+
+// SetCookie adds a cookie to the response. Multiple calls add multiple
+// cookies. Cookies are written with [http.SetCookie] after any headers, so
+// Set-Cookie headers set with [Response.Header] are kept. Invalid cookies are
+// silently dropped.
+//
+//	Mock.On(http.MethodPost, "/login", AnyBody).RespondNoContent().SetCookie(&http.Cookie{Name: "session", Value: "1234"})
+func (r *Response) SetCookie(cookie *http.Cookie) *Response {
+	r.lock()
+	defer r.unlock()
+
+	r.cookies = append(r.cookies, cookie)
+	return r
+}
+
+// StatusCode returns the HTTP status code of the response.
+func (r *Response) StatusCode() int {
+	r.lock()
+	defer r.unlock()
+
+	return r.statusCode
+}
+
+// SetStatusCode sets the HTTP status code of the response, which is useful in a
+// hook added with [Mock.ResponseHook].
+func (r *Response) SetStatusCode(statusCode int) *Response {
+	r.lock()
+	defer r.unlock()
+
+	r.statusCode = statusCode
+	return r
+}
+
+// Body returns the body of the response. In a hook added with
+// [Mock.ResponseHook], it is the body that is about to be written.
+func (r *Response) Body() []byte {
+	r.lock()
+	defer r.unlock()
+
+	return r.body
+}
+
+// SetBody sets the body of the response, which is useful in a hook added with
+// [Mock.ResponseHook].
+//
+//	Mock.ResponseHook(func(resp *Response, r *http.Request) {
+//		resp.SetBody(append(append([]byte(`{"data": `), resp.Body()...), '}'))
+//	})
+func (r *Response) SetBody(body []byte) *Response {
+	r.lock()
+	defer r.unlock()
+
+	r.body = body
+	return r
+}
+
+// Gzip compresses the body of the response with gzip and sets the
+// Content-Encoding header, if the received request's Accept-Encoding header
+// accepts gzip. Otherwise, the body is written uncompressed. Streamed
+// responses are not compressed.
+//
+//	Mock.On(http.MethodGet, "/some/path", nil).RespondOK([]byte(`{"id": "1234"}`)).Gzip()
+func (r *Response) Gzip() *Response {
+	r.lock()
+	defer r.unlock()
+
+	r.gzip = gzipNegotiate
+	return r
+}
+
+// ForceGzip compresses the body of the response with gzip and sets the
+// Content-Encoding header, regardless of the received request's
+// Accept-Encoding header. Streamed responses are not compressed.
+//
+//	Mock.On(http.MethodGet, "/some/path", nil).RespondOK([]byte(`{"id": "1234"}`)).ForceGzip()
+func (r *Response) ForceGzip() *Response {
+	r.lock()
+	defer r.unlock()
+
+	r.gzip = gzipForce
+	return r
+}
+
+// NoContentLength writes the response without a Content-Length header, which
+// forces HTTP/1.1 responses to use chunked transfer encoding, rather than
+// letting the server compute the length of small bodies. This is useful for
+// testing clients that behave differently with chunked and length-delimited
+// responses. The headers are flushed before the body is written, and any
+// Content-Length header that was set is removed. Streamed responses are
+// already chunked, so it has no additional effect on them.
+//
+// Chunked transfer encoding requires HTTP/1.1. HTTP/1.0 responses are instead
+// delimited by closing the connection, and HTTP/2 responses have no transfer
+// encoding, so they are only sent without a Content-Length.
+//
+//	Mock.On(http.MethodGet, "/some/path", nil).RespondOK([]byte(`{"id": "1234"}`)).NoContentLength()
+func (r *Response) NoContentLength() *Response {
+	r.lock()
+	defer r.unlock()
+
+	r.noContentLength = true
+	return r
+}
+
+// Delay sets an amount of time to wait before the response is written. If the
+// request's context is canceled during the delay, such as when a client times
+// out, nothing is written.
+//
+//	Mock.On(http.MethodGet, "/some/path", nil).RespondOK(nil).Delay(5 * time.Second)
+func (r *Response) Delay(d time.Duration) *Response {
+	r.lock()
+	defer r.unlock()
+
+	r.delay = d
+	return r
+}
+
 // Once is a convenience method which indicates that the grandparent [Mock]
 // should only expect the parent request once.
 //
@@ -102,25 +385,226 @@ func (r *Response) On(method string, path string, body []byte) *Request {
 // successfully written to the [http.ResponseWriter] are returned, as well as
 // any errors.
 //
+// Headers that are already set on the [http.ResponseWriter], such as by
+// [Server.ResponseMiddleware], are kept rather than replaced by those of the
+// response.
+//
 // Note: If [Request.RespondUsing] was previously called, all response
-// configurations are ignored except for the provided custom [ResponseWriter].
+// configurations are ignored except for [Response.Delay] and the provided
+// custom [ResponseWriter].
 func (r *Response) Write(w http.ResponseWriter, req *http.Request) (int, error) {
+	// Copy the configuration so that the lock is not held while writing
 	r.lock()
-	defer r.unlock()
+	resp := *r
+	sniff := r.parent.parent.sniffContentType
+	hooks := r.parent.parent.responseHooks
+	decodeEcho := !r.parent.parent.keepRequestEncoding
+	var conditions []responseCondition
+	if r != r.parent.exhaustedResponse {
+		conditions = r.parent.conditions
+	}
+	var weighted *Responder
+	if len(r.weighted) > 0 {
+		weighted = r.weighted[r.parent.parent.chooseWeighted(r.weighted)].Responder
+	}
+	r.unlock()
 
-	if r.writer != nil {
-		return r.writer(w, req)
+	if resp.delay > 0 && !wait(req, resp.delay) {
+		return 0, nil
+	}
+	if resp.gate != nil && !waitGate(req, resp.gate) {
+		return 0, nil
+	}
+
+	if resp.writer != nil {
+		return resp.writer(w, req)
 	}
 
+	if condition := matchCondition(conditions, req); condition != nil {
+		resp.statusCode = condition.statusCode
+		resp.body = condition.body
+		resp.sequence = nil
+		resp.weighted = nil
+		weighted = nil
+		resp.negotiated = nil
+		resp.template = nil
+		resp.echo = false
+		resp.chunks = nil
+	}
+
+	if len(resp.sequence) > 0 {
+		i := int(resp.sequenceCalls.Add(1) - 1)
+		resp.sequence[min(i, len(resp.sequence)-1)].apply(&resp)
+	}
+	if weighted != nil {
+		weighted.apply(&resp)
+	}
+
+	if resp.negotiated != nil {
+		mediaType := negotiate(resp.negotiated, strings.Join(req.Header.Values("Accept"), ","))
+		if mediaType == "" {
+			resp.statusCode = http.StatusNotAcceptable
+			resp.body = nil
+			resp.header = resp.header.Clone()
+		} else {
+			resp.negotiated[mediaType].apply(&resp)
+			if mediaType != "*/*" {
+				resp.contentType = mediaType
+			}
+		}
+		resp.header.Add("Vary", "Accept")
+	}
+
+	body := resp.body
+	if resp.template != nil {
+		var err error
+		if body, err = renderTemplate(resp.template, req); err != nil {
+			return 0, err
+		}
+	}
+	if resp.echo {
+		var err error
+		if body, err = SafeReadBody(req); err != nil {
+			return 0, err
+		}
+		if contentType := req.Header.Get("Content-Type"); contentType != "" {
+			resp.contentType = contentType
+		}
+		// The body is echoed as it was matched, so it is decoded unless the
+		// Mock keeps request encodings. A body that is not decoded is
+		// written back with its encoding.
+		if encoding := req.Header.Get("Content-Encoding"); encoding != "" {
+			var decoded []byte
+			if decodeEcho {
+				decoded, _, _ = decodeBody(encoding, body, 0)
+			}
+			if decoded != nil {
+				body = decoded
+			} else {
+				resp.header = resp.header.Clone()
+				resp.header.Set("Content-Encoding", encoding)
+			}
+		}
+	}
+	if resp.etag != "" || !resp.lastModified.IsZero() {
+		resp.header = resp.header.Clone()
+		if resp.etag != "" {
+			resp.header.Set("ETag", resp.etag)
+		}
+		if !resp.lastModified.IsZero() {
+			resp.header.Set("Last-Modified", resp.lastModified.UTC().Format(http.TimeFormat))
+		}
+		if statusCode := preconditionStatus(resp.etag, resp.lastModified, req); statusCode != 0 {
+			resp.statusCode = statusCode
+			body = nil
+			resp.contentType = ""
+		}
+	}
+	if len(hooks) > 0 {
+		// Hooks modify a copy of the configuration, not the response itself
+		resp.header = resp.header.Clone()
+		resp.trailer = resp.trailer.Clone()
+		resp.cookies = slices.Clone(resp.cookies)
+		resp.body = body
+		for _, hook := range hooks {
+			hook(&resp, req)
+		}
+		body = resp.body
+	}
+	if resp.statusOnly {
+		if (len(body) > 0 || len(resp.chunks) > 0) && !bodyAllowedForStatus(resp.statusCode) {
+			resp.parent.parent.fail("body configured for status-only %d response for request %s\n", resp.statusCode, resp.parent.summary())
+		}
+		body = nil
+		resp.chunks = nil
+		resp.contentType = ""
+	}
+
+	// Keys are canonicalized, as they would be on the wire, so that in-process
+	// clients, such as those of Mock.RoundTripper, see the same headers as
+	// clients of a Server. Values are copied so that adding cookies does not
+	// modify the response's configuration.
+	keys := make([]string, 0, len(resp.header))
+	for key := range resp.header {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	merged := make(http.Header, len(keys))
+	for _, key := range keys {
+		canonical := http.CanonicalHeaderKey(key)
+		merged[canonical] = append(merged[canonical], resp.header[key]...)
+	}
+
+	// Headers that were already set, such as by a middleware of a Server, are
+	// kept
 	h := w.Header()
-	for key, values := range r.header {
-		h[key] = values
+	for key, values := range merged {
+		if _, ok := h[key]; !ok {
+			h[key] = values
+		}
+	}
+	for _, cookie := range resp.cookies {
+		http.SetCookie(w, cookie)
+	}
+	if resp.contentType != "" && h.Get("Content-Type") == "" {
+		h.Set("Content-Type", resp.contentType)
+	}
+	if sniff && h.Get("Content-Type") == "" {
+		sniffed := body
+		if len(resp.chunks) > 0 {
+			sniffed = resp.chunks[0]
+		}
+		if len(sniffed) > 0 {
+			h.Set("Content-Type", http.DetectContentType(sniffed))
+		}
+	}
+	if resp.gzip == gzipNegotiate {
+		h.Add("Vary", "Accept-Encoding")
+	}
+	if resp.chunks == nil && len(body) > 0 && (resp.gzip == gzipForce || (resp.gzip == gzipNegotiate && acceptsGzip(req))) {
+		var err error
+		if body, err = gzipBody(body); err != nil {
+			return 0, err
+		}
+		h.Set("Content-Encoding", "gzip")
+		// Any configured length would be for the uncompressed body
+		h.Del("Content-Length")
+	}
+	if resp.truncated {
+		h.Set("Content-Length", strconv.Itoa(resp.declaredLen))
+		if len(body) > resp.declaredLen {
+			body = body[:max(resp.declaredLen, 0)]
+		}
 	}
 
-	w.WriteHeader(r.statusCode)
+	if resp.noContentLength || (resp.statusOnly && (resp.statusCode == http.StatusNoContent || resp.statusCode == http.StatusNotModified)) {
+		h.Del("Content-Length")
+	}
+	if len(resp.trailer) > 0 {
+		keys := make([]string, 0, len(resp.trailer))
+		for key := range resp.trailer {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		h.Set("Trailer", strings.Join(keys, ", "))
+		// Trailers are only sent with chunked transfer encoding
+		h.Del("Content-Length")
+		defer writeTrailer(w, resp.trailer)
+	}
+
+	w.WriteHeader(resp.statusCode)
+
+	if resp.noContentLength {
+		// Once the headers are sent, the server cannot add a Content-Length
+		_ = http.NewResponseController(w).Flush()
+	}
 
-	if r.body != nil {
-		n, err := w.Write(r.body)
+	if resp.chunks != nil {
+		return writeChunks(w, req, resp.chunks, resp.interval)
+	}
+
+	if body != nil {
+		n, err := w.Write(body)
 		if err != nil {
 			return n, ErrWriteReturnBody
 		}
@@ -129,3 +613,162 @@ func (r *Response) Write(w http.ResponseWriter, req *http.Request) (int, error)
 
 	return 0, nil
 }
+
+// preconditionStatus returns the status code to respond with when a received
+// request's conditional headers match the given validators, or 0 if the
+// response is sent as usual. Following RFC 9110, a matching If-None-Match
+// returns 304 Not Modified for GET and HEAD requests, and 412 Precondition
+// Failed for other methods, and takes precedence over If-Modified-Since.
+func preconditionStatus(etag string, lastModified time.Time, req *http.Request) int {
+	safe := req.Method == http.MethodGet || req.Method == http.MethodHead
+
+	if inm := req.Header.Get("If-None-Match"); inm != "" && etag != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				if !safe {
+					return http.StatusPreconditionFailed
+				}
+				return http.StatusNotModified
+			}
+		}
+		return 0
+	}
+
+	if lastModified.IsZero() || !safe {
+		return 0
+	}
+	ims, err := http.ParseTime(req.Header.Get("If-Modified-Since"))
+	if err != nil || lastModified.After(ims) {
+		return 0
+	}
+	return http.StatusNotModified
+}
+
+// bodyAllowedForStatus reports whether a response with the given status code
+// may have a body. 205 Reset Content is included with 204 and 304, since its
+// body must be empty.
+func bodyAllowedForStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusNoContent, http.StatusResetContent, http.StatusNotModified:
+		return false
+	}
+	return true
+}
+
+// writeTrailer sets the values of trailers that were declared in the Trailer
+// header, once the body has been written.
+func writeTrailer(w http.ResponseWriter, trailer http.Header) {
+	h := w.Header()
+	for key, values := range trailer {
+		h[key] = slices.Clone(values)
+	}
+}
+
+// acceptsGzip reports whether a received request's Accept-Encoding header
+// accepts gzip, either explicitly or with a wildcard.
+func acceptsGzip(req *http.Request) bool {
+	if req == nil {
+		return false
+	}
+
+	for _, value := range req.Header.Values("Accept-Encoding") {
+		for _, coding := range strings.Split(value, ",") {
+			name, params, _ := strings.Cut(coding, ";")
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name != "gzip" && name != "*" {
+				continue
+			}
+			// A quality value of 0 means the coding is not acceptable
+			if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+				if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+					continue
+				}
+			}
+			return true
+		}
+	}
+
+	return false
+}
+
+// gzipBody compresses a body with gzip.
+func gzipBody(body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(body); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrWriteReturnBody, err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrWriteReturnBody, err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeChunks writes each chunk of a streamed body, flushing after each one
+// and waiting interval between them. If the [http.ResponseWriter] does not
+// implement [http.Flusher], the chunks are written without flushing. Writing
+// stops early if the request's context is done.
+func writeChunks(w http.ResponseWriter, req *http.Request, chunks [][]byte, interval time.Duration) (int, error) {
+	flusher, canFlush := w.(http.Flusher)
+	if canFlush {
+		flusher.Flush()
+	}
+
+	var total int
+	for i, chunk := range chunks {
+		if i > 0 && interval > 0 && !wait(req, interval) {
+			return total, nil
+		}
+		if req != nil && req.Context().Err() != nil {
+			return total, nil
+		}
+
+		n, err := w.Write(chunk)
+		total += n
+		if err != nil {
+			return total, ErrWriteReturnBody
+		}
+		if canFlush {
+			flusher.Flush()
+		}
+	}
+
+	return total, nil
+}
+
+// wait blocks for the given duration, returning true once it has elapsed. If
+// the request's context is done first, it returns false.
+func wait(req *http.Request, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	if req == nil {
+		<-timer.C
+		return true
+	}
+
+	select {
+	case <-timer.C:
+		return true
+	case <-req.Context().Done():
+		return false
+	}
+}
+
+// waitGate blocks until a value is received from gate, or it is closed,
+// returning true. If the request's context is done first, it returns false.
+func waitGate(req *http.Request, gate <-chan struct{}) bool {
+	if req == nil {
+		<-gate
+		return true
+	}
+
+	select {
+	case <-gate:
+		return true
+	case <-req.Context().Done():
+		return false
+	}
+}
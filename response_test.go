@@ -1,10 +1,17 @@
 package httpmock
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"text/template"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -102,73 +109,1303 @@ func TestResponse_Header(t *testing.T) {
 	}
 }
 
+func TestResponse_Headers(t *testing.T) {
+	// Setup
+	response := &Response{
+		parent: &Request{parent: new(Mock).Test(t)},
+		header: http.Header{"X-Foo": []string{"1"}, "X-Bar": []string{"1"}},
+	}
+	h := http.Header{"x-foo": []string{"2", "3"}, "X-Baz": []string{"1"}}
+
+	// Test
+	got := response.Headers(h).Headers(http.Header{"X-BAZ": []string{"2"}})
+
+	// Assertions
+	assert.Equal(t, response, got)
+	want := http.Header{
+		"X-Foo": []string{"2", "3"},
+		"X-Bar": []string{"1"},
+		"X-Baz": []string{"2"},
+	}
+	assert.Equal(t, want, response.header)
+
+	// Mutating the given headers should not affect the response
+	h["x-foo"][0] = "4"
+	assert.Equal(t, want, response.header)
+}
+
+func TestResponse_Headers_MixedCase(t *testing.T) {
+	// Setup
+	m := new(Mock).Test(t)
+	resp := m.On(http.MethodGet, "/foo", nil).RespondOK(nil).
+		Header("x-foo", "a").
+		Headers(http.Header{"X-Foo": []string{"b"}})
+	recorder := httptest.NewRecorder()
+
+	// Test
+	_, gotErr := resp.Write(recorder, httptest.NewRequest(http.MethodGet, "/foo", http.NoBody))
+
+	// Assertions
+	assert.NoError(t, gotErr)
+	assert.Equal(t, http.Header{"X-Foo": []string{"b"}}, resp.header)
+	assert.Equal(t, []string{"b"}, recorder.Result().Header.Values("X-Foo"))
+}
+
+func TestResponse_Headers_ContentType(t *testing.T) {
+	// Setup
+	m := new(Mock).Test(t)
+	m.On(http.MethodGet, "/foo", nil).
+		RespondJSON(http.StatusOK, map[string]string{"id": "1234"}).
+		Headers(http.Header{"content-type": []string{"application/vnd.api+json"}})
+
+	received := mustNewRequest(http.NewRequest(http.MethodGet, "/foo", http.NoBody))
+	recorder := httptest.NewRecorder()
+
+	// Test
+	_, err := m.Requested(received).Write(recorder, received)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"application/vnd.api+json"}, recorder.Header().Values("Content-Type"))
+}
+
+func TestResponse_Trailer(t *testing.T) {
+	// Setup
+	response := &Response{
+		parent: &Request{parent: new(Mock).Test(t)},
+		header: http.Header{},
+	}
+
+	// Test
+	got := response.Trailer("grpc-status", "1").Trailer("Grpc-Status", "0").Trailer("X-Checksum", "abc", "def")
+
+	// Assertions
+	assert.Equal(t, response, got)
+	want := http.Header{
+		"Grpc-Status": []string{"0"},
+		"X-Checksum":  []string{"abc", "def"},
+	}
+	assert.Equal(t, want, response.trailer)
+	assert.Empty(t, response.header)
+}
+
+func TestResponse_Write_Trailer(t *testing.T) {
+	tests := []struct {
+		name     string
+		response *Response
+		wantBody string
+	}{
+		{
+			name: "body",
+			response: &Response{
+				statusCode: http.StatusOK,
+				header:     http.Header{"Content-Length": []string{"12"}},
+				body:       []byte(testBody),
+			},
+			wantBody: testBody,
+		},
+		{
+			name: "stream",
+			response: &Response{
+				statusCode: http.StatusOK,
+				header:     http.Header{},
+				chunks:     [][]byte{[]byte("foo"), []byte("bar")},
+			},
+			wantBody: "foobar",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			tt.response.parent = &Request{parent: new(Mock).Test(t)}
+			tt.response.trailer = http.Header{"Grpc-Status": []string{"0"}, "Grpc-Message": []string{"OK"}}
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/foo", http.NoBody)
+
+			// Test
+			_, gotErr := tt.response.Write(recorder, req)
+
+			// Assertions
+			assert.NoError(t, gotErr)
+			got := recorder.Result()
+			assert.Equal(t, "Grpc-Message, Grpc-Status", got.Header.Get("Trailer"))
+			assert.Empty(t, got.Header.Get("Content-Length"))
+			assert.Equal(t, http.Header{"Grpc-Status": []string{"0"}, "Grpc-Message": []string{"OK"}}, got.Trailer)
+			assert.Equal(t, tt.wantBody, recorder.Body.String())
+		})
+	}
+}
+
+func TestResponse_ContentType(t *testing.T) {
+	// Setup
+	response := &Response{
+		parent: &Request{parent: new(Mock).Test(t)},
+		header: http.Header{"Content-Type": []string{"text/plain"}},
+	}
+
+	// Test
+	got := response.ContentType("text/csv")
+
+	// Assertions
+	assert.Equal(t, response, got)
+	assert.Equal(t, http.Header{"Content-Type": []string{"text/csv"}}, response.header)
+}
+
+func TestResponse_SetCookie(t *testing.T) {
+	// Setup
+	response := &Response{parent: &Request{parent: new(Mock).Test(t)}, header: http.Header{}}
+
+	session := &http.Cookie{Name: "session", Value: "1234"}
+	csrf := &http.Cookie{Name: "csrf", Value: "5678"}
+
+	// Test
+	got := response.SetCookie(session).SetCookie(csrf)
+
+	// Assertions
+	assert.Equal(t, response, got)
+	assert.Equal(t, []*http.Cookie{session, csrf}, response.cookies)
+	assert.Empty(t, response.header)
+}
+
+func TestResponse_Gzip(t *testing.T) {
+	// Setup
+	response := &Response{parent: &Request{parent: new(Mock).Test(t)}}
+
+	// Test
+	got := response.Gzip()
+
+	// Assertions
+	assert.Equal(t, response, got)
+	assert.Equal(t, gzipNegotiate, response.gzip)
+}
+
+func TestResponse_ForceGzip(t *testing.T) {
+	// Setup
+	response := &Response{parent: &Request{parent: new(Mock).Test(t)}}
+
+	// Test
+	got := response.ForceGzip()
+
+	// Assertions
+	assert.Equal(t, response, got)
+	assert.Equal(t, gzipForce, response.gzip)
+}
+
+func TestResponse_NoContentLength(t *testing.T) {
+	// Setup
+	response := &Response{parent: &Request{parent: new(Mock).Test(t)}}
+
+	// Test
+	got := response.NoContentLength()
+
+	// Assertions
+	assert.Equal(t, response, got)
+	assert.True(t, response.noContentLength)
+}
+
+func TestResponse_Delay(t *testing.T) {
+	// Setup
+	response := &Response{parent: &Request{parent: new(Mock).Test(t)}}
+
+	// Test
+	got := response.Delay(time.Second)
+
+	// Assertions
+	assert.Equal(t, response, got)
+	assert.Equal(t, time.Second, response.delay)
+}
+
 func TestResponse_Once(t *testing.T) {
 	// Setup
-	expected := &Request{parent: new(Mock).Test(t)}
-	response := Response{parent: expected}
+	expected := &Request{parent: new(Mock).Test(t)}
+	response := Response{parent: expected}
+
+	// Test
+	response.Once()
+
+	// Assertions
+	assert.Equal(t, 1, expected.repeatability)
+}
+
+func TestResponse_Twice(t *testing.T) {
+	// Setup
+	expected := &Request{parent: new(Mock).Test(t)}
+	response := Response{parent: expected}
+
+	// Test
+	response.Twice()
+
+	// Assertions
+	assert.Equal(t, 2, expected.repeatability)
+}
+
+func TestResponse_Times(t *testing.T) {
+	// Setup
+	expected := &Request{parent: new(Mock).Test(t)}
+	response := Response{parent: expected}
+
+	// Test
+	response.Times(4)
+
+	// Assertions
+	assert.Equal(t, 4, expected.repeatability)
+}
+
+func TestResponse_On(t *testing.T) {
+	// Setup
+	response := &Response{
+		parent:     &Request{parent: new(Mock).Test(t)},
+		statusCode: http.StatusOK,
+		body:       []byte(testBody),
+	}
+
+	// Test
+	got := response.On(http.MethodPut, "test.com/foo", []byte(`{"foo": "bar"}`))
+
+	// Assertions
+	assert.NotNil(t, got)
+	wantExpectedRequests := []*Request{got}
+	assert.Equal(t, wantExpectedRequests, response.parent.parent.ExpectedRequests)
+}
+
+func TestResponse_Write_FailWriteBody(t *testing.T) {
+	// Setup
+	response := &Response{
+		parent:     &Request{parent: new(Mock).Test(t)},
+		statusCode: http.StatusOK,
+		body:       []byte(testBody),
+	}
+
+	// Test
+	gotN, gotErr := response.Write(&badResponseWriter{}, nil)
+
+	// Assertions
+	assert.Equal(t, -1, gotN)
+	assert.ErrorIs(t, gotErr, ErrWriteReturnBody)
+}
+
+func TestResponse_Write_Delay(t *testing.T) {
+	// Setup
+	response := &Response{
+		parent:     &Request{parent: new(Mock).Test(t)},
+		statusCode: http.StatusOK,
+		body:       []byte(testBody),
+		delay:      50 * time.Millisecond,
+	}
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/foo", http.NoBody)
+
+	// Test
+	start := time.Now()
+	gotN, gotErr := response.Write(recorder, req)
+	elapsed := time.Since(start)
+
+	// Assertions
+	assert.NoError(t, gotErr)
+	assert.Equal(t, len(testBody), gotN)
+	assert.GreaterOrEqual(t, elapsed, 50*time.Millisecond)
+	assert.Equal(t, testBody, recorder.Body.String())
+}
+
+func TestResponse_Write_DelayCanceled(t *testing.T) {
+	// Setup
+	response := &Response{
+		parent:     &Request{parent: new(Mock).Test(t)},
+		statusCode: http.StatusInternalServerError,
+		body:       []byte(testBody),
+		delay:      time.Minute,
+	}
+	recorder := httptest.NewRecorder()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodGet, "/foo", http.NoBody).WithContext(ctx)
+
+	// Test
+	gotN, gotErr := response.Write(recorder, req)
+
+	// Assertions
+	assert.NoError(t, gotErr)
+	assert.Zero(t, gotN)
+	assert.False(t, recorder.Flushed)
+	assert.Empty(t, recorder.Body.String())
+	// The recorder defaults to 200 if WriteHeader was never called
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestResponse_Write_Gate(t *testing.T) {
+	// Setup
+	gate := make(chan struct{})
+	response := &Response{
+		parent:     &Request{parent: new(Mock).Test(t)},
+		statusCode: http.StatusOK,
+		body:       []byte(testBody),
+		gate:       gate,
+	}
+	recorders := []*httptest.ResponseRecorder{httptest.NewRecorder(), httptest.NewRecorder()}
+	done := make(chan int, len(recorders))
+
+	// Test
+	for i, recorder := range recorders {
+		go func() {
+			_, _ = response.Write(recorder, httptest.NewRequest(http.MethodGet, "/foo", http.NoBody))
+			done <- i
+		}()
+	}
+
+	// Assertions
+	select {
+	case <-done:
+		t.Fatal("response was written before the gate was released")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	// Each send releases one response
+	gate <- struct{}{}
+	first := <-done
+	assert.Equal(t, testBody, recorders[first].Body.String())
+	select {
+	case <-done:
+		t.Fatal("second response was written before the gate was released again")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(gate)
+	second := <-done
+	assert.Equal(t, testBody, recorders[second].Body.String())
+}
+
+func TestResponse_Write_GateCanceled(t *testing.T) {
+	// Setup
+	response := &Response{
+		parent:     &Request{parent: new(Mock).Test(t)},
+		statusCode: http.StatusOK,
+		body:       []byte(testBody),
+		gate:       make(chan struct{}),
+	}
+	recorder := httptest.NewRecorder()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodGet, "/foo", http.NoBody).WithContext(ctx)
+
+	// Test
+	gotN, gotErr := response.Write(recorder, req)
+
+	// Assertions
+	assert.NoError(t, gotErr)
+	assert.Zero(t, gotN)
+	assert.Empty(t, recorder.Body.String())
+}
+
+func TestResponse_Write_FailRenderTemplate(t *testing.T) {
+	// Setup
+	response := &Response{
+		parent:     &Request{parent: new(Mock).Test(t)},
+		statusCode: http.StatusOK,
+		template:   template.Must(template.New("test").Parse(`{{.Missing}}`)),
+	}
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/foo", http.NoBody)
+
+	// Test
+	gotN, gotErr := response.Write(recorder, req)
+
+	// Assertions
+	assert.Zero(t, gotN)
+	assert.ErrorIs(t, gotErr, ErrRenderTemplate)
+	assert.Empty(t, recorder.Body.String())
+}
+
+func TestResponse_Write_Template(t *testing.T) {
+	// Setup
+	response := &Response{
+		parent:      &Request{parent: new(Mock).Test(t)},
+		statusCode:  http.StatusCreated,
+		header:      http.Header{"X-Request-Id": []string{"5678"}},
+		contentType: "application/json",
+		template:    template.Must(template.New("test").Parse(`{"id": "{{.Query.Get "id"}}", "body": "{{.Body}}"}`)),
+	}
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/foo?id=1234", strings.NewReader(testBody))
+
+	// Test
+	gotN, gotErr := response.Write(recorder, req)
+
+	// Assertions
+	wantBody := `{"id": "1234", "body": "Hello World!"}`
+	assert.NoError(t, gotErr)
+	assert.Equal(t, len(wantBody), gotN)
+	assert.Equal(t, http.StatusCreated, recorder.Code)
+	assert.Equal(t, "5678", recorder.Header().Get("X-Request-Id"))
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, wantBody, recorder.Body.String())
+}
+
+func TestResponse_Write_Echo(t *testing.T) {
+	tests := []struct {
+		name            string
+		contentType     string
+		wantContentType string
+	}{
+		{
+			name:            "content-type",
+			contentType:     "application/json",
+			wantContentType: "application/json",
+		},
+		{
+			name:            "no-content-type",
+			wantContentType: "text/plain",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			response := &Response{
+				parent:      &Request{parent: new(Mock).Test(t)},
+				statusCode:  http.StatusOK,
+				header:      http.Header{},
+				contentType: "text/plain",
+				echo:        true,
+			}
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/foo", strings.NewReader(testBody))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+
+			// Test
+			gotN, gotErr := response.Write(recorder, req)
+
+			// Assertions
+			assert.NoError(t, gotErr)
+			assert.Equal(t, len(testBody), gotN)
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, tt.wantContentType, recorder.Header().Get("Content-Type"))
+			assert.Equal(t, testBody, recorder.Body.String())
+		})
+	}
+}
+
+func TestResponse_Write_Echo_ContentEncoding(t *testing.T) {
+	tests := []struct {
+		name         string
+		keepEncoding bool
+		encoding     string
+		wantEncoding string
+	}{
+		{
+			name:     "decoded",
+			encoding: "gzip",
+		},
+		{
+			name:         "keep-encoding",
+			keepEncoding: true,
+			encoding:     "gzip",
+			wantEncoding: "gzip",
+		},
+		{
+			name:         "unsupported",
+			encoding:     "br",
+			wantEncoding: "br",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			m := new(Mock).Test(t).DecodeRequestBodies(!tt.keepEncoding)
+			m.On(http.MethodPost, "/echo", AnyBody).RespondEcho(http.StatusOK)
+			body := []byte(`{"id": "1234"}`)
+			if tt.encoding == "gzip" {
+				body = mustEncode("gzip", body)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/echo", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Content-Encoding", tt.encoding)
+			recorder := httptest.NewRecorder()
+
+			// Test
+			m.ServeHTTP(recorder, req)
+
+			// Assertions
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+			assert.Equal(t, tt.wantEncoding, recorder.Header().Get("Content-Encoding"))
+			if tt.wantEncoding == "" {
+				assert.Equal(t, `{"id": "1234"}`, recorder.Body.String())
+			} else {
+				assert.Equal(t, body, recorder.Body.Bytes())
+			}
+		})
+	}
+}
+
+func TestResponse_Write_Echo_ReadError(t *testing.T) {
+	// Setup
+	response := &Response{
+		parent:     &Request{parent: new(Mock).Test(t)},
+		statusCode: http.StatusOK,
+		header:     http.Header{},
+		echo:       true,
+	}
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/foo", &badReader{})
+
+	// Test
+	gotN, gotErr := response.Write(recorder, req)
+
+	// Assertions
+	assert.Zero(t, gotN)
+	assert.ErrorIs(t, gotErr, ErrReadBody)
+	assert.Empty(t, recorder.Body.String())
+}
+
+func TestResponse_Write_Stream(t *testing.T) {
+	tests := []struct {
+		name        string
+		writer      func(recorder *httptest.ResponseRecorder) http.ResponseWriter
+		wantFlushed bool
+	}{
+		{
+			name:        "flusher",
+			writer:      func(recorder *httptest.ResponseRecorder) http.ResponseWriter { return recorder },
+			wantFlushed: true,
+		},
+		{
+			name: "not-flusher",
+			writer: func(recorder *httptest.ResponseRecorder) http.ResponseWriter {
+				// Embedding the interface hides the recorder's Flush method
+				return struct{ http.ResponseWriter }{recorder}
+			},
+			wantFlushed: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			response := &Response{
+				parent:     &Request{parent: new(Mock).Test(t)},
+				statusCode: http.StatusOK,
+				chunks:     [][]byte{[]byte("data: 1\n\n"), []byte("data: 2\n\n"), []byte("data: 3\n\n")},
+				interval:   10 * time.Millisecond,
+			}
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/events", http.NoBody)
+
+			// Test
+			start := time.Now()
+			gotN, gotErr := response.Write(tt.writer(recorder), req)
+			elapsed := time.Since(start)
+
+			// Assertions
+			assert.NoError(t, gotErr)
+			assert.Equal(t, 27, gotN)
+			assert.GreaterOrEqual(t, elapsed, 20*time.Millisecond)
+			assert.Equal(t, tt.wantFlushed, recorder.Flushed)
+			assert.Equal(t, "data: 1\n\ndata: 2\n\ndata: 3\n\n", recorder.Body.String())
+		})
+	}
+}
+
+func TestResponse_Write_StreamCanceled(t *testing.T) {
+	// Setup
+	response := &Response{
+		parent:     &Request{parent: new(Mock).Test(t)},
+		statusCode: http.StatusOK,
+		chunks:     [][]byte{[]byte("foo"), []byte("bar")},
+		interval:   time.Minute,
+	}
+	recorder := httptest.NewRecorder()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest(http.MethodGet, "/events", http.NoBody).WithContext(ctx)
+
+	// Test
+	gotN, gotErr := response.Write(recorder, req)
+
+	// Assertions
+	assert.NoError(t, gotErr)
+	assert.Equal(t, 3, gotN)
+	assert.Equal(t, "foo", recorder.Body.String())
+}
+
+func TestResponse_Write_StreamFailWriteBody(t *testing.T) {
+	// Setup
+	response := &Response{
+		parent:     &Request{parent: new(Mock).Test(t)},
+		statusCode: http.StatusOK,
+		chunks:     [][]byte{[]byte("foo"), []byte("bar")},
+	}
+
+	// Test
+	gotN, gotErr := response.Write(&badResponseWriter{}, nil)
+
+	// Assertions
+	assert.Equal(t, -1, gotN)
+	assert.ErrorIs(t, gotErr, ErrWriteReturnBody)
+}
+
+func Test_preconditionStatus(t *testing.T) {
+	modified := time.Date(2024, time.May, 1, 12, 30, 15, 0, time.UTC)
+	before := modified.Add(-time.Hour).Format(http.TimeFormat)
+	after := modified.Add(time.Hour).Format(http.TimeFormat)
+
+	tests := []struct {
+		name         string
+		method       string
+		etag         string
+		lastModified time.Time
+		header       http.Header
+		want         int
+	}{
+		{
+			name:   "etag-match",
+			etag:   `"v1"`,
+			header: http.Header{"If-None-Match": []string{`"v1"`}},
+			want:   http.StatusNotModified,
+		},
+		{
+			name:   "etag-list",
+			etag:   `"v2"`,
+			header: http.Header{"If-None-Match": []string{`"v1", "v2"`}},
+			want:   http.StatusNotModified,
+		},
+		{
+			name:   "etag-weak",
+			etag:   `"v1"`,
+			header: http.Header{"If-None-Match": []string{`W/"v1"`}},
+			want:   http.StatusNotModified,
+		},
+		{
+			name:   "etag-weak-response",
+			etag:   `W/"v1"`,
+			header: http.Header{"If-None-Match": []string{`"v1"`}},
+			want:   http.StatusNotModified,
+		},
+		{
+			name:   "etag-wildcard",
+			etag:   `"v1"`,
+			header: http.Header{"If-None-Match": []string{"*"}},
+			want:   http.StatusNotModified,
+		},
+		{
+			name:   "etag-match-put",
+			method: http.MethodPut,
+			etag:   `"v1"`,
+			header: http.Header{"If-None-Match": []string{`"v1"`}},
+			want:   http.StatusPreconditionFailed,
+		},
+		{
+			name:   "etag-mismatch-put",
+			method: http.MethodPut,
+			etag:   `"v1"`,
+			header: http.Header{"If-None-Match": []string{`"v2"`}},
+			want:   0,
+		},
+		{
+			name:   "etag-mismatch",
+			etag:   `"v1"`,
+			header: http.Header{"If-None-Match": []string{`"v2"`}},
+			want:   0,
+		},
+		{
+			name:   "etag-no-header",
+			etag:   `"v1"`,
+			header: http.Header{},
+			want:   0,
+		},
+		{
+			name:         "modified-since-not-modified",
+			lastModified: modified,
+			header:       http.Header{"If-Modified-Since": []string{modified.Format(http.TimeFormat)}},
+			want:         http.StatusNotModified,
+		},
+		{
+			name:         "modified-since-later",
+			lastModified: modified,
+			header:       http.Header{"If-Modified-Since": []string{after}},
+			want:         http.StatusNotModified,
+		},
+		{
+			name:         "modified-since-modified",
+			lastModified: modified,
+			header:       http.Header{"If-Modified-Since": []string{before}},
+			want:         0,
+		},
+		{
+			name:         "modified-since-invalid",
+			lastModified: modified,
+			header:       http.Header{"If-Modified-Since": []string{"yesterday"}},
+			want:         0,
+		},
+		{
+			name:         "modified-since-post",
+			method:       http.MethodPost,
+			lastModified: modified,
+			header:       http.Header{"If-Modified-Since": []string{after}},
+			want:         0,
+		},
+		{
+			name:         "etag-takes-precedence",
+			etag:         `"v1"`,
+			lastModified: modified,
+			header:       http.Header{"If-None-Match": []string{`"v2"`}, "If-Modified-Since": []string{after}},
+			want:         0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, "/foo", http.NoBody)
+			req.Header = tt.header
+
+			// Test
+			got := preconditionStatus(tt.etag, tt.lastModified, req)
+
+			// Assertions
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestResponse_Write_ETag(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		ifNoneMatch string
+		wantStatus  int
+		wantBody    string
+	}{
+		{
+			name:       "unconditional",
+			wantStatus: http.StatusOK,
+			wantBody:   testBody,
+		},
+		{
+			name:        "not-modified",
+			ifNoneMatch: `"v1"`,
+			wantStatus:  http.StatusNotModified,
+			wantBody:    "",
+		},
+		{
+			name:        "modified",
+			ifNoneMatch: `"v0"`,
+			wantStatus:  http.StatusOK,
+			wantBody:    testBody,
+		},
+		{
+			name:        "precondition-failed",
+			method:      http.MethodPut,
+			ifNoneMatch: `"v1"`,
+			wantStatus:  http.StatusPreconditionFailed,
+			wantBody:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			modified := time.Date(2024, time.May, 1, 12, 30, 15, 0, time.UTC)
+			r := &Request{parent: new(Mock).Test(t)}
+			response := r.RespondETag("v1", []byte(testBody)).Header("Cache-Control", "no-cache")
+			response.lastModified = modified
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, "/foo", http.NoBody)
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			recorder := httptest.NewRecorder()
+
+			// Test
+			_, gotErr := response.Write(recorder, req)
+
+			// Assertions
+			assert.NoError(t, gotErr)
+			assert.Equal(t, tt.wantStatus, recorder.Code)
+			assert.Equal(t, tt.wantBody, recorder.Body.String())
+			assert.Equal(t, `"v1"`, recorder.Header().Get("ETag"))
+			assert.Equal(t, "Wed, 01 May 2024 12:30:15 GMT", recorder.Header().Get("Last-Modified"))
+			assert.Equal(t, "no-cache", recorder.Header().Get("Cache-Control"))
+			assert.Empty(t, response.header.Get("ETag"))
+		})
+	}
+}
+
+func TestResponse_Write_RespondWhenHeader(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     http.Header
+		exhausted  bool
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "first-condition",
+			header:     http.Header{"X-Feature": []string{"on"}},
+			wantStatus: http.StatusOK,
+			wantBody:   "enabled",
+		},
+		{
+			name:       "registration-order",
+			header:     http.Header{"X-Feature": []string{"on"}, "X-Beta": []string{"true"}},
+			wantStatus: http.StatusOK,
+			wantBody:   "enabled",
+		},
+		{
+			name:       "second-condition",
+			header:     http.Header{"X-Beta": []string{"true"}},
+			wantStatus: http.StatusAccepted,
+			wantBody:   "beta",
+		},
+		{
+			name:       "multiple-values",
+			header:     http.Header{"X-Feature": []string{"off", "on"}},
+			wantStatus: http.StatusOK,
+			wantBody:   "enabled",
+		},
+		{
+			name:       "fallback",
+			header:     http.Header{"X-Feature": []string{"off"}},
+			wantStatus: http.StatusForbidden,
+			wantBody:   "disabled",
+		},
+		{
+			name:       "exhausted-ignores-conditions",
+			header:     http.Header{"X-Feature": []string{"on"}},
+			exhausted:  true,
+			wantStatus: http.StatusTooManyRequests,
+			wantBody:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			r := new(Mock).Test(t).On(http.MethodGet, "/feature", nil).
+				RespondWhenHeader("X-Feature", "on", http.StatusOK, []byte("enabled")).
+				RespondWhenHeader("X-Beta", "true", http.StatusAccepted, []byte("beta"))
+			response := r.Respond(http.StatusForbidden, []byte("disabled")).Header("X-Trace", "abc")
+			if tt.exhausted {
+				response = r.RespondExhausted(http.StatusTooManyRequests, nil)
+			}
+			req := httptest.NewRequest(http.MethodGet, "/feature", http.NoBody)
+			req.Header = tt.header
+			recorder := httptest.NewRecorder()
+
+			// Test
+			_, gotErr := response.Write(recorder, req)
+
+			// Assertions
+			assert.NoError(t, gotErr)
+			assert.Equal(t, tt.wantStatus, recorder.Code)
+			assert.Equal(t, tt.wantBody, recorder.Body.String())
+			if !tt.exhausted {
+				assert.Equal(t, "abc", recorder.Header().Get("X-Trace"))
+			}
+		})
+	}
+}
+
+func TestResponse_Write_RespondWhenHeader_Sequence(t *testing.T) {
+	// Setup
+	r := new(Mock).Test(t).On(http.MethodGet, "/feature", nil).
+		RespondWhenHeader("X-Feature", "on", http.StatusOK, []byte("enabled"))
+	response := r.RespondSequence(
+		NewResponder().Status(http.StatusServiceUnavailable),
+		NewResponder().Status(http.StatusForbidden),
+	)
+	enabled := httptest.NewRequest(http.MethodGet, "/feature", http.NoBody)
+	enabled.Header.Set("X-Feature", "on")
+	disabled := httptest.NewRequest(http.MethodGet, "/feature", http.NoBody)
 
-	// Test
-	response.Once()
+	var got []int
+	for _, req := range []*http.Request{enabled, disabled, enabled, disabled} {
+		recorder := httptest.NewRecorder()
 
-	// Assertions
-	assert.Equal(t, 1, expected.repeatability)
+		// Test
+		_, err := response.Write(recorder, req)
+
+		// Assertions
+		assert.NoError(t, err)
+		got = append(got, recorder.Code)
+	}
+
+	// A matching condition does not advance the sequence
+	want := []int{http.StatusOK, http.StatusServiceUnavailable, http.StatusOK, http.StatusForbidden}
+	assert.Equal(t, want, got)
 }
 
-func TestResponse_Twice(t *testing.T) {
+func TestResponse_Write_ResponseHook(t *testing.T) {
 	// Setup
-	expected := &Request{parent: new(Mock).Test(t)}
-	response := Response{parent: expected}
+	m := new(Mock).Test(t)
+	m.ResponseHook(func(resp *Response, r *http.Request) {
+		resp.SetBody(append(append([]byte(`{"data": `), resp.Body()...), '}'))
+	})
+	m.ResponseHook(func(resp *Response, r *http.Request) {
+		// Runs after the first hook, so it sees the wrapped body
+		resp.Header("X-Body-Length", strconv.Itoa(len(resp.Body())))
+		resp.SetStatusCode(resp.StatusCode() + 1)
+	})
+	resp := m.On(http.MethodGet, "/foo", nil).RespondJSON(http.StatusOK, map[string]string{"id": "1234"})
+	req := httptest.NewRequest(http.MethodGet, "/foo", http.NoBody)
 
-	// Test
-	response.Twice()
+	for i := 0; i < 2; i++ {
+		recorder := httptest.NewRecorder()
 
-	// Assertions
-	assert.Equal(t, 2, expected.repeatability)
+		// Test
+		_, gotErr := resp.Write(recorder, req)
+
+		// Assertions
+		assert.NoError(t, gotErr)
+		assert.Equal(t, http.StatusCreated, recorder.Code)
+		assert.Equal(t, `{"data": {"id":"1234"}}`, recorder.Body.String())
+		assert.Equal(t, []string{"23"}, recorder.Header().Values("X-Body-Length"))
+		assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+	}
+
+	// The configured response is not modified
+	assert.Equal(t, http.StatusOK, resp.StatusCode())
+	assert.Equal(t, `{"id":"1234"}`, string(resp.Body()))
+	assert.Empty(t, resp.header.Values("X-Body-Length"))
 }
 
-func TestResponse_Times(t *testing.T) {
+func Test_bodyAllowedForStatus(t *testing.T) {
+	tests := []struct {
+		statusCode int
+		want       bool
+	}{
+		{statusCode: http.StatusOK, want: true},
+		{statusCode: http.StatusAccepted, want: true},
+		{statusCode: http.StatusNoContent, want: false},
+		{statusCode: http.StatusResetContent, want: false},
+		{statusCode: http.StatusNotModified, want: false},
+		{statusCode: http.StatusNotFound, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.statusCode), func(t *testing.T) {
+			// Test and Assertions
+			assert.Equal(t, tt.want, bodyAllowedForStatus(tt.statusCode))
+		})
+	}
+}
+
+func TestResponse_Write_RespondStatus(t *testing.T) {
+	tests := []struct {
+		name              string
+		statusCode        int
+		wantContentLength []string
+	}{
+		{
+			name:              "accepted",
+			statusCode:        http.StatusAccepted,
+			wantContentLength: []string{"0"},
+		},
+		{
+			name:       "no-content",
+			statusCode: http.StatusNoContent,
+		},
+		{
+			name:       "not-modified",
+			statusCode: http.StatusNotModified,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			m := new(Mock).Test(t)
+			resp := m.On(http.MethodGet, "/foo", nil).RespondStatus(tt.statusCode).Header("Content-Length", "0")
+			recorder := httptest.NewRecorder()
+
+			// Test
+			gotN, gotErr := resp.Write(recorder, httptest.NewRequest(http.MethodGet, "/foo", http.NoBody))
+
+			// Assertions
+			assert.NoError(t, gotErr)
+			assert.Zero(t, gotN)
+			assert.Equal(t, tt.statusCode, recorder.Code)
+			assert.Empty(t, recorder.Body.Bytes())
+			assert.Equal(t, tt.wantContentLength, recorder.Header().Values("Content-Length"))
+		})
+	}
+}
+
+func TestResponse_Write_RespondStatus_IgnoresBody(t *testing.T) {
 	// Setup
-	expected := &Request{parent: new(Mock).Test(t)}
-	response := Response{parent: expected}
+	m := new(Mock).Test(t)
+	resp := m.On(http.MethodGet, "/foo", nil).RespondStatus(http.StatusAccepted).SetBody([]byte(testBody))
+	recorder := httptest.NewRecorder()
 
 	// Test
-	response.Times(4)
+	gotN, gotErr := resp.Write(recorder, httptest.NewRequest(http.MethodGet, "/foo", http.NoBody))
 
 	// Assertions
-	assert.Equal(t, 4, expected.repeatability)
+	assert.NoError(t, gotErr)
+	assert.Zero(t, gotN)
+	assert.Equal(t, http.StatusAccepted, recorder.Code)
+	assert.Empty(t, recorder.Body.Bytes())
 }
 
-func TestResponse_On(t *testing.T) {
+func TestResponse_Write_RespondStatus_BodyNotAllowed(t *testing.T) {
 	// Setup
-	response := &Response{
-		parent:     &Request{parent: new(Mock).Test(t)},
-		statusCode: http.StatusOK,
-		body:       []byte(testBody),
-	}
+	var successfulWriteCall int
+
+	mockT := new(MockTestingT)
+	m := new(Mock).Test(mockT)
+	resp := m.On(http.MethodGet, "/foo", nil).RespondStatus(http.StatusNoContent).SetBody([]byte(testBody))
+	recorder := httptest.NewRecorder()
+
+	defer func() {
+		r := recover()
+		if r == nil {
+			t.Fatal("Did not expect to get here")
+		}
+		// Assertions
+		assert.Equal(t, "FailNow was called", r.(string))
+		assert.Equal(t, 1, mockT.errorfCount)
+		assert.Equal(t, 1, mockT.failNowCount)
+		assert.Zero(t, successfulWriteCall)
+		assert.False(t, recorder.Flushed)
+		assert.Empty(t, recorder.Body.Bytes())
+	}()
 
 	// Test
-	got := response.On(http.MethodPut, "test.com/foo", []byte(`{"foo": "bar"}`))
+	_, _ = resp.Write(recorder, httptest.NewRequest(http.MethodGet, "/foo", http.NoBody))
+	successfulWriteCall++
+}
 
-	// Assertions
-	assert.NotNil(t, got)
-	wantExpectedRequests := []*Request{got}
-	assert.Equal(t, wantExpectedRequests, response.parent.parent.ExpectedRequests)
+func TestResponse_Write_SniffContentType(t *testing.T) {
+	tests := []struct {
+		name            string
+		response        *Response
+		sniff           bool
+		wantContentType string
+	}{
+		{
+			name:            "disabled",
+			response:        &Response{statusCode: http.StatusOK, header: http.Header{}, body: []byte("<html></html>")},
+			wantContentType: "",
+		},
+		{
+			name:            "sniffed",
+			response:        &Response{statusCode: http.StatusOK, header: http.Header{}, body: []byte("<html></html>")},
+			sniff:           true,
+			wantContentType: "text/html; charset=utf-8",
+		},
+		{
+			name: "sniffed-stream",
+			response: &Response{
+				statusCode: http.StatusOK,
+				header:     http.Header{},
+				chunks:     [][]byte{[]byte(testBody), []byte("<html></html>")},
+			},
+			sniff:           true,
+			wantContentType: "text/plain; charset=utf-8",
+		},
+		{
+			name:            "empty-body",
+			response:        &Response{statusCode: http.StatusNoContent, header: http.Header{}},
+			sniff:           true,
+			wantContentType: "",
+		},
+		{
+			name: "content-type-default",
+			response: &Response{
+				statusCode:  http.StatusOK,
+				header:      http.Header{},
+				body:        []byte("<html></html>"),
+				contentType: "application/json",
+			},
+			sniff:           true,
+			wantContentType: "application/json",
+		},
+		{
+			name: "header",
+			response: &Response{
+				statusCode: http.StatusOK,
+				header:     http.Header{"Content-Type": []string{"text/csv"}},
+				body:       []byte("<html></html>"),
+			},
+			sniff:           true,
+			wantContentType: "text/csv",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			tt.response.parent = &Request{parent: new(Mock).Test(t).SniffContentType(tt.sniff)}
+
+			recorder := httptest.NewRecorder()
+
+			// Test
+			_, gotErr := tt.response.Write(recorder, nil)
+
+			// Assertions
+			assert.NoError(t, gotErr)
+			assert.Equal(t, tt.wantContentType, recorder.Result().Header.Get("Content-Type"))
+		})
+	}
 }
 
-func TestResponse_Write_FailWriteBody(t *testing.T) {
-	// Setup
-	response := &Response{
-		parent:     &Request{parent: new(Mock).Test(t)},
-		statusCode: http.StatusOK,
-		body:       []byte(testBody),
+func Test_acceptsGzip(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptEncoding []string
+		want           bool
+	}{
+		{
+			name: "missing",
+			want: false,
+		},
+		{
+			name:           "gzip",
+			acceptEncoding: []string{"gzip"},
+			want:           true,
+		},
+		{
+			name:           "list",
+			acceptEncoding: []string{"deflate, GZIP;q=0.5, br"},
+			want:           true,
+		},
+		{
+			name:           "multiple-headers",
+			acceptEncoding: []string{"deflate", "gzip"},
+			want:           true,
+		},
+		{
+			name:           "wildcard",
+			acceptEncoding: []string{"*"},
+			want:           true,
+		},
+		{
+			name:           "not-acceptable",
+			acceptEncoding: []string{"gzip;q=0, deflate"},
+			want:           false,
+		},
+		{
+			name:           "other",
+			acceptEncoding: []string{"identity"},
+			want:           false,
+		},
 	}
 
-	// Test
-	gotN, gotErr := response.Write(&badResponseWriter{}, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			received := httptest.NewRequest(http.MethodGet, "/foo", http.NoBody)
+			for _, v := range tt.acceptEncoding {
+				received.Header.Add("Accept-Encoding", v)
+			}
 
-	// Assertions
-	assert.Equal(t, -1, gotN)
-	assert.ErrorIs(t, gotErr, ErrWriteReturnBody)
+			// Test
+			got := acceptsGzip(received)
+
+			// Assertions
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestResponse_Write_Gzip(t *testing.T) {
+	tests := []struct {
+		name           string
+		mode           gzipMode
+		acceptEncoding string
+		body           []byte
+		wantHeaders    http.Header
+		wantCompressed bool
+	}{
+		{
+			name:           "negotiated",
+			mode:           gzipNegotiate,
+			acceptEncoding: "gzip",
+			body:           []byte(testBody),
+			wantHeaders:    http.Header{"Content-Encoding": []string{"gzip"}, "Vary": []string{"Accept-Encoding"}},
+			wantCompressed: true,
+		},
+		{
+			name:        "not-negotiated",
+			mode:        gzipNegotiate,
+			body:        []byte(testBody),
+			wantHeaders: http.Header{"Content-Length": []string{"12"}, "Vary": []string{"Accept-Encoding"}},
+		},
+		{
+			name:           "forced",
+			mode:           gzipForce,
+			body:           []byte(testBody),
+			wantHeaders:    http.Header{"Content-Encoding": []string{"gzip"}},
+			wantCompressed: true,
+		},
+		{
+			name:           "empty-body",
+			mode:           gzipForce,
+			wantHeaders:    http.Header{},
+			wantCompressed: false,
+		},
+		{
+			name:        "off",
+			mode:        gzipOff,
+			body:        []byte(testBody),
+			wantHeaders: http.Header{"Content-Length": []string{"12"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			response := &Response{
+				parent:     &Request{parent: new(Mock).Test(t)},
+				statusCode: http.StatusOK,
+				header:     http.Header{"Content-Length": []string{strconv.Itoa(len(tt.body))}},
+				body:       tt.body,
+				gzip:       tt.mode,
+			}
+			if len(tt.body) == 0 {
+				response.header = http.Header{}
+			}
+
+			received := httptest.NewRequest(http.MethodGet, "/foo", http.NoBody)
+			if tt.acceptEncoding != "" {
+				received.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			recorder := httptest.NewRecorder()
+
+			// Test
+			gotN, gotErr := response.Write(recorder, received)
+
+			// Assertions
+			assert.NoError(t, gotErr)
+			assert.Equal(t, tt.wantHeaders, recorder.Header())
+			assert.Equal(t, recorder.Body.Len(), gotN)
+
+			gotBody := recorder.Body.Bytes()
+			if tt.wantCompressed {
+				gz, err := gzip.NewReader(recorder.Body)
+				if err != nil {
+					t.Fatalf("unexpected error reading gzip body: %v", err)
+				}
+				if gotBody, err = io.ReadAll(gz); err != nil {
+					t.Fatalf("unexpected error reading gzip body: %v", err)
+				}
+			}
+			assert.Equal(t, string(tt.body), string(gotBody))
+		})
+	}
 }
 
 func TestResponse_Write(t *testing.T) {
@@ -196,7 +1433,7 @@ func TestResponse_Write(t *testing.T) {
 				header:     http.Header{"next": []string{"aaa21242"}},
 			},
 			wantStatusCode: http.StatusOK,
-			wantHeaders:    http.Header{"next": []string{"aaa21242"}},
+			wantHeaders:    http.Header{"Next": []string{"aaa21242"}},
 			wantBody:       []byte(testBody),
 		},
 		{
@@ -226,6 +1463,47 @@ func TestResponse_Write(t *testing.T) {
 			},
 			wantBody: []byte(testBody),
 		},
+		{
+			name: "ok-content-type",
+			response: &Response{
+				statusCode:  http.StatusOK,
+				body:        []byte(`{"foo": "bar"}`),
+				contentType: "application/json",
+			},
+			wantStatusCode: http.StatusOK,
+			wantHeaders:    http.Header{"Content-Type": []string{"application/json"}},
+			wantBody:       []byte(`{"foo": "bar"}`),
+		},
+		{
+			name: "ok-content-type-header-override",
+			response: &Response{
+				statusCode:  http.StatusOK,
+				header:      http.Header{"Content-Type": []string{"application/vnd.foo+json"}},
+				body:        []byte(`{"foo": "bar"}`),
+				contentType: "application/json",
+			},
+			wantStatusCode: http.StatusOK,
+			wantHeaders:    http.Header{"Content-Type": []string{"application/vnd.foo+json"}},
+			wantBody:       []byte(`{"foo": "bar"}`),
+		},
+		{
+			name: "ok-cookies",
+			response: &Response{
+				statusCode: http.StatusOK,
+				header:     http.Header{"Set-Cookie": []string{"theme=dark"}},
+				cookies: []*http.Cookie{
+					{Name: "session", Value: "1234", Path: "/", HttpOnly: true},
+					{Name: "csrf", Value: "5678"},
+					{Name: "invalid name"},
+				},
+				body: []byte(testBody),
+			},
+			wantStatusCode: http.StatusOK,
+			wantHeaders: http.Header{
+				"Set-Cookie": []string{"theme=dark", "session=1234; Path=/; HttpOnly", "csrf=5678"},
+			},
+			wantBody: []byte(testBody),
+		},
 		{
 			name: "bad-request",
 			response: &Response{
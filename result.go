@@ -0,0 +1,114 @@
+package httpmock
+
+import (
+	"net/http"
+	"slices"
+)
+
+// MatchResult describes how a received [http.Request] compared to each
+// expected [Request] of a [Mock]. It is returned by [Mock.Match], and allows
+// tests to inspect the matching logic without parsing the failure message.
+type MatchResult struct {
+	// Every expected [Request], ordered from the closest match to the furthest.
+	// Candidates with the same number of differences keep the order in which
+	// they were registered.
+	Candidates []MatchCandidate
+}
+
+// Closest returns the [MatchCandidate] with the fewest differences, or nil if
+// the [Mock] has no expected [Request]'s.
+func (mr *MatchResult) Closest() *MatchCandidate {
+	if mr == nil || len(mr.Candidates) == 0 {
+		return nil
+	}
+	return &mr.Candidates[0]
+}
+
+// MatchCandidate describes how a received [http.Request] compared to a single
+// expected [Request].
+type MatchCandidate struct {
+	// The expected [Request] that was compared.
+	Request *Request
+
+	// The result of comparing the method, URL, body, each matcher, and the
+	// order of the expected [Request], in the order they appear in the diff.
+	Results []MatcherResult
+
+	// Total number of differences. A candidate with no differences matches.
+	Differences int
+
+	// Whether the expected [Request] has already been received the number of
+	// times configured with [Request.Times], so that it can no longer match.
+	Exhausted bool
+}
+
+// MatcherResult is the result of a single comparison of an expected [Request]
+// to a received [http.Request].
+type MatcherResult struct {
+	// Position of the comparison in the diff. 0, 1, and 2 are the method, URL,
+	// and body, followed by each matcher in the order they were added.
+	Index int
+
+	// Kind of comparison, which is one of "method", "url", "body", "matcher",
+	// "order", or "after".
+	Name string
+
+	// Whether the comparison found no differences.
+	Passed bool
+
+	// Number of differences found by the comparison.
+	Differences int
+
+	// Formatted description of the comparison, such as
+	// "PASS:  header Accept: application/json == application/json".
+	Output string
+
+	// Output as it appears in the diff, including the index.
+	raw string
+}
+
+// Match compares a received [http.Request] to the expected [Request]'s without
+// recording it, and returns the [Request] that [Mock.Requested] would match,
+// or nil if there is none. The [MatchResult] describes how each expected
+// [Request] compared, which is useful for debugging matchers. Like
+// [Mock.Requested], the body is decoded unless [Mock.DecodeRequestBodies] was
+// disabled. If the body cannot be matched, because it exceeds the limit of
+// [Mock.MaxBodyBuffer] or cannot be read or decoded, nil and a [MatchResult]
+// without candidates are returned.
+//
+//	matched, result := Mock.Match(req)
+//	for _, r := range result.Closest().Results {
+//		t.Log(r.Output)
+//	}
+func (m *Mock) Match(received *http.Request) (*Request, *MatchResult) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	_, tooLarge, restore, err := m.readReceivedBody(received)
+	defer restore()
+	result := &MatchResult{}
+	if tooLarge || err != nil {
+		return nil, result
+	}
+
+	for _, expected := range m.ExpectedRequests {
+		candidate := MatchCandidate{
+			Request:   expected,
+			Results:   expected.results(received),
+			Exhausted: expected.repeatability <= -1,
+		}
+		for _, r := range candidate.Results {
+			candidate.Differences += r.Differences
+		}
+		result.Candidates = append(result.Candidates, candidate)
+	}
+	slices.SortStableFunc(result.Candidates, func(a, b MatchCandidate) int {
+		return a.Differences - b.Differences
+	})
+
+	found, expected := m.findExpectedRequest(received)
+	if found < 0 {
+		return nil, result
+	}
+	return expected, result
+}
@@ -0,0 +1,137 @@
+package httpmock
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMock_Match(t *testing.T) {
+	// Setup
+	m := new(Mock)
+	m.On(http.MethodPost, "https://test.com/foo", nil)
+	m.On(http.MethodGet, "https://test.com/foo", nil).MatchHeader("Accept", "application/json")
+	m.On(http.MethodGet, "https://test.com/bar", nil)
+
+	received := mustNewRequest(http.NewRequest(http.MethodGet, "https://test.com/foo", http.NoBody))
+	received.Header.Set("Accept", "application/json")
+
+	// Test
+	gotRequest, gotResult := m.Match(received)
+
+	// Assertions
+	assert.Equal(t, m.ExpectedRequests[1], gotRequest)
+	assert.Len(t, gotResult.Candidates, 3)
+	assert.Equal(t, m.ExpectedRequests[1], gotResult.Closest().Request)
+	assert.Equal(t, 0, gotResult.Closest().Differences)
+	assert.False(t, gotResult.Closest().Exhausted)
+	gotNames := []string{}
+	for _, r := range gotResult.Closest().Results {
+		gotNames = append(gotNames, r.Name)
+		assert.True(t, r.Passed)
+	}
+	assert.Equal(t, []string{"method", "url", "body", "matcher"}, gotNames)
+	assert.Equal(t, 3, gotResult.Closest().Results[3].Index)
+	assert.Equal(t, `PASS:  header Accept: "application/json" == "application/json"`, gotResult.Closest().Results[3].Output)
+
+	// Candidates with the same number of differences keep their order
+	assert.Equal(t, m.ExpectedRequests[0], gotResult.Candidates[1].Request)
+	assert.Equal(t, m.ExpectedRequests[2], gotResult.Candidates[2].Request)
+	assert.Equal(t, 1, gotResult.Candidates[1].Differences)
+	assert.False(t, gotResult.Candidates[1].Results[0].Passed)
+
+	// Matching does not record the request
+	assert.Empty(t, m.Requests)
+	assert.Empty(t, m.History())
+}
+
+func TestMock_Match_NoMatch(t *testing.T) {
+	// Setup
+	m := new(Mock)
+	m.On(http.MethodGet, "https://test.com/foo", nil).MatchHeader("Accept", "application/json")
+
+	received := mustNewRequest(http.NewRequest(http.MethodGet, "https://test.com/foo", http.NoBody))
+
+	// Test
+	gotRequest, gotResult := m.Match(received)
+
+	// Assertions
+	assert.Nil(t, gotRequest)
+	assert.Equal(t, 1, gotResult.Closest().Differences)
+	assert.Equal(t, MatcherResult{
+		Index:       3,
+		Name:        "matcher",
+		Passed:      false,
+		Differences: 1,
+		Output:      `FAIL:  header Accept: (Missing) != "application/json"`,
+		raw:         "\t3: FAIL:  header Accept: (Missing) != \"application/json\"\n",
+	}, gotResult.Closest().Results[3])
+}
+
+func TestMock_Match_Exhausted(t *testing.T) {
+	// Setup
+	m := new(Mock)
+	m.On(http.MethodGet, "https://test.com/foo", nil).Times(-1)
+
+	received := mustNewRequest(http.NewRequest(http.MethodGet, "https://test.com/foo", http.NoBody))
+
+	// Test
+	gotRequest, gotResult := m.Match(received)
+
+	// Assertions
+	assert.Nil(t, gotRequest)
+	assert.Equal(t, 0, gotResult.Closest().Differences)
+	assert.True(t, gotResult.Closest().Exhausted)
+}
+
+func TestMock_Match_DecodedBody(t *testing.T) {
+	// Setup
+	m := new(Mock)
+	m.On(http.MethodPost, "https://test.com/foo", []byte(testBody))
+
+	received := mustNewRequest(http.NewRequest(http.MethodPost, "https://test.com/foo", bytes.NewReader(mustEncode("gzip", []byte(testBody)))))
+	received.Header.Set("Content-Encoding", "gzip")
+
+	// Test
+	gotRequest, gotResult := m.Match(received)
+	gotBody, err := io.ReadAll(received.Body)
+	if err != nil {
+		t.Fatalf("unexpected error reading body: %v", err)
+	}
+
+	// Assertions
+	assert.Equal(t, m.ExpectedRequests[0], gotRequest)
+	assert.Equal(t, 0, gotResult.Closest().Differences)
+	assert.Equal(t, mustEncode("gzip", []byte(testBody)), gotBody)
+}
+
+func TestMock_Match_BodyTooLarge(t *testing.T) {
+	// Setup
+	m := new(Mock).MaxBodyBuffer(5)
+	m.On(http.MethodPost, "https://test.com/foo", AnyBody)
+
+	received := mustNewRequest(http.NewRequest(http.MethodPost, "https://test.com/foo", strings.NewReader(testBody)))
+
+	// Test
+	gotRequest, gotResult := m.Match(received)
+
+	// Assertions
+	assert.Nil(t, gotRequest)
+	assert.Empty(t, gotResult.Candidates)
+}
+
+func TestMatchResult_Closest(t *testing.T) {
+	// Setup
+	var nilResult *MatchResult
+
+	// Test
+	got := new(MatchResult).Closest()
+
+	// Assertions
+	assert.Nil(t, got)
+	assert.Nil(t, nilResult.Closest())
+}
@@ -1,9 +1,25 @@
 package httpmock
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 // Server simplifies the orchestration of a [Mock] inside a handler and server.
@@ -16,8 +32,31 @@ type Server struct {
 
 	// Whether or not panics should be caught in the server goroutine or
 	// allowed to propagate to the parent process. If false, the panic will be
-	// printed and a 404 will be returned to the client.
+	// printed and the unmatched status will be returned to the client.
 	ignorePanic bool
+
+	// Status code returned to the client when a panic is caught. 0 means to
+	// use [http.StatusNotFound].
+	unmatchedStatus int
+
+	// Path of the Unix domain socket the server listens on, if any.
+	socketPath string
+
+	// Optional logger for received requests and recovered panics.
+	logger *slog.Logger
+
+	// Functions that run before a matched response is written, in the order
+	// they were added.
+	middlewares     []func(w http.ResponseWriter, r *http.Request)
+	middlewareMutex sync.Mutex
+
+	// Maximum amount of time to spend matching and responding to a request. 0
+	// means there is no limit.
+	matchTimeout time.Duration
+
+	// Whether responses are written as HTTP/1.0, closing the connection after
+	// each response.
+	forceHTTP10 bool
 }
 
 // ServerConfig contains settings for configuring a [Server]. It is used with
@@ -26,6 +65,20 @@ type ServerConfig struct {
 	// Create TLS-configured server
 	TLS bool
 
+	// Enable HTTP/2 on a TLS-configured server. HTTP/2 without TLS (h2c) is
+	// not supported, so this has no effect unless TLS or TLSConfig is also set.
+	HTTP2 bool
+
+	// Custom TLS configuration, such as certificates or client authentication.
+	// Setting this creates a TLS-configured server, regardless of TLS. If no
+	// certificates are provided, the default [httptest] certificate is used.
+	TLSConfig *tls.Config
+
+	// Path of a Unix domain socket to listen on instead of a TCP port. The
+	// socket file must not already exist, and it is removed when the server is
+	// closed. This may be combined with TLS.
+	UnixSocket string
+
 	// Custom server handler
 	Handler http.HandlerFunc
 }
@@ -35,12 +88,33 @@ type ServerConfig struct {
 func makeHandler(s *Server) http.HandlerFunc {
 	return http.HandlerFunc(
 		func(w http.ResponseWriter, r *http.Request) {
+			defer s.Mock.trackCall()()
+
+			if s.forceHTTP10 {
+				// Deferred first, so that it sends the response after any
+				// recovered panic has written its status
+				hw := &http10Writer{w: w, r: r, header: http.Header{}}
+				defer hw.finish()
+				w = hw
+			}
+
+			recorder := &statusRecorder{ResponseWriter: w}
+			if s.logger != nil {
+				w = recorder
+				r = r.WithContext(context.WithValue(r.Context(), loggerKey{}, s.logger))
+			}
+
 			defer func() {
 				if rc := recover(); rc != nil {
 					if s.IsRecoverable() {
-						fmt.Printf("%v\n", rc)
+						statusCode := s.unmatchedStatusCode()
+						if s.logger != nil {
+							s.logger.Error("httpmock: recovered from panic", "method", r.Method, "path", r.URL.Path, "panic", fmt.Sprint(rc), "status", statusCode)
+						} else {
+							fmt.Printf("%v\n", rc)
+						}
 
-						w.WriteHeader(http.StatusNotFound)
+						w.WriteHeader(statusCode)
 					} else {
 						panic(rc)
 					}
@@ -48,14 +122,317 @@ func makeHandler(s *Server) http.HandlerFunc {
 				}
 			}()
 
-			response := s.Mock.Requested(r)
-			if _, err := response.Write(w, r); err != nil {
-				s.Mock.fail("failed to write response for request:\n%s\nwith error: %v", response.parent.String(), err)
+			s.middlewareMutex.Lock()
+			middlewares := s.middlewares
+			s.middlewareMutex.Unlock()
+
+			serve := func(w http.ResponseWriter) *Response {
+				return s.Mock.serve(w, r, middlewares)
+			}
+
+			var response *Response
+			if s.matchTimeout > 0 {
+				// Recovered panics must not write to the response once it
+				// has timed out
+				tw := &timeoutWriter{w: w, header: http.Header{}}
+				w = tw
+				response = s.serveWithTimeout(tw, r, serve)
+			} else {
+				response = serve(w)
+			}
+
+			if s.logger != nil {
+				s.logger.Info("httpmock: request", "method", r.Method, "path", r.URL.Path, "matched", s.describeMatch(response), "status", recorder.statusCode)
 			}
 		},
 	)
 }
 
+// loggerKey is the context key of the logger set with [Server.SetLogger], so
+// that a [ResponseWriter] can log to it.
+type loggerKey struct{}
+
+// requestLogger returns the logger set with [Server.SetLogger] for the server
+// that received a [http.Request], or nil if there is none.
+func requestLogger(r *http.Request) *slog.Logger {
+	if r == nil {
+		return nil
+	}
+	logger, _ := r.Context().Value(loggerKey{}).(*slog.Logger)
+	return logger
+}
+
+// serveWithTimeout runs serve in a separate goroutine, and waits for it for up
+// to the match timeout of the [Server]. If it times out, a 504 is written if
+// the response has not started, and the test fails. Panics in serve are
+// propagated to the caller, so that they are recovered as usual.
+func (s *Server) serveWithTimeout(tw *timeoutWriter, r *http.Request, serve func(w http.ResponseWriter) *Response) *Response {
+	type result struct {
+		response  *Response
+		panicked  any
+		completed bool
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		var res result
+		defer func() {
+			res.panicked = recover()
+			done <- res
+		}()
+
+		res.response = serve(tw)
+		res.completed = true
+	}()
+
+	timer := time.NewTimer(s.matchTimeout)
+	defer timer.Stop()
+
+	select {
+	case res := <-done:
+		if res.panicked != nil {
+			panic(res.panicked)
+		}
+		if !res.completed {
+			// The test was failed with FailNow, which exits the goroutine
+			runtime.Goexit()
+		}
+		return res.response
+	case <-timer.C:
+		tw.timeout()
+		s.Mock.fail("\nassert: httpmock: Timed out after %s while matching or responding to request %s %s.\n\tCheck for a RequestMatcher or ResponseWriter that does not return.", s.matchTimeout, r.Method, r.URL.String())
+		return nil
+	}
+}
+
+// timeoutWriter is a [http.ResponseWriter] that stops writing to the underlying
+// [http.ResponseWriter] once a request times out, so that a goroutine that is
+// still responding does not write concurrently with the handler. Headers are
+// buffered until they are written, for the same reason.
+type timeoutWriter struct {
+	w http.ResponseWriter
+
+	mutex       sync.Mutex
+	header      http.Header
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(statusCode int) {
+	tw.mutex.Lock()
+	defer tw.mutex.Unlock()
+
+	tw.writeHeader(statusCode)
+}
+
+// writeHeader copies the buffered headers and writes the status code. The
+// mutex must be held.
+func (tw *timeoutWriter) writeHeader(statusCode int) {
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.wroteHeader = true
+
+	h := tw.w.Header()
+	for key, values := range tw.header {
+		h[key] = values
+	}
+	tw.w.WriteHeader(statusCode)
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mutex.Lock()
+	defer tw.mutex.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	tw.writeHeader(http.StatusOK)
+	return tw.w.Write(b)
+}
+
+// Flush implements [http.Flusher], since streamed responses check for it
+// directly.
+func (tw *timeoutWriter) Flush() {
+	tw.mutex.Lock()
+	defer tw.mutex.Unlock()
+
+	if tw.timedOut {
+		return
+	}
+	tw.writeHeader(http.StatusOK)
+	_ = http.NewResponseController(tw.w).Flush()
+}
+
+func (tw *timeoutWriter) Unwrap() http.ResponseWriter {
+	return tw.w
+}
+
+// timeout stops any further writes, and writes a 504 if the response has not
+// started.
+func (tw *timeoutWriter) timeout() {
+	tw.mutex.Lock()
+	defer tw.mutex.Unlock()
+
+	if !tw.wroteHeader {
+		tw.w.WriteHeader(http.StatusGatewayTimeout)
+		tw.wroteHeader = true
+	}
+	tw.timedOut = true
+}
+
+// describeMatch returns a short description of the [Request] that a
+// [Response] belongs to, for logging.
+func (s *Server) describeMatch(response *Response) string {
+	if response == nil {
+		return "(Timed Out)"
+	}
+
+	s.Mock.mutex.Lock()
+	defaultResponse := s.Mock.defaultResponse
+	passthroughResponse := s.Mock.passthroughResponse
+	s.Mock.mutex.Unlock()
+
+	switch response {
+	case defaultResponse:
+		return "(Default)"
+	case passthroughResponse:
+		return "(Passthrough)"
+	}
+	return response.parent.summary()
+}
+
+// statusRecorder is a [http.ResponseWriter] that records the status code that
+// was written, for logging. It supports [http.ResponseController], so that
+// responses can still flush and hijack the connection.
+type statusRecorder struct {
+	http.ResponseWriter
+
+	statusCode int
+}
+
+func (sr *statusRecorder) WriteHeader(statusCode int) {
+	if sr.statusCode == 0 {
+		sr.statusCode = statusCode
+	}
+	sr.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (sr *statusRecorder) Write(b []byte) (int, error) {
+	if sr.statusCode == 0 {
+		sr.statusCode = http.StatusOK
+	}
+	return sr.ResponseWriter.Write(b)
+}
+
+// Flush implements [http.Flusher], since streamed responses check for it
+// directly.
+func (sr *statusRecorder) Flush() {
+	_ = http.NewResponseController(sr.ResponseWriter).Flush()
+}
+
+func (sr *statusRecorder) Unwrap() http.ResponseWriter {
+	return sr.ResponseWriter
+}
+
+// http10Writer is a [http.ResponseWriter] that buffers a response and then
+// writes it as HTTP/1.0 directly to the hijacked connection, which is closed
+// afterward. If the response was flushed, the Content-Length is omitted, so the
+// body is delimited by closing the connection.
+type http10Writer struct {
+	w http.ResponseWriter
+	r *http.Request
+
+	header     http.Header
+	statusCode int
+	body       bytes.Buffer
+	flushed    bool
+}
+
+func (hw *http10Writer) Header() http.Header {
+	return hw.header
+}
+
+func (hw *http10Writer) WriteHeader(statusCode int) {
+	if hw.statusCode == 0 {
+		hw.statusCode = statusCode
+	}
+}
+
+func (hw *http10Writer) Write(b []byte) (int, error) {
+	hw.WriteHeader(http.StatusOK)
+	return hw.body.Write(b)
+}
+
+// Flush implements [http.Flusher], since streamed responses check for it
+// directly. Nothing is sent until the handler returns.
+func (hw *http10Writer) Flush() {
+	hw.WriteHeader(http.StatusOK)
+	hw.flushed = true
+}
+
+func (hw *http10Writer) Unwrap() http.ResponseWriter {
+	return hw.w
+}
+
+// finish writes the buffered response to the client as HTTP/1.0. If the
+// connection cannot be hijacked, such as with HTTP/2, the response is written
+// normally instead. If the connection was already hijacked, such as by
+// [Request.RespondReset], nothing is written.
+func (hw *http10Writer) finish() {
+	statusCode := hw.statusCode
+	if statusCode == 0 {
+		statusCode = http.StatusOK
+	}
+
+	h := hw.header.Clone()
+	// HTTP/1.0 has no trailers
+	for _, declared := range h.Values("Trailer") {
+		for _, key := range strings.Split(declared, ",") {
+			h.Del(strings.TrimSpace(key))
+		}
+	}
+	h.Del("Trailer")
+	bodyAllowed := statusCode >= 200 && statusCode != http.StatusNoContent && statusCode != http.StatusNotModified
+	if h.Get("Date") == "" {
+		h.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+	}
+	if bodyAllowed && hw.body.Len() > 0 && h.Get("Content-Type") == "" {
+		h.Set("Content-Type", http.DetectContentType(hw.body.Bytes()))
+	}
+	if bodyAllowed && !hw.flushed && h.Get("Content-Length") == "" {
+		h.Set("Content-Length", strconv.Itoa(hw.body.Len()))
+	}
+	h.Set("Connection", "close")
+
+	conn, buf, err := http.NewResponseController(hw.w).Hijack()
+	if errors.Is(err, http.ErrHijacked) {
+		return
+	}
+	if err != nil {
+		out := hw.w.Header()
+		for key, values := range hw.header {
+			out[key] = values
+		}
+		hw.w.WriteHeader(statusCode)
+		_, _ = hw.w.Write(hw.body.Bytes())
+		return
+	}
+	defer conn.Close()
+
+	fmt.Fprintf(buf, "HTTP/1.0 %d %s\r\n", statusCode, http.StatusText(statusCode))
+	_ = h.Write(buf)
+	_, _ = buf.WriteString("\r\n")
+	if bodyAllowed && hw.r.Method != http.MethodHead {
+		_, _ = buf.Write(hw.body.Bytes())
+	}
+	_ = buf.Flush()
+}
+
 // NewServer creates a new [Server] and associated [Mock].
 func NewServer() *Server {
 	s := &Server{Mock: new(Mock)}
@@ -64,6 +441,8 @@ func NewServer() *Server {
 	return s
 }
 
+// NewServerWithConfig creates a new [Server] and associated [Mock], using the
+// provided [ServerConfig]. The server is started before it is returned.
 func NewServerWithConfig(cfg ServerConfig) *Server {
 	s := &Server{Mock: new(Mock)}
 
@@ -72,18 +451,248 @@ func NewServerWithConfig(cfg ServerConfig) *Server {
 		handler = http.HandlerFunc(makeHandler(s))
 	}
 
-	if cfg.TLS {
-		s.Server = httptest.NewTLSServer(handler)
+	s.Server = httptest.NewUnstartedServer(handler)
+	if cfg.UnixSocket != "" {
+		l, err := net.Listen("unix", cfg.UnixSocket)
+		if err != nil {
+			panic(fmt.Sprintf("httpmock: failed to listen on unix socket %q: %v", cfg.UnixSocket, err))
+		}
+		_ = s.Server.Listener.Close()
+		s.Server.Listener = l
+		s.socketPath = cfg.UnixSocket
+	}
+
+	if cfg.TLS || cfg.TLSConfig != nil {
+		s.Server.TLS = cfg.TLSConfig
+		s.Server.EnableHTTP2 = cfg.HTTP2
+		s.StartTLS()
 	} else {
-		s.Server = httptest.NewServer(handler)
+		s.Start()
 	}
 
 	return s
 }
 
+// NewUnstartedServer creates a new [Server] and associated [Mock], but does not
+// start it. This allows the listener or the [http.Server] to be configured
+// before the server is started with [Server.Start] or [Server.StartTLS], such
+// as to bind a specific port. The [Mock] is fully usable before the server is
+// started, so it is safe to register expected [Request]'s first.
+//
+//	s := httpmock.NewUnstartedServer()
+//	s.Config.ReadTimeout = time.Second
+//	s.On(http.MethodGet, "/some/path", nil).RespondOK(nil)
+//	s.Start()
+func NewUnstartedServer() *Server {
+	s := &Server{Mock: new(Mock)}
+	s.Server = httptest.NewUnstartedServer(http.HandlerFunc(makeHandler(s)))
+
+	return s
+}
+
+// Start starts a [Server] created with [NewUnstartedServer].
+func (s *Server) Start() {
+	s.Server.Start()
+
+	if s.socketPath != "" {
+		s.useUnixSocket()
+	}
+}
+
+// StartTLS starts TLS on a [Server] created with [NewUnstartedServer]. Like
+// [NewServerWithConfig], the client returned by [Server.Client] trusts the
+// whole certificate chain of a custom [tls.Config].
+func (s *Server) StartTLS() {
+	s.Server.StartTLS()
+
+	// The httptest client only trusts the leaf certificate, so also trust the
+	// rest of the chain
+	if transport, ok := s.Server.Client().Transport.(*http.Transport); ok && transport.TLSClientConfig != nil {
+		transport.TLSClientConfig.RootCAs = s.CertPool()
+	}
+
+	if s.socketPath != "" {
+		s.useUnixSocket()
+	}
+}
+
+// unixSocketHost is the host of the URL of a [Server] that listens on a Unix
+// domain socket. It is reserved by RFC 2606, so it never resolves, and requests
+// from clients that do not dial the socket fail rather than reaching the
+// network.
+const unixSocketHost = "unix.invalid"
+
+// useUnixSocket configures the URL and client of a [Server] that listens on a
+// Unix domain socket. The socket path cannot be represented in a URL, so the
+// URL uses the reserved [unixSocketHost], and the client dials the socket
+// regardless of the host. The certificate of a TLS-configured [Server] is not
+// valid for that host, so the client verifies its chain without the host name,
+// as [Server.Transport] does.
+func (s *Server) useUnixSocket() {
+	scheme, _, _ := strings.Cut(s.URL, "://")
+	s.URL = scheme + "://" + unixSocketHost
+
+	if transport, ok := s.Server.Client().Transport.(*http.Transport); ok {
+		var dialer net.Dialer
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", s.socketPath)
+		}
+		if transport.TLSClientConfig != nil {
+			transport.TLSClientConfig.InsecureSkipVerify = true
+			transport.TLSClientConfig.VerifyConnection = verifyChain(s.CertPool())
+		}
+	}
+}
+
+// SocketPath returns the path of the Unix domain socket the [Server] listens
+// on, as set with [ServerConfig.UnixSocket]. If the [Server] listens on a TCP
+// port, an empty string is returned.
+func (s *Server) SocketPath() string {
+	return s.socketPath
+}
+
+// Close shuts down the [Server] and blocks until all outstanding requests have
+// completed. If the [Server] listens on a Unix domain socket, the socket file
+// is removed.
+func (s *Server) Close() {
+	s.Server.Close()
+
+	if s.socketPath != "" {
+		if err := os.Remove(s.socketPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			fmt.Printf("httpmock: failed to remove unix socket %q: %v\n", s.socketPath, err)
+		}
+	}
+}
+
+// Client returns a [http.Client] that is configured to make requests to the
+// [Server]. If the [Server] is TLS-configured, the client trusts the server's
+// certificate, as well as any certificates in its chain, such as a custom CA
+// provided with [ServerConfig.TLSConfig].
+//
+// The same client is returned on every call, and it is closed when the
+// [Server] is closed.
+func (s *Server) Client() *http.Client {
+	return s.Server.Client()
+}
+
+// Transport returns a [http.RoundTripper] that connects to the [Server] for
+// every request, regardless of the host in the request's URL, and without
+// resolving it with DNS. This lets code that builds its own [http.Client] use
+// production URLs while still sending requests over a real socket. Unlike
+// [Mock.RoundTripper], the request passes through the server's TCP or Unix
+// socket, TLS, and HTTP/2 stacks. The Host header is unchanged, so it can be
+// checked with [Request.MatchHost].
+//
+// If the [Server] is TLS-configured, the transport trusts the certificates in
+// [Server.CertPool] for any host name, since every host is served by the
+// [Server]. The scheme of the URL must still match the [Server], such as https
+// for a TLS-configured [Server]. Redirects are followed by the [http.Client],
+// so a redirect to any other host is also sent to the [Server].
+//
+// The [Server] must be started before calling Transport. A new transport is
+// returned on every call.
+//
+//	client := &http.Client{Transport: Server.Transport()}
+//	resp, err := client.Get("https://api.example.com/users/1234")
+func (s *Server) Transport() http.RoundTripper {
+	addr := s.Listener.Addr()
+	var dialer net.Dialer
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, addr.Network(), addr.String())
+		},
+		ForceAttemptHTTP2: s.EnableHTTP2,
+	}
+
+	if pool := s.CertPool(); pool != nil {
+		transport.TLSClientConfig = &tls.Config{
+			// The host name never matches the certificate, so the chain is
+			// verified without it instead
+			InsecureSkipVerify: true,
+			VerifyConnection:   verifyChain(pool),
+		}
+	}
+
+	return transport
+}
+
+// verifyChain creates a [tls.Config] VerifyConnection function that verifies
+// the certificate chain presented by a [Server] against pool, without
+// checking the host name.
+func verifyChain(pool *x509.CertPool) func(tls.ConnectionState) error {
+	return func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) == 0 {
+			return errors.New("httpmock: server did not present a certificate")
+		}
+		opts := x509.VerifyOptions{Roots: pool, Intermediates: x509.NewCertPool()}
+		for _, cert := range cs.PeerCertificates[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+		_, err := cs.PeerCertificates[0].Verify(opts)
+		return err
+	}
+}
+
+// Addr returns the address the [Server] listens on, which has the form
+// ipaddr:port, for clients that take an address rather than a URL. If the
+// [Server] listens on a Unix domain socket, the path of the socket is returned.
+//
+//	conn, err := net.Dial("tcp", Server.Addr())
+func (s *Server) Addr() string {
+	return s.Listener.Addr().String()
+}
+
+// BaseURL returns the parsed URL of the [Server], which has the form
+// http://ipaddr:port or https://ipaddr:port. A new copy is returned on every
+// call, so it may be modified freely.
+func (s *Server) BaseURL() *url.URL {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		s.Mock.fail("failed to parse server url %q. Error: %v\n", s.URL, err)
+	}
+
+	return u
+}
+
+// URLf formats a path according to a format specifier and joins it onto the URL
+// of the [Server], ensuring there is exactly one slash between them. The path
+// may include a query string.
+//
+//	Server.URLf("/users/%d?verbose=true", 1234)
+func (s *Server) URLf(format string, args ...any) string {
+	path := fmt.Sprintf(format, args...)
+	return strings.TrimSuffix(s.URL, "/") + "/" + strings.TrimPrefix(path, "/")
+}
+
+// CertPool returns a [x509.CertPool] containing the certificate chain used by
+// a TLS-configured [Server], which can be used to build a [http.Client] that
+// trusts the server. If the [Server] is not TLS-configured, nil is returned.
+//
+// The client returned by [Server.Client] already trusts the certificates, so
+// this is only needed when a different client must be used, such as one that
+// presents a client certificate.
+func (s *Server) CertPool() *x509.CertPool {
+	cert := s.Certificate()
+	if cert == nil {
+		return nil
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	if len(s.TLS.Certificates) > 0 {
+		for _, der := range s.TLS.Certificates[0].Certificate[1:] {
+			if c, err := x509.ParseCertificate(der); err == nil {
+				pool.AddCert(c)
+			}
+		}
+	}
+	return pool
+}
+
 // NotRecoverable sets a [Server] as not recoverable, so that panics are allowed
 // to propagate to the main process. With the default handler, panics are caught
-// and printed to stdout, with a final 404 returned to the client.
+// and printed to stdout, with a final 404 returned to the client, or the status
+// code set with [Server.UnmatchedStatus].
 //
 // 404 was chosen rather than 500 due to panics almost always occurring when a
 // matching [Request] cannot be found. However, custom handlers can choose to
@@ -94,6 +703,93 @@ func (s *Server) NotRecoverable() *Server {
 	return s
 }
 
+// UnmatchedStatus sets the status code that the default handler returns to the
+// client when a panic is caught, such as when a matching [Request] cannot be
+// found. It defaults to 404. The test fails if statusCode is not a valid HTTP
+// status code.
+//
+//	Server.UnmatchedStatus(http.StatusNotImplemented)
+func (s *Server) UnmatchedStatus(statusCode int) *Server {
+	if statusCode < 100 || statusCode > 599 {
+		s.Mock.fail("invalid unmatched status code %d\n", statusCode)
+	}
+
+	s.unmatchedStatus = statusCode
+	return s
+}
+
+// SetLogger sets a logger that the default handler uses to log every received
+// request, including its method, path, the [Request] it matched, and the status
+// code that was written. Recovered panics are also logged, rather than printed
+// to stdout. By default, requests are not logged.
+//
+//	Server.SetLogger(slog.New(slog.NewTextHandler(os.Stderr, nil)))
+func (s *Server) SetLogger(logger *slog.Logger) *Server {
+	s.logger = logger
+	return s
+}
+
+// ResponseMiddleware adds a function that the default handler runs before
+// writing every matched response, which is useful for cross-cutting concerns
+// such as echoing a request ID. Middlewares run in the order they were added.
+// Headers set by a middleware are kept when the response is written, even if
+// the response sets the same header. Middlewares may be added while the
+// [Server] is running, and apply to requests received afterwards.
+//
+//	Server.ResponseMiddleware(func(w http.ResponseWriter, r *http.Request) {
+//		w.Header().Set("X-Request-Id", r.Header.Get("X-Request-Id"))
+//	})
+func (s *Server) ResponseMiddleware(fn func(w http.ResponseWriter, r *http.Request)) *Server {
+	s.middlewareMutex.Lock()
+	defer s.middlewareMutex.Unlock()
+
+	s.middlewares = append(s.middlewares, fn)
+	return s
+}
+
+// MatchTimeout sets the maximum amount of time that the default handler spends
+// matching and responding to a request, such as to detect a [RequestMatcher] or
+// [ResponseWriter] that never returns. If it is exceeded, a 504 is returned to
+// the client, unless the response has already started, and the test fails. The
+// default is no timeout.
+//
+// A timed out request keeps running in the background, and may still hold the
+// lock of the [Mock], so later requests will also time out. Panics that occur
+// before the timeout are recovered as usual.
+//
+//	Server.MatchTimeout(5 * time.Second)
+func (s *Server) MatchTimeout(d time.Duration) *Server {
+	s.matchTimeout = d
+	return s
+}
+
+// ForceHTTP10 sets the [Server] to write responses as HTTP/1.0, closing the
+// connection after each response, which helps reproduce the behavior of
+// clients against legacy servers. Requests are still read by the standard
+// server, so clients may send any HTTP/1.x request.
+//
+// Each response is buffered and written once the default handler returns, so
+// streamed responses, such as with [Request.RespondStream], arrive all at once.
+// Flushed responses omit the Content-Length, so their body is delimited by
+// closing the connection. Trailers are not sent. HTTP/2 connections cannot be
+// hijacked, so they are unaffected. This has no effect on a custom
+// [ServerConfig.Handler].
+//
+//	Server.ForceHTTP10()
+func (s *Server) ForceHTTP10() *Server {
+	s.forceHTTP10 = true
+	return s
+}
+
+// unmatchedStatusCode returns the status code set with
+// [Server.UnmatchedStatus], or 404 if it was not set.
+func (s *Server) unmatchedStatusCode() int {
+	if s.unmatchedStatus == 0 {
+		return http.StatusNotFound
+	}
+	return s.unmatchedStatus
+}
+
 // IsRecoverable returns whether or not the [Server] is considered recoverable.
 func (s *Server) IsRecoverable() bool {
 	return !s.ignorePanic
@@ -105,3 +801,55 @@ func (s *Server) IsRecoverable() bool {
 func (s *Server) On(method string, URL string, body []byte) *Request {
 	return s.Mock.On(method, URL, body)
 }
+
+// OnE is a convenience method to invoke the [Mock.OnE] method.
+//
+//	r, err := Server.OnE(http.MethodDelete, "/some/path/1234", nil)
+func (s *Server) OnE(method string, URL string, body []byte) (*Request, error) {
+	return s.Mock.OnE(method, URL, body)
+}
+
+// MaxBodyBuffer is a convenience method to invoke the [Mock.MaxBodyBuffer]
+// method.
+//
+//	Server.MaxBodyBuffer(1 << 20)
+func (s *Server) MaxBodyBuffer(n int64) *Server {
+	s.Mock.MaxBodyBuffer(n)
+	return s
+}
+
+// Record is a convenience method to invoke the [Mock.Record] method.
+//
+//	Server.Record("https://api.example.com")
+func (s *Server) Record(upstream string) *Server {
+	s.Mock.Record(upstream)
+	return s
+}
+
+// OnAny is a convenience method to invoke the [Mock.OnAny] method.
+//
+//	Server.OnAny("/some/path", AnyBody)
+func (s *Server) OnAny(URL string, body []byte) *Request {
+	return s.Mock.OnAny(URL, body)
+}
+
+// OnCORS is a convenience method to invoke the [Mock.OnCORS] method.
+//
+//	Server.OnCORS("/users", httpmock.CORSOptions{AllowedOrigins: []string{"https://app.example.com"}})
+func (s *Server) OnCORS(URL string, opts CORSOptions) *Request {
+	return s.Mock.OnCORS(URL, opts)
+}
+
+// OnString is a convenience method to invoke the [Mock.OnString] method.
+//
+//	Server.OnString(http.MethodPost, "/some/path", `{"id": 1234}`)
+func (s *Server) OnString(method string, URL string, body string) *Request {
+	return s.Mock.OnString(method, URL, body)
+}
+
+// OnReader is a convenience method to invoke the [Mock.OnReader] method.
+//
+//	Server.OnReader(http.MethodPost, "/some/path", strings.NewReader(`{"id": 1234}`))
+func (s *Server) OnReader(method string, URL string, r io.Reader) *Request {
+	return s.Mock.OnReader(method, URL, r)
+}
@@ -1,47 +1,1189 @@
 package httpmock
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"log/slog"
+	"math/big"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
 
+// mustNewCertificate is a convenience test helper that creates a self-signed
+// certificate for localhost with the given common name, and panics if an error
+// occurs. It is only intended to be used during test setup.
+func mustNewCertificate(commonName string) tls.Certificate {
+	return mustNewSignedCertificate(commonName, nil)
+}
+
+// mustNewSignedCertificate is a convenience test helper that creates a
+// certificate for localhost with the given common name, signed by parent, and
+// panics if an error occurs. If parent is nil, the certificate is self-signed.
+// The certificate chain includes the parent. It is only intended to be used
+// during test setup.
+func mustNewSignedCertificate(commonName string, parent *tls.Certificate) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		panic(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+		DNSNames:              []string{"localhost"},
+	}
+
+	signer, signerKey := template, any(key)
+	var chain [][]byte
+	if parent != nil {
+		signer, signerKey = parent.Leaf, parent.PrivateKey
+		chain = parent.Certificate
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		panic(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		panic(err)
+	}
+
+	return tls.Certificate{Certificate: append([][]byte{der}, chain...), PrivateKey: key, Leaf: leaf}
+}
+
 func Test_NewServer(t *testing.T) {
 	// Test
 	s := NewServer()
 	defer s.Close()
 
 	// Assertions
-	assert.NotNil(t, s.Mock)
-	if s.Server == nil {
-		t.Fatalf("unexpected nil Server")
+	assert.NotNil(t, s.Mock)
+	if s.Server == nil {
+		t.Fatalf("unexpected nil Server")
+	}
+	// Equivalent to s != TLSServer
+	assert.Nil(t, s.Server.TLS)
+	// Equivalent to the server having been Start()'ed already
+	assert.NotEmpty(t, s.Server.URL)
+}
+
+func Test_NewUnstartedServer(t *testing.T) {
+	tests := []struct {
+		name    string
+		start   func(s *Server)
+		wantTLS bool
+	}{
+		{
+			name:  "start",
+			start: func(s *Server) { s.Start() },
+		},
+		{
+			name:    "start-tls",
+			start:   func(s *Server) { s.StartTLS() },
+			wantTLS: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			s := NewUnstartedServer()
+			defer s.Close()
+
+			// Test
+			assert.Empty(t, s.URL)
+			s.Config.ReadHeaderTimeout = time.Second
+			s.On(http.MethodGet, "/foo", nil).RespondOK([]byte(testBody))
+			tt.start(s)
+
+			got, err := s.Client().Get(s.URLf("/foo"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer got.Body.Close()
+
+			// Assertions
+			assert.Equal(t, tt.wantTLS, s.Server.TLS != nil)
+			assert.Equal(t, http.StatusOK, got.StatusCode)
+			s.Mock.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_NewServerWithConfig_TLS(t *testing.T) {
+	// Setup
+	cfg := ServerConfig{TLS: true}
+
+	// Test
+	s := NewServerWithConfig(cfg)
+
+	// Assertions
+	assert.NotNil(t, s.Mock)
+	if s.Server == nil {
+		t.Fatalf("unexpected nil Server")
+	}
+	// Equivalent to s == TLSServer
+	assert.NotNil(t, s.Server.TLS)
+	// Equivalent to the server having been Start()'ed already
+	assert.NotEmpty(t, s.Server.URL)
+}
+
+func Test_NewServerWithConfig_HTTP2(t *testing.T) {
+	tests := []struct {
+		name           string
+		cfg            ServerConfig
+		wantProtoMajor int
+	}{
+		{
+			name:           "tls",
+			cfg:            ServerConfig{TLS: true, HTTP2: true},
+			wantProtoMajor: 2,
+		},
+		{
+			name:           "tls-disabled",
+			cfg:            ServerConfig{TLS: true},
+			wantProtoMajor: 1,
+		},
+		{
+			name:           "no-tls",
+			cfg:            ServerConfig{HTTP2: true},
+			wantProtoMajor: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			s := NewServerWithConfig(tt.cfg)
+			defer s.Close()
+			s.On(http.MethodGet, "/foo", nil).RespondOK([]byte(testBody))
+
+			// Test
+			got, err := s.Client().Get(s.URL + "/foo")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer got.Body.Close()
+
+			// Assertions
+			assert.Equal(t, http.StatusOK, got.StatusCode)
+			assert.Equal(t, tt.wantProtoMajor, got.ProtoMajor)
+		})
+	}
+}
+
+func Test_NewServerWithConfig_UnixSocket(t *testing.T) {
+	for _, useTLS := range []bool{false, true} {
+		t.Run(fmt.Sprintf("tls-%t", useTLS), func(t *testing.T) {
+			// Setup
+			socketPath := filepath.Join(t.TempDir(), "httpmock.sock")
+			s := NewServerWithConfig(ServerConfig{TLS: useTLS, UnixSocket: socketPath})
+			s.On(http.MethodGet, "/foo", nil).RespondOK([]byte(testBody)).Once()
+
+			// Test
+			got, err := s.Client().Get(s.URLf("/foo"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer got.Body.Close()
+			gotBody, err := io.ReadAll(got.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Assertions
+			assert.Equal(t, socketPath, s.SocketPath())
+			assert.Equal(t, socketPath, s.Addr())
+			assert.Equal(t, "unix", s.Listener.Addr().Network())
+			assert.Equal(t, "unix.invalid", s.BaseURL().Host)
+			assert.Equal(t, http.StatusOK, got.StatusCode)
+			assert.Equal(t, testBody, string(gotBody))
+			assert.Equal(t, useTLS, got.TLS != nil)
+			s.Mock.AssertExpectations(t)
+
+			s.Close()
+			_, err = os.Stat(socketPath)
+			assert.ErrorIs(t, err, fs.ErrNotExist)
+		})
+	}
+}
+
+func Test_NewServerWithConfig_TLSConfig(t *testing.T) {
+	// Setup
+	cert := mustNewCertificate("test-server")
+	cfg := ServerConfig{TLSConfig: &tls.Config{Certificates: []tls.Certificate{cert}}}
+
+	// Test
+	s := NewServerWithConfig(cfg)
+	defer s.Close()
+	s.On(http.MethodGet, "/foo", nil).RespondOK([]byte(testBody))
+
+	got, err := s.Client().Get(s.URL + "/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer got.Body.Close()
+
+	// Assertions
+	assert.NotNil(t, s.Server.TLS)
+	assert.Equal(t, http.StatusOK, got.StatusCode)
+	assert.Equal(t, "test-server", got.TLS.PeerCertificates[0].Subject.CommonName)
+	// The provided config should not be modified when the server starts
+	assert.Nil(t, cfg.TLSConfig.NextProtos)
+}
+
+func Test_NewServerWithConfig_TLSConfig_ClientAuth(t *testing.T) {
+	// Setup
+	clientCert := mustNewCertificate("test-client")
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert.Leaf)
+
+	s := NewServerWithConfig(ServerConfig{
+		TLS: true,
+		TLSConfig: &tls.Config{
+			ClientAuth: tls.RequireAndVerifyClientCert,
+			ClientCAs:  clientCAs,
+		},
+	})
+	defer s.Close()
+	s.On(http.MethodGet, "/foo", nil).RespondOK([]byte(testBody))
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				RootCAs:      s.CertPool(),
+				Certificates: []tls.Certificate{clientCert},
+			},
+		},
+	}
+
+	// Test
+	_, gotNoCertErr := s.Client().Get(s.URL + "/foo")
+	got, err := client.Get(s.URL + "/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer got.Body.Close()
+
+	// Assertions
+	assert.Error(t, gotNoCertErr)
+	assert.Equal(t, http.StatusOK, got.StatusCode)
+}
+
+func TestServer_defaultHandler_MatchClientCertCN(t *testing.T) {
+	// Setup
+	clientCert := mustNewCertificate("test-client")
+	otherCert := mustNewCertificate("other-client")
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert.Leaf)
+	clientCAs.AddCert(otherCert.Leaf)
+
+	s := NewServerWithConfig(ServerConfig{
+		TLSConfig: &tls.Config{
+			ClientAuth: tls.VerifyClientCertIfGiven,
+			ClientCAs:  clientCAs,
+		},
+	})
+	defer s.Close()
+	s.On(http.MethodGet, "/foo", nil).MatchClientCertCN("test-client").RespondOK([]byte(testBody))
+
+	tests := []struct {
+		name           string
+		certificates   []tls.Certificate
+		wantStatusCode int
+	}{
+		{
+			name:           "match",
+			certificates:   []tls.Certificate{clientCert},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "mismatch",
+			certificates:   []tls.Certificate{otherCert},
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			name:           "no-certificate",
+			wantStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &http.Client{
+				Transport: &http.Transport{
+					TLSClientConfig: &tls.Config{RootCAs: s.CertPool(), Certificates: tt.certificates},
+				},
+			}
+
+			// Test
+			got, err := client.Get(s.URL + "/foo")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer got.Body.Close()
+
+			// Assertions
+			assert.Equal(t, tt.wantStatusCode, got.StatusCode)
+		})
+	}
+}
+
+func TestServer_defaultHandler_MatchServerName(t *testing.T) {
+	// Setup
+	s := NewServerWithConfig(ServerConfig{TLS: true})
+	defer s.Close()
+	s.On(http.MethodGet, "/foo", nil).MatchServerName("example.com").RespondOK([]byte(testBody))
+
+	tests := []struct {
+		name           string
+		serverName     string
+		wantStatusCode int
+	}{
+		{
+			name:           "match",
+			serverName:     "example.com",
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "no-server-name",
+			wantStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &http.Client{
+				Transport: &http.Transport{
+					TLSClientConfig: &tls.Config{RootCAs: s.CertPool(), ServerName: tt.serverName},
+				},
+			}
+
+			// Test
+			got, err := client.Get(s.URL + "/foo")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer got.Body.Close()
+
+			// Assertions
+			assert.Equal(t, tt.wantStatusCode, got.StatusCode)
+		})
+	}
+}
+
+func TestServer_defaultHandler_MatchRemoteIP(t *testing.T) {
+	// Setup
+	s := NewServer()
+	defer s.Close()
+	s.On(http.MethodGet, "/foo", nil).MatchRemoteIP("127.0.0.1").RespondOK([]byte(testBody))
+
+	// Test
+	got, err := s.Client().Get(s.URLf("/foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer got.Body.Close()
+
+	// Assertions
+	assert.Equal(t, http.StatusOK, got.StatusCode)
+	s.Mock.AssertExpectations(t)
+}
+
+func TestServer_defaultHandler_Gzip(t *testing.T) {
+	// Setup
+	s := NewServer()
+	defer s.Close()
+	s.On(http.MethodGet, "/foo", nil).RespondOK([]byte(testBody)).Gzip()
+
+	// Test
+	got, err := s.Client().Get(s.URL + "/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer got.Body.Close()
+	gotBody, err := io.ReadAll(got.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Assertions
+	assert.True(t, got.Uncompressed)
+	assert.Equal(t, testBody, string(gotBody))
+}
+
+func TestServer_defaultHandler_NoContentLength(t *testing.T) {
+	tests := []struct {
+		name                 string
+		noContentLength      bool
+		wantContentLength    int64
+		wantTransferEncoding []string
+	}{
+		{
+			name:              "content-length",
+			wantContentLength: int64(len(testBody)),
+		},
+		{
+			name:                 "no-content-length",
+			noContentLength:      true,
+			wantContentLength:    -1,
+			wantTransferEncoding: []string{"chunked"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			s := NewServer()
+			defer s.Close()
+			resp := s.On(http.MethodGet, "/foo", nil).RespondOK([]byte(testBody)).Header("Content-Length", strconv.Itoa(len(testBody)))
+			if tt.noContentLength {
+				resp.NoContentLength()
+			}
+
+			// Test
+			got, err := s.Client().Get(s.URLf("/foo"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer got.Body.Close()
+			gotBody, err := io.ReadAll(got.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Assertions
+			assert.Equal(t, tt.wantContentLength, got.ContentLength)
+			assert.Equal(t, tt.wantTransferEncoding, got.TransferEncoding)
+			assert.Equal(t, testBody, string(gotBody))
+		})
+	}
+}
+
+func TestServer_defaultHandler_RespondFunc(t *testing.T) {
+	// Setup
+	s := NewServer()
+	defer s.Close()
+	s.On(http.MethodGet, "/foo", nil).RespondFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("panic") != "" {
+			panic("testing panic")
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}).Twice()
+
+	// Test
+	got, err := s.Client().Get(s.URL + "/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer got.Body.Close()
+	gotPanic, err := s.Client().Get(s.URL + "/foo?panic=true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gotPanic.Body.Close()
+
+	// Assertions
+	assert.Equal(t, http.StatusAccepted, got.StatusCode)
+	assert.Equal(t, http.StatusNotFound, gotPanic.StatusCode)
+	s.Mock.AssertExpectations(t)
+}
+
+func TestServer_defaultHandler_RespondEcho(t *testing.T) {
+	// Setup
+	s := NewServer()
+	defer s.Close()
+	s.On(http.MethodPost, "/echo", AnyBody).MatchBodyContainsString("World").RespondEcho(http.StatusOK)
+
+	// Test
+	got, err := s.Client().Post(s.URLf("/echo"), "application/json", strings.NewReader(testBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer got.Body.Close()
+	gotBody, err := io.ReadAll(got.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Assertions
+	assert.Equal(t, http.StatusOK, got.StatusCode)
+	assert.Equal(t, "application/json", got.Header.Get("Content-Type"))
+	assert.Equal(t, testBody, string(gotBody))
+	s.Mock.AssertNumberOfRequests(t, http.MethodPost, "/echo", 1)
+	s.Mock.AssertNoUnexpectedCalls(t)
+}
+
+func TestServer_MaxBodyBuffer(t *testing.T) {
+	// Setup
+	s := NewServer().MaxBodyBuffer(5)
+	defer s.Close()
+	s.On(http.MethodPost, "/foo", AnyBody).RespondOK(nil)
+
+	// Test
+	gotLarge, err := s.Client().Post(s.URLf("/foo"), "text/plain", strings.NewReader(testBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gotLarge.Body.Close()
+	gotSmall, err := s.Client().Post(s.URLf("/foo"), "text/plain", strings.NewReader("Hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gotSmall.Body.Close()
+
+	// Assertions
+	assert.Equal(t, http.StatusNotFound, gotLarge.StatusCode)
+	assert.Equal(t, http.StatusOK, gotSmall.StatusCode)
+	s.Mock.AssertNumberOfRequests(t, http.MethodPost, "/foo", 1)
+}
+
+func TestServer_defaultHandler_OnAny(t *testing.T) {
+	// Setup
+	s := NewServer()
+	defer s.Close()
+	s.OnAny("/foo", AnyBody).Respond(http.StatusMethodNotAllowed, nil).Twice()
+	s.On(http.MethodGet, "/foo", nil).RespondOK(nil).Once()
+
+	// Test
+	gotGet, err := s.Client().Get(s.URL + "/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gotGet.Body.Close()
+	gotPost, err := s.Client().Post(s.URL+"/foo", "text/plain", strings.NewReader(testBody))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gotPost.Body.Close()
+	gotGetAgain, err := s.Client().Get(s.URL + "/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gotGetAgain.Body.Close()
+
+	// Assertions
+	assert.Equal(t, http.StatusOK, gotGet.StatusCode)
+	assert.Equal(t, http.StatusMethodNotAllowed, gotPost.StatusCode)
+	assert.Equal(t, http.StatusMethodNotAllowed, gotGetAgain.StatusCode)
+	s.Mock.AssertExpectations(t)
+}
+
+// newTestLogger is a convenience test helper that creates a logger that writes
+// text records without timestamps to buf.
+func newTestLogger(buf *bytes.Buffer) *slog.Logger {
+	return slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+}
+
+func TestServer_ResponseMiddleware(t *testing.T) {
+	// Setup
+	var order []string
+	s := NewServer().
+		ResponseMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			order = append(order, "first")
+			w.Header().Set("X-Request-Id", r.Header.Get("X-Request-Id"))
+			w.Header().Set("X-Overridden", "middleware")
+		}).
+		ResponseMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			order = append(order, "second")
+			w.Header().Set("Content-Type", "text/csv")
+		})
+	defer s.Close()
+	s.On(http.MethodGet, "/foo", nil).RespondJSON(http.StatusOK, []string{"a"}).Header("X-Overridden", "response")
+
+	test := mustNewRequest(http.NewRequest(http.MethodGet, s.URLf("/foo"), http.NoBody))
+	test.Header.Set("X-Request-Id", "1234")
+
+	// Test
+	got, err := s.Client().Do(test)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer got.Body.Close()
+
+	// Assertions
+	assert.Equal(t, http.StatusOK, got.StatusCode)
+	assert.Equal(t, "1234", got.Header.Get("X-Request-Id"))
+	assert.Equal(t, []string{"middleware"}, got.Header.Values("X-Overridden"))
+	assert.Equal(t, "text/csv", got.Header.Get("Content-Type"))
+	assert.Equal(t, []string{"first", "second"}, order)
+}
+
+func TestServer_ResponseMiddleware_Concurrent(t *testing.T) {
+	// Setup
+	s := NewServer()
+	defer s.Close()
+	s.On(http.MethodGet, "/foo", nil).RespondOK(nil)
+
+	// Test
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := s.Client().Get(s.URLf("/foo"))
+			if err == nil {
+				got.Body.Close()
+			}
+		}()
+		s.ResponseMiddleware(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Middleware", "1")
+		})
+	}
+	wg.Wait()
+
+	got, err := s.Client().Get(s.URLf("/foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer got.Body.Close()
+
+	// Assertions
+	assert.Len(t, got.Header.Values("X-Middleware"), 10)
+}
+
+func TestServer_SetLogger(t *testing.T) {
+	// Setup
+	var buf bytes.Buffer
+	s := NewServer().SetLogger(newTestLogger(&buf))
+	defer s.Close()
+	s.On(http.MethodGet, "/foo", nil).Respond(http.StatusAccepted, nil).Once()
+
+	// Test
+	got, err := s.Client().Get(s.URLf("/foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer got.Body.Close()
+	gotUnmatched, err := s.Client().Get(s.URLf("/bar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gotUnmatched.Body.Close()
+
+	// Assertions
+	assert.Equal(t, http.StatusAccepted, got.StatusCode)
+	assert.Equal(t, http.StatusNotFound, gotUnmatched.StatusCode)
+
+	gotLines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, gotLines, 2)
+	assert.Equal(t, `level=INFO msg="httpmock: request" method=GET path=/foo matched="GET /foo" status=202`, gotLines[0])
+	assert.True(t, strings.HasPrefix(gotLines[1], `level=ERROR msg="httpmock: recovered from panic" method=GET path=/bar panic=`))
+	assert.True(t, strings.HasSuffix(gotLines[1], ` status=404`))
+}
+
+func TestServer_SetLogger_Default(t *testing.T) {
+	// Setup
+	var buf bytes.Buffer
+	s := NewServer().SetLogger(newTestLogger(&buf))
+	defer s.Close()
+	s.Mock.RespondDefault(http.StatusTeapot, nil)
+
+	// Test
+	got, err := s.Client().Get(s.URLf("/foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer got.Body.Close()
+
+	// Assertions
+	assert.Equal(t, http.StatusTeapot, got.StatusCode)
+	assert.Equal(t, `level=INFO msg="httpmock: request" method=GET path=/foo matched=(Default) status=418`, strings.TrimSpace(buf.String()))
+}
+
+func TestServer_defaultHandler_MatchFuncPanic(t *testing.T) {
+	// Setup
+	s := NewServer()
+	defer s.Close()
+	s.On(http.MethodGet, "/foo", nil).MatchFunc(func(r *http.Request) bool {
+		if r.URL.Query().Get("panic") != "" {
+			panic("testing panic")
+		}
+		return true
+	})
+
+	// Test
+	gotPanic, err := s.Client().Get(s.URLf("/foo?panic=true"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gotPanic.Body.Close()
+	got, err := s.Client().Get(s.URLf("/foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer got.Body.Close()
+
+	// Assertions
+	assert.Equal(t, http.StatusNotFound, gotPanic.StatusCode)
+	assert.Equal(t, http.StatusOK, got.StatusCode)
+}
+
+func TestServer_MatchTimeout(t *testing.T) {
+	// Setup
+	release := make(chan struct{})
+	mockT := new(MockTestingT)
+	s := NewServer().MatchTimeout(50 * time.Millisecond)
+	defer s.Close()
+	s.Mock.Test(mockT)
+	s.On(http.MethodGet, "/hang", nil).MatchFunc(func(r *http.Request) bool {
+		<-release
+		return true
+	})
+
+	// Test
+	got, err := s.Client().Get(s.URLf("/hang"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer got.Body.Close()
+
+	// Assertions
+	assert.Equal(t, http.StatusGatewayTimeout, got.StatusCode)
+	assert.Equal(t, 1, mockT.errorfCount)
+	assert.Equal(t, 1, mockT.failNowCount)
+
+	// Let the hung matcher finish, so the request completes in the background
+	close(release)
+}
+
+func TestServer_MatchTimeout_NotExceeded(t *testing.T) {
+	// Setup
+	s := NewServer().MatchTimeout(5 * time.Second)
+	defer s.Close()
+	s.On(http.MethodGet, "/foo", nil).RespondOK([]byte(testBody)).Header("X-Trace", "abc")
+
+	// Test
+	got, err := s.Client().Get(s.URLf("/foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer got.Body.Close()
+	gotBody, err := io.ReadAll(got.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotUnexpected, err := s.Client().Get(s.URLf("/bar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gotUnexpected.Body.Close()
+
+	// Assertions
+	assert.Equal(t, http.StatusOK, got.StatusCode)
+	assert.Equal(t, "abc", got.Header.Get("X-Trace"))
+	assert.Equal(t, testBody, string(gotBody))
+	assert.Equal(t, http.StatusNotFound, gotUnexpected.StatusCode)
+}
+
+func TestServer_defaultHandler_MatchHost(t *testing.T) {
+	// Setup
+	s := NewServer()
+	defer s.Close()
+	s.On(http.MethodGet, "/foo", nil).MatchHost("api.example.com").RespondOK([]byte(testBody))
+
+	// Test
+	test := mustNewRequest(http.NewRequest(http.MethodGet, s.URLf("/foo"), http.NoBody))
+	test.Host = "api.example.com"
+	got, err := s.Client().Do(test)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer got.Body.Close()
+	gotWrongHost, err := s.Client().Get(s.URLf("/foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gotWrongHost.Body.Close()
+
+	// Assertions
+	assert.Equal(t, http.StatusOK, got.StatusCode)
+	assert.Equal(t, http.StatusNotFound, gotWrongHost.StatusCode)
+}
+
+func TestServer_defaultHandler_MatchRawURL(t *testing.T) {
+	// Setup
+	s := NewServer()
+	defer s.Close()
+	s.On(http.MethodGet, "/search", nil).MatchRawURL("/search?q=a%20b").RespondOK([]byte(testBody))
+	s.On(http.MethodGet, "/search", nil).RespondNoContent()
+
+	// Test
+	got, err := s.Client().Get(s.URL + "/search?q=a%20b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer got.Body.Close()
+	gotOtherEncoding, err := s.Client().Get(s.URL + "/search?q=a+b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gotOtherEncoding.Body.Close()
+
+	// Assertions
+	assert.Equal(t, http.StatusOK, got.StatusCode)
+	assert.Equal(t, http.StatusNoContent, gotOtherEncoding.StatusCode)
+}
+
+func TestServer_MatchContextValue(t *testing.T) {
+	// Setup
+	var s *Server
+	s = NewServerWithConfig(ServerConfig{
+		Handler: func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), testContextKey("tenant"), r.Header.Get("X-Tenant"))
+			makeHandler(s)(w, r.WithContext(ctx))
+		},
+	})
+	defer s.Close()
+	s.On(http.MethodGet, "/foo", nil).MatchContextValue(testContextKey("tenant"), "acme").RespondOK([]byte(testBody))
+
+	test := mustNewRequest(http.NewRequest(http.MethodGet, s.URLf("/foo"), http.NoBody))
+	test.Header.Set("X-Tenant", "acme")
+
+	// Test
+	got, err := s.Client().Do(test)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer got.Body.Close()
+
+	// Assertions
+	assert.Equal(t, http.StatusOK, got.StatusCode)
+	s.Mock.AssertExpectations(t)
+}
+
+func TestServer_Client(t *testing.T) {
+	// Setup
+	ca := mustNewCertificate("test-ca")
+	serverCert := mustNewSignedCertificate("test-server", &ca)
+	otherCert := mustNewSignedCertificate("other-server", &ca)
+
+	s := NewServerWithConfig(ServerConfig{TLSConfig: &tls.Config{Certificates: []tls.Certificate{serverCert}}})
+	defer s.Close()
+	s.On(http.MethodGet, "/foo", nil).RespondOK([]byte(testBody))
+
+	// A different server with a certificate signed by the same CA
+	other := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	other.TLS = &tls.Config{Certificates: []tls.Certificate{otherCert}}
+	other.StartTLS()
+	defer other.Close()
+
+	// Test
+	got, err := s.Client().Get(s.URL + "/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer got.Body.Close()
+	gotOther, err := s.Client().Get(other.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gotOther.Body.Close()
+
+	// Assertions
+	assert.Same(t, s.Client(), s.Client())
+	assert.Equal(t, http.StatusOK, got.StatusCode)
+	assert.Equal(t, http.StatusAccepted, gotOther.StatusCode)
+}
+
+func TestServer_defaultHandler_RespondRedirect(t *testing.T) {
+	// Setup
+	s := NewServer()
+	defer s.Close()
+	s.On(http.MethodGet, "/a", nil).RespondRedirect(http.StatusFound, "/b")
+	s.On(http.MethodGet, "/b", nil).RespondRedirect(http.StatusMovedPermanently, s.URL+"/c")
+	s.On(http.MethodGet, "/c", nil).RespondOK([]byte(testBody))
+
+	client := s.Client()
+	stopping := &http.Client{
+		Transport: client.Transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 2 {
+				return http.ErrUseLastResponse
+			}
+			return nil
+		},
+	}
+
+	// Test
+	got, err := client.Get(s.URL + "/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer got.Body.Close()
+	gotStopped, err := stopping.Get(s.URL + "/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gotStopped.Body.Close()
+
+	// Assertions
+	assert.Equal(t, http.StatusOK, got.StatusCode)
+	assert.Equal(t, "/c", got.Request.URL.Path)
+	assert.Equal(t, http.StatusMovedPermanently, gotStopped.StatusCode)
+	assert.Equal(t, s.URL+"/c", gotStopped.Header.Get("Location"))
+}
+
+func TestServer_DecodeRequestBodies(t *testing.T) {
+	// Setup
+	s := NewServer()
+	defer s.Close()
+	s.On(http.MethodPost, "/users", AnyBody).MatchJSONField("id", 1234).RespondEcho(http.StatusOK)
+
+	encoded := mustEncode("gzip", []byte(`{"id": 1234}`))
+	req := mustNewRequest(http.NewRequest(http.MethodPost, s.URLf("/users"), bytes.NewReader(encoded)))
+	req.Header.Set("Content-Encoding", "gzip")
+
+	// Test
+	got, err := s.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer got.Body.Close()
+	gotBody, err := io.ReadAll(got.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Assertions
+	assert.Equal(t, http.StatusOK, got.StatusCode)
+	assert.Empty(t, got.Header.Get("Content-Encoding"))
+	assert.Equal(t, `{"id": 1234}`, string(gotBody))
+	s.Mock.AssertExpectations(t)
+}
+
+func TestServer_Addr(t *testing.T) {
+	// Setup
+	s := NewServer()
+	defer s.Close()
+	s.On(http.MethodGet, "/foo", nil).RespondOK([]byte(testBody))
+
+	// Test
+	got := s.Addr()
+
+	// Assertions
+	assert.Equal(t, s.URL, "http://"+got)
+	conn, err := net.Dial("tcp", got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	_, err = io.WriteString(conn, "GET /foo HTTP/1.0\r\n\r\n")
+	assert.NoError(t, err)
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestServer_BaseURL(t *testing.T) {
+	for _, useTLS := range []bool{false, true} {
+		t.Run(fmt.Sprintf("tls-%t", useTLS), func(t *testing.T) {
+			// Setup
+			s := NewServerWithConfig(ServerConfig{TLS: useTLS})
+			defer s.Close()
+
+			// Test
+			got := s.BaseURL()
+			got.Path = "/foo"
+
+			// Assertions
+			assert.Equal(t, s.URL, fmt.Sprintf("%s://%s", got.Scheme, got.Host))
+			assert.Empty(t, s.BaseURL().Path)
+		})
+	}
+}
+
+func TestServer_URLf(t *testing.T) {
+	// Setup
+	s := NewServer()
+	defer s.Close()
+	s.On(http.MethodGet, "/users/1234?verbose=true", nil).RespondOK([]byte(testBody))
+
+	tests := []struct {
+		name   string
+		format string
+		args   []any
+		want   string
+	}{
+		{
+			name:   "leading-slash",
+			format: "/users/%d",
+			args:   []any{1234},
+			want:   s.URL + "/users/1234",
+		},
+		{
+			name:   "no-leading-slash",
+			format: "users/%d",
+			args:   []any{1234},
+			want:   s.URL + "/users/1234",
+		},
+		{
+			name:   "query",
+			format: "/users/%d?verbose=%t",
+			args:   []any{1234, true},
+			want:   s.URL + "/users/1234?verbose=true",
+		},
+		{
+			name:   "empty",
+			format: "",
+			want:   s.URL + "/",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Test
+			got := s.URLf(tt.format, tt.args...)
+
+			// Assertions
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	got, err := s.Client().Get(s.URLf("/users/%d?verbose=true", 1234))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer got.Body.Close()
+	assert.Equal(t, http.StatusOK, got.StatusCode)
+}
+
+func TestServer_CertPool(t *testing.T) {
+	// Setup
+	s := NewServer()
+	defer s.Close()
+	tlsServer := NewServerWithConfig(ServerConfig{TLS: true})
+	defer tlsServer.Close()
+
+	// Test
+	got := s.CertPool()
+	gotTLS := tlsServer.CertPool()
+
+	// Assertions
+	assert.Nil(t, got)
+	if assert.NotNil(t, gotTLS) {
+		_, err := tlsServer.Certificate().Verify(x509.VerifyOptions{Roots: gotTLS})
+		assert.NoError(t, err)
 	}
-	// Equivalent to s != TLSServer
-	assert.Nil(t, s.Server.TLS)
-	// Equivalent to the server having been Start()'ed already
-	assert.NotEmpty(t, s.Server.URL)
 }
 
-func Test_NewServerWithConfig_TLS(t *testing.T) {
+func TestServer_Transport(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  ServerConfig
+		url  string
+	}{
+		{
+			name: "http",
+			url:  "http://api.example.com/foo",
+		},
+		{
+			name: "tls",
+			cfg:  ServerConfig{TLS: true},
+			url:  "https://api.example.com/foo",
+		},
+		{
+			name: "http2",
+			cfg:  ServerConfig{TLS: true, HTTP2: true},
+			url:  "https://api.example.com/foo",
+		},
+		{
+			name: "unix-socket",
+			cfg:  ServerConfig{UnixSocket: filepath.Join(t.TempDir(), "httpmock.sock")},
+			url:  "http://api.example.com/foo",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			s := NewServerWithConfig(tt.cfg)
+			defer s.Close()
+			s.On(http.MethodGet, "/foo", nil).MatchHost("api.example.com").RespondOK([]byte(testBody))
+			client := &http.Client{Transport: s.Transport()}
+
+			// Test
+			got, err := client.Get(tt.url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer got.Body.Close()
+			gotBody, err := io.ReadAll(got.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Assertions
+			assert.Equal(t, http.StatusOK, got.StatusCode)
+			assert.Equal(t, testBody, string(gotBody))
+			assert.Equal(t, tt.cfg.HTTP2, got.ProtoMajor == 2)
+			s.Mock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServer_Transport_UntrustedCertificate(t *testing.T) {
 	// Setup
-	cfg := ServerConfig{TLS: true}
+	s := NewServerWithConfig(ServerConfig{TLS: true})
+	defer s.Close()
+	other := NewServerWithConfig(ServerConfig{TLSConfig: &tls.Config{Certificates: []tls.Certificate{mustNewCertificate("example.com")}}})
+	defer other.Close()
+	transport := s.Transport().(*http.Transport)
+	transport.DialContext = other.Transport().(*http.Transport).DialContext
+	client := &http.Client{Transport: transport}
 
 	// Test
-	s := NewServerWithConfig(cfg)
+	_, err := client.Get("https://api.example.com/foo")
 
 	// Assertions
-	assert.NotNil(t, s.Mock)
-	if s.Server == nil {
-		t.Fatalf("unexpected nil Server")
-	}
-	// Equivalent to s == TLSServer
-	assert.NotNil(t, s.Server.TLS)
-	// Equivalent to the server having been Start()'ed already
-	assert.NotEmpty(t, s.Server.URL)
+	assert.ErrorContains(t, err, "certificate signed by unknown authority")
 }
 
 func Test_NewServerWithConfig_CustomHandler(t *testing.T) {
@@ -104,6 +1246,69 @@ func TestServer_defaultHandler_NoMatch(t *testing.T) {
 	s.Mock.AssertNotRequested(t, http.MethodDelete, fmt.Sprintf("%s/foo/1234", s.URL), nil)
 }
 
+func TestServer_defaultHandler_UnmatchedStatus(t *testing.T) {
+	// Setup
+	s := NewServer().UnmatchedStatus(http.StatusNotImplemented)
+	defer s.Close()
+	s.On(http.MethodGet, "/foo/1234", nil).RespondOK([]byte(testBody))
+
+	// Test
+	test := mustNewRequest(http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/foo/1234", s.URL), http.NoBody))
+	got, err := s.Client().Do(test)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer got.Body.Close()
+
+	// Assertions
+	assert.Equal(t, http.StatusNotImplemented, got.StatusCode)
+}
+
+func TestServer_UnmatchedStatus_BadStatusCode(t *testing.T) {
+	// Setup
+	mockT := new(MockTestingT)
+	s := &Server{Mock: new(Mock).Test(mockT)}
+
+	defer func() {
+		rc := recover()
+		if rc == nil {
+			t.Fatal("Did not expect to get here")
+		}
+		// Assertions
+		assert.Equal(t, "FailNow was called", rc.(string))
+		assert.Equal(t, 1, mockT.failNowCount)
+		assert.Zero(t, s.unmatchedStatus)
+	}()
+
+	// Test
+	s.UnmatchedStatus(1000)
+}
+
+func TestServer_defaultHandler_RespondDefault(t *testing.T) {
+	// Setup
+	s := NewServer()
+	defer s.Close()
+	s.On(http.MethodGet, "/foo/1234", nil).RespondOK([]byte(testBody))
+	s.Mock.RespondDefault(http.StatusNotImplemented, []byte(`not implemented`))
+
+	// Test
+	test := mustNewRequest(http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/foo/1234", s.URL), http.NoBody))
+	got, err := s.Client().Do(test)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotBody, err := io.ReadAll(got.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer got.Body.Close()
+
+	// Assertions
+	assert.Equal(t, http.StatusNotImplemented, got.StatusCode)
+	assert.Equal(t, "not implemented", string(gotBody))
+	s.Mock.AssertNotRequested(t, http.MethodDelete, "/foo/1234", nil)
+}
+
 func TestServer_defaultHandler_AssertRequested(t *testing.T) {
 	// Setup
 	s := NewServer()
@@ -286,6 +1491,326 @@ func TestServer_defaultHandler_AssertNumberOfRequests(t *testing.T) {
 	s.Mock.AssertNumberOfRequests(t, http.MethodDelete, "/foo/1234", 1)
 }
 
+func TestServer_defaultHandler_Delay(t *testing.T) {
+	// Setup
+	s := NewServer()
+	defer s.Close()
+	s.On(http.MethodGet, "/foo/1234", nil).RespondOK([]byte(testBody)).Delay(time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// Test
+	test := mustNewRequest(http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/foo/1234", s.URL), http.NoBody))
+	got, err := s.Client().Do(test)
+
+	// Assertions
+	assert.Nil(t, got)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	s.Mock.AssertNumberOfRequests(t, http.MethodGet, "/foo/1234", 1)
+}
+
+func TestServer_defaultHandler_RespondGate(t *testing.T) {
+	// Setup
+	s := NewServer()
+	defer s.Close()
+	_, release := s.On(http.MethodGet, "/foo", nil).RespondGate(http.StatusOK, []byte(testBody))
+
+	type result struct {
+		resp *http.Response
+		err  error
+	}
+	results := make(chan result, 1)
+
+	// Test
+	go func() {
+		resp, err := s.Client().Get(s.URLf("/foo"))
+		results <- result{resp, err}
+	}()
+	if !s.Mock.WaitForCalls(1, time.Second) {
+		t.Fatal("timed out waiting for request")
+	}
+	select {
+	case <-results:
+		t.Fatal("response was written before the gate was released")
+	case <-time.After(20 * time.Millisecond):
+	}
+	release <- struct{}{}
+	got := <-results
+
+	// Assertions
+	if got.err != nil {
+		t.Fatal(got.err)
+	}
+	defer got.resp.Body.Close()
+	gotBody, err := io.ReadAll(got.resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, http.StatusOK, got.resp.StatusCode)
+	assert.Equal(t, testBody, string(gotBody))
+}
+
+func TestServer_ForceHTTP10(t *testing.T) {
+	// Setup
+	s := NewServer().ForceHTTP10()
+	defer s.Close()
+	s.On(http.MethodGet, "/foo", nil).RespondOK([]byte(testBody)).Header("X-Foo", "bar").Trailer("X-Trailer", "baz")
+	s.On(http.MethodGet, "/stream", nil).RespondStream(http.StatusOK, [][]byte{[]byte("foo"), []byte("bar")}, 0)
+	s.On(http.MethodGet, "/reset", nil).RespondReset()
+
+	get := func(path string) (*http.Response, []byte) {
+		t.Helper()
+
+		resp, err := s.Client().Get(s.URLf(path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp, body
+	}
+
+	// Test
+	got, gotBody := get("/foo")
+	gotAgain, _ := get("/foo")
+	gotStream, gotStreamBody := get("/stream")
+	gotUnmatched, _ := get("/bar")
+	_, gotResetErr := s.Client().Get(s.URLf("/reset"))
+
+	// Assertions
+	assert.Equal(t, "HTTP/1.0", got.Proto)
+	assert.True(t, got.Close)
+	assert.Equal(t, http.StatusOK, got.StatusCode)
+	assert.Equal(t, "bar", got.Header.Get("X-Foo"))
+	assert.Empty(t, got.Header.Get("Trailer"))
+	assert.Empty(t, got.Header.Get("X-Trailer"))
+	assert.Empty(t, got.Trailer)
+	assert.Equal(t, int64(len(testBody)), got.ContentLength)
+	assert.Equal(t, testBody, string(gotBody))
+	assert.Equal(t, http.StatusOK, gotAgain.StatusCode)
+
+	assert.Equal(t, "HTTP/1.0", gotStream.Proto)
+	assert.Equal(t, int64(-1), gotStream.ContentLength)
+	assert.Equal(t, "foobar", string(gotStreamBody))
+
+	assert.Equal(t, "HTTP/1.0", gotUnmatched.Proto)
+	assert.Equal(t, http.StatusNotFound, gotUnmatched.StatusCode)
+
+	assert.Error(t, gotResetErr)
+}
+
+func TestServer_ForceHTTP10_HTTP2(t *testing.T) {
+	// Setup
+	s := NewServerWithConfig(ServerConfig{TLS: true, HTTP2: true}).ForceHTTP10()
+	defer s.Close()
+	s.On(http.MethodGet, "/foo", nil).RespondOK([]byte(testBody))
+
+	// Test
+	got, err := s.Client().Get(s.URLf("/foo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer got.Body.Close()
+	gotBody, err := io.ReadAll(got.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Assertions
+	assert.Equal(t, 2, got.ProtoMajor)
+	assert.Equal(t, http.StatusOK, got.StatusCode)
+	assert.Equal(t, testBody, string(gotBody))
+}
+
+func TestServer_defaultHandler_Trailer(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  ServerConfig
+	}{
+		{
+			name: "http1",
+		},
+		{
+			name: "http2",
+			cfg:  ServerConfig{TLS: true, HTTP2: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			s := NewServerWithConfig(tt.cfg)
+			defer s.Close()
+			s.On(http.MethodPost, "/rpc", AnyBody).RespondOK([]byte(testBody)).Trailer("Grpc-Status", "0")
+			s.On(http.MethodPost, "/stream", AnyBody).
+				RespondStream(http.StatusOK, [][]byte{[]byte("foo"), []byte("bar")}, 0).
+				Trailer("Grpc-Status", "0")
+
+			for _, path := range []string{"/rpc", "/stream"} {
+				// Test
+				got, err := s.Client().Post(s.URLf(path), "application/grpc", http.NoBody)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer got.Body.Close()
+				_, err = io.ReadAll(got.Body)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				// Assertions
+				assert.Equal(t, http.StatusOK, got.StatusCode)
+				assert.Equal(t, "0", got.Trailer.Get("Grpc-Status"))
+				assert.Empty(t, got.Header.Get("Grpc-Status"))
+			}
+		})
+	}
+}
+
+func TestServer_defaultHandler_Times_Concurrent(t *testing.T) {
+	// Setup
+	s := NewServer()
+	defer s.Close()
+	s.On(http.MethodPost, "/token", nil).RespondOK([]byte(testBody)).Times(5)
+	s.On(http.MethodPost, "/token", nil).Respond(http.StatusUnauthorized, nil)
+
+	// Test
+	var wg sync.WaitGroup
+	statusCodes := make(chan int, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			test := mustNewRequest(http.NewRequest(http.MethodPost, fmt.Sprintf("%s/token", s.URL), http.NoBody))
+			got, err := s.Client().Do(test)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			got.Body.Close()
+			statusCodes <- got.StatusCode
+		}()
+	}
+	wg.Wait()
+	close(statusCodes)
+
+	// Assertions
+	counts := map[int]int{}
+	for code := range statusCodes {
+		counts[code]++
+	}
+	assert.Equal(t, map[int]int{http.StatusOK: 5, http.StatusUnauthorized: 5}, counts)
+	s.Mock.AssertExpectations(t)
+	s.Mock.AssertNumberOfRequests(t, http.MethodPost, "/token", 10)
+}
+
+func TestServer_Mock_Reset(t *testing.T) {
+	// Setup
+	s := NewServer()
+	defer s.Close()
+
+	tests := []struct {
+		name       string
+		statusCode int
+	}{
+		{name: "ok", statusCode: http.StatusOK},
+		{name: "not-found", statusCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			s.Mock.Reset()
+			s.On(http.MethodGet, "/foo/1234", nil).Respond(tt.statusCode, nil).Once()
+
+			// Test
+			test := mustNewRequest(http.NewRequest(http.MethodGet, fmt.Sprintf("%s/foo/1234", s.URL), http.NoBody))
+			got, err := s.Client().Do(test)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got.Body.Close()
+
+			// Assertions
+			assert.Equal(t, tt.statusCode, got.StatusCode)
+			s.Mock.AssertExpectations(t)
+			s.Mock.AssertNumberOfRequests(t, http.MethodGet, "/foo/1234", 1)
+		})
+	}
+}
+
+func TestServer_defaultHandler_RespondStream(t *testing.T) {
+	// Setup
+	s := NewServer()
+	defer s.Close()
+	s.On(http.MethodGet, "/events", nil).
+		RespondStream(http.StatusOK, [][]byte{[]byte("data: 1\n"), []byte("data: 2\n")}, 100*time.Millisecond).
+		Header("Content-Type", "text/event-stream")
+
+	// Test
+	test := mustNewRequest(http.NewRequest(http.MethodGet, fmt.Sprintf("%s/events", s.URL), http.NoBody))
+	start := time.Now()
+	got, err := s.Client().Do(test)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer got.Body.Close()
+
+	// Assertions
+	assert.Equal(t, http.StatusOK, got.StatusCode)
+	assert.Equal(t, "text/event-stream", got.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(got.Body)
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "data: 1\n", line)
+	// The first chunk should arrive before the second chunk is written
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+
+	line, err = reader.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "data: 2\n", line)
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+}
+
+func TestServer_defaultHandler_RespondNDJSON(t *testing.T) {
+	// Setup
+	s := NewServer()
+	defer s.Close()
+	s.On(http.MethodGet, "/events", nil).RespondNDJSON(http.StatusOK, []any{map[string]int{"id": 1}, map[string]int{"id": 2}}, 100*time.Millisecond)
+
+	// Test
+	got, err := s.Client().Get(s.URLf("/events"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer got.Body.Close()
+
+	// Assertions
+	assert.Equal(t, http.StatusOK, got.StatusCode)
+	assert.Equal(t, "application/x-ndjson", got.Header.Get("Content-Type"))
+
+	dec := json.NewDecoder(got.Body)
+	var gotIDs []int
+	for dec.More() {
+		var item struct{ ID int }
+		if err := dec.Decode(&item); err != nil {
+			t.Fatal(err)
+		}
+		gotIDs = append(gotIDs, item.ID)
+	}
+	assert.Equal(t, []int{1, 2}, gotIDs)
+}
+
 // TestSomething is the example given in the documentation.
 //
 // Let's keep it as a real test to ensure it actually works!
@@ -344,3 +1869,91 @@ func TestSomething(t *testing.T) {
 	ts.Mock.AssertExpectations(t)
 	ts.Mock.AssertNumberOfRequests(t, http.MethodPatch, "/foo/1234", 1)
 }
+
+func TestServer_RespondLastModified(t *testing.T) {
+	// Setup
+	modified := time.Date(2024, time.May, 1, 12, 30, 15, 0, time.UTC)
+	s := NewServer()
+	defer s.Close()
+	s.On(http.MethodGet, "/report", nil).RespondLastModified(modified, []byte(testBody))
+
+	req := mustNewRequest(http.NewRequest(http.MethodGet, s.URLf("/report"), http.NoBody))
+	req.Header.Set("If-Modified-Since", modified.Format(http.TimeFormat))
+
+	// Test
+	got, err := s.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer got.Body.Close()
+	gotBody, err := io.ReadAll(got.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Assertions
+	assert.Equal(t, http.StatusNotModified, got.StatusCode)
+	assert.Empty(t, gotBody)
+	assert.Equal(t, modified.Format(http.TimeFormat), got.Header.Get("Last-Modified"))
+	assert.Empty(t, got.Header.Get("Content-Type"))
+}
+
+func TestServer_SetFailHandler(t *testing.T) {
+	// Setup
+	var mutex sync.Mutex
+	var gotFailures []string
+
+	s := NewServer()
+	defer s.Close()
+	s.Mock.SetFailHandler(func(format string, args ...any) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		gotFailures = append(gotFailures, fmt.Sprintf(format, args...))
+	})
+
+	// Test
+	got, err := s.Client().Get(s.URLf("/unexpected"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer got.Body.Close()
+
+	// Assertions
+	assert.Equal(t, http.StatusNotFound, got.StatusCode)
+	mutex.Lock()
+	defer mutex.Unlock()
+	if assert.Len(t, gotFailures, 1) {
+		assert.Contains(t, gotFailures[0], "I don't know what to return because the request was unexpected")
+	}
+}
+
+func TestServer_MaxConcurrentCalls(t *testing.T) {
+	// Setup
+	s := NewServer()
+	defer s.Close()
+	_, release := s.On(http.MethodGet, "/slow", nil).RespondGate(http.StatusOK, nil)
+
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := s.Client().Get(s.URLf("/slow"))
+			if err == nil {
+				resp.Body.Close()
+			}
+		}()
+	}
+
+	// Test
+	gotWaited := s.Mock.WaitForCalls(3, time.Second)
+	close(release)
+	wg.Wait()
+	got := s.Mock.MaxConcurrentCalls()
+	s.Mock.Reset()
+
+	// Assertions
+	assert.True(t, gotWaited)
+	assert.Equal(t, 3, got)
+	assert.Zero(t, s.Mock.MaxConcurrentCalls())
+}
@@ -0,0 +1,80 @@
+package httpmock
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"text/template"
+)
+
+// TemplateData is the data available to a response template set with
+// [Request.RespondTemplate]. It describes the received [http.Request].
+type TemplateData struct {
+	// The received request.
+	Request *http.Request
+
+	// The HTTP method that was requested.
+	Method string
+
+	// The URL that was requested.
+	URL *url.URL
+
+	// The query parameters that were requested.
+	Query url.Values
+
+	// The headers that were requested.
+	Header http.Header
+
+	// The body that was requested.
+	Body string
+}
+
+// PathValue returns the value for the named path wildcard of the received
+// request, as with [http.Request.PathValue]. Path values are only available
+// when the request was routed by a [http.ServeMux] pattern, such as when the
+// [Mock] is used inside a custom handler; otherwise, it returns an empty
+// string.
+func (d TemplateData) PathValue(name string) string {
+	if d.Request == nil {
+		return ""
+	}
+	return d.Request.PathValue(name)
+}
+
+// newTemplateData creates a [TemplateData] for a received [http.Request].
+func newTemplateData(req *http.Request) (TemplateData, error) {
+	if req == nil {
+		return TemplateData{}, nil
+	}
+
+	body, err := SafeReadBody(req)
+	if err != nil {
+		return TemplateData{}, err
+	}
+
+	return TemplateData{
+		Request: req,
+		Method:  req.Method,
+		URL:     req.URL,
+		Query:   req.URL.Query(),
+		Header:  req.Header,
+		Body:    string(body),
+	}, nil
+}
+
+// renderTemplate executes a response template against a received
+// [http.Request].
+func renderTemplate(tmpl *template.Template, req *http.Request) ([]byte, error) {
+	data, err := newTemplateData(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRenderTemplate, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRenderTemplate, err)
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,106 @@
+package httpmock
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_newTemplateData_NilRequest(t *testing.T) {
+	// Test
+	got, err := newTemplateData(nil)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Equal(t, TemplateData{}, got)
+	assert.Empty(t, got.PathValue("id"))
+}
+
+func Test_newTemplateData(t *testing.T) {
+	// Setup
+	req := mustNewRequest(http.NewRequest(http.MethodPost, "/users/1234?verbose=true", strings.NewReader(testBody)))
+	req.Header.Set("X-Request-Id", "5678")
+
+	// Test
+	got, err := newTemplateData(req)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Equal(t, req, got.Request)
+	assert.Equal(t, http.MethodPost, got.Method)
+	assert.Equal(t, "/users/1234?verbose=true", got.URL.String())
+	assert.Equal(t, "true", got.Query.Get("verbose"))
+	assert.Equal(t, "5678", got.Header.Get("X-Request-Id"))
+	assert.Equal(t, testBody, got.Body)
+
+	// Body should still be readable
+	gotBody, err := io.ReadAll(req.Body)
+	if err != nil {
+		t.Fatalf("unexpected error reading body: %v", err)
+	}
+	assert.Equal(t, testBody, string(gotBody))
+}
+
+func TestTemplateData_PathValue(t *testing.T) {
+	// Setup
+	var got TemplateData
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		if got, err = newTemplateData(r); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	// Test
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1234", http.NoBody))
+
+	// Assertions
+	assert.Equal(t, "1234", got.PathValue("id"))
+	assert.Empty(t, got.PathValue("missing"))
+}
+
+func Test_renderTemplate_FailToReadBody(t *testing.T) {
+	// Setup
+	tmpl := template.Must(template.New("test").Parse(`{{.Body}}`))
+	req := mustNewRequest(http.NewRequest(http.MethodPost, "/foo", &badReader{}))
+
+	// Test
+	got, err := renderTemplate(tmpl, req)
+
+	// Assertions
+	assert.Nil(t, got)
+	assert.ErrorIs(t, err, ErrRenderTemplate)
+}
+
+func Test_renderTemplate_FailToExecute(t *testing.T) {
+	// Setup
+	tmpl := template.Must(template.New("test").Parse(`{{.Missing}}`))
+	req := mustNewRequest(http.NewRequest(http.MethodGet, "/foo", http.NoBody))
+
+	// Test
+	got, err := renderTemplate(tmpl, req)
+
+	// Assertions
+	assert.Nil(t, got)
+	assert.ErrorIs(t, err, ErrRenderTemplate)
+}
+
+func Test_renderTemplate(t *testing.T) {
+	// Setup
+	tmpl := template.Must(template.New("test").Parse(`{{.Method}} {{.URL.Path}} {{.Query.Get "limit"}} {{.Header.Get "X-Request-Id"}} {{.Body}}`))
+	req := mustNewRequest(http.NewRequest(http.MethodPut, "/foo?limit=10", strings.NewReader(testBody)))
+	req.Header.Set("X-Request-Id", "5678")
+
+	// Test
+	got, err := renderTemplate(tmpl, req)
+
+	// Assertions
+	assert.NoError(t, err)
+	assert.Equal(t, "PUT /foo 10 5678 Hello World!", string(got))
+}
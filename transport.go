@@ -0,0 +1,170 @@
+package httpmock
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"syscall"
+)
+
+// roundTripper implements [http.RoundTripper] by passing requests directly to
+// a [Mock], without sending them over the network.
+type roundTripper struct {
+	mock *Mock
+}
+
+// RoundTrip passes a [http.Request] to the [Mock] and converts the matched
+// [Response] into a [http.Response].
+//
+// The request is presented to the [Mock] as a server would receive it, so that
+// matching behaves the same as with the [Server] handler. That is, the URL only
+// contains the path and query, and the host is available in
+// [http.Request.Host]. Like the [Server] handler, panics, such as when no
+// expected [Request] matches and no test is set with [Mock.Test], are recovered
+// and returned as an error, unless [Mock.NotRecoverable] was called.
+func (rt *roundTripper) RoundTrip(req *http.Request) (resp *http.Response, err error) {
+	defer rt.mock.trackCall()()
+
+	defer func() {
+		if rc := recover(); rc != nil {
+			if !rt.mock.IsRecoverable() {
+				panic(rc)
+			}
+			resp, err = nil, fmt.Errorf("failed to handle request %s %s: %v", req.Method, req.URL, rc)
+		}
+	}()
+
+	received := req.Clone(req.Context())
+	if received.Body == nil {
+		received.Body = http.NoBody
+	}
+	if received.Host == "" {
+		received.Host = req.URL.Host
+	}
+
+	u := *req.URL
+	u.Scheme = ""
+	u.Opaque = ""
+	u.User = nil
+	u.Host = ""
+	u.Fragment = ""
+	u.RawFragment = ""
+	received.URL = &u
+	received.RequestURI = u.RequestURI()
+
+	response, forward := rt.mock.requested(received, true)
+	if forward {
+		forwarded := req.Clone(req.Context())
+		if req.Body != nil {
+			forwarded.Body = received.Body
+		}
+		return http.DefaultTransport.RoundTrip(forwarded)
+	}
+
+	recorder := &roundTripRecorder{ResponseRecorder: httptest.NewRecorder()}
+	if _, err := response.Write(recorder, received); err != nil {
+		return nil, fmt.Errorf("failed to write response for request %s %s: %w", req.Method, req.URL, err)
+	}
+	if recorder.wasReset {
+		return nil, fmt.Errorf("failed to read response for request %s %s: %w", req.Method, req.URL, syscall.ECONNRESET)
+	}
+
+	resp = recorder.Result()
+	resp.Request = req
+	// Responses to HEAD requests, and those with a 1xx, 204 or 304 status, have
+	// no body regardless of their Content-Length
+	hasBody := req.Method != http.MethodHead && resp.StatusCode >= 200 &&
+		resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotModified
+	if written := int64(recorder.Body.Len()); hasBody && resp.ContentLength > written {
+		// Like a closed connection, fail reads after the written body
+		resp.Body = &truncatedBody{ReadCloser: resp.Body}
+	}
+
+	return resp, nil
+}
+
+// roundTripRecorder records the response for a [roundTripper]. It cannot be
+// hijacked, so [Request.RespondReset] resets it instead, which fails the round
+// trip.
+type roundTripRecorder struct {
+	*httptest.ResponseRecorder
+
+	// Whether the connection was reset instead of written
+	wasReset bool
+}
+
+func (rec *roundTripRecorder) reset() {
+	rec.wasReset = true
+}
+
+// truncatedBody is the body of a response that declared a longer
+// Content-Length than was written, such as with [Request.RespondTruncated]. It
+// returns [io.ErrUnexpectedEOF] instead of [io.EOF] once the written body has
+// been read.
+type truncatedBody struct {
+	io.ReadCloser
+}
+
+func (b *truncatedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+// passthroughHostListed reports whether the host of a received [http.Request]
+// was given to [Mock.PassthroughHosts], either with or without its port. The
+// mutex of the [Mock] must be held.
+func (m *Mock) passthroughHostListed(received *http.Request) bool {
+	hostname := received.Host
+	if h, _, err := net.SplitHostPort(received.Host); err == nil {
+		hostname = h
+	}
+	return slices.ContainsFunc(m.passthroughHosts, func(host string) bool {
+		return strings.EqualFold(host, received.Host) || strings.EqualFold(host, hostname)
+	})
+}
+
+// PassthroughHosts configures [Mock.RoundTripper] to forward requests for the
+// given hosts to the network with [http.DefaultTransport] when they do not
+// match any expected [Request], while requests for other hosts are mocked as
+// usual. A host may include a port, in which case only requests to that port
+// are forwarded. An expected [Request] that matches always takes precedence.
+//
+// Forwarded responses are returned unchanged, so redirects are followed by the
+// [http.Client] as usual. Like [Mock.Passthrough], forwarded requests do not
+// count towards [Mock.AssertExpectations] or the other request assertions. This
+// has no effect on a [Server]; use [Mock.Passthrough] instead.
+//
+//	Mock.PassthroughHosts("auth.example.com", "localhost:8080")
+func (m *Mock) PassthroughHosts(hosts ...string) *Mock {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.passthroughHosts = append([]string{}, hosts...)
+	return m
+}
+
+// RoundTripper returns a [http.RoundTripper] that passes requests to the
+// [Mock] instead of sending them over the network. Use it to inject the [Mock]
+// into code that accepts a [http.Client] or [http.RoundTripper], without
+// starting a [Server].
+//
+//	client := &http.Client{Transport: Mock.RoundTripper()}
+func (m *Mock) RoundTripper() http.RoundTripper {
+	return &roundTripper{mock: m}
+}
+
+// Client returns a [http.Client] that uses [Mock.RoundTripper] as its
+// transport.
+//
+//	resp, err := Mock.Client().Get("https://test.com/some/path")
+func (m *Mock) Client() *http.Client {
+	return &http.Client{Transport: m.RoundTripper()}
+}
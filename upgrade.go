@@ -0,0 +1,138 @@
+package httpmock
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+var ErrUpgrade = errors.New("error upgrading connection")
+
+// websocketGUID is appended to the Sec-WebSocket-Key of a WebSocket handshake
+// to compute the Sec-WebSocket-Accept, as defined by RFC 6455.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// headerHasToken reports whether a comma-separated header, such as Connection
+// or Upgrade, contains the given token, ignoring case.
+func headerHasToken(h http.Header, key string, token string) bool {
+	for _, value := range h.Values(key) {
+		for _, t := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// upgradeMatcher creates a [RequestMatcher] that expects a received
+// [http.Request] to ask to upgrade the connection to the given protocol.
+func upgradeMatcher(protocol string) RequestMatcher {
+	fn := func(received *http.Request) (output string, differences int) {
+		if !headerHasToken(received.Header, "Connection", "Upgrade") || received.Header.Get("Upgrade") == "" {
+			output = fmt.Sprintf("FAIL:  upgrade: %s != %q", fmtMissing, protocol)
+			differences = 1
+			return
+		}
+		actual := strings.Join(received.Header.Values("Upgrade"), ", ")
+		if !headerHasToken(received.Header, "Upgrade", protocol) {
+			output = fmt.Sprintf("FAIL:  upgrade: %q != %q", actual, protocol)
+			differences = 1
+			return
+		}
+		output = fmt.Sprintf("PASS:  upgrade: %q == %q", actual, protocol)
+		return
+	}
+
+	return fn
+}
+
+// MatchUpgrade adds a [RequestMatcher] to the [Request] which expects a
+// received [http.Request] to ask to upgrade the connection to the given
+// protocol, such as "websocket". The request must have a Connection header
+// with the "Upgrade" token, and an Upgrade header that lists the protocol. Both
+// are compared without regard to case. Requests that are not upgrades are
+// matched as usual by [Request]'s that do not call MatchUpgrade.
+//
+//	Mock.On(http.MethodGet, "/ws", nil).MatchUpgrade("websocket").RespondUpgrade(func(conn net.Conn) {
+//		_, _ = conn.Write([]byte{0x81, 0x02, 'h', 'i'})
+//	})
+func (r *Request) MatchUpgrade(protocol string) *Request {
+	return r.Matches(upgradeMatcher(protocol))
+}
+
+// RespondUpgrade configures the [Request] to complete the handshake for an
+// upgrade request, and then to hand the connection to fn, which can act as a
+// minimal fake of the upgraded protocol, such as a WebSocket server. A 101
+// Switching Protocols response is written with the Upgrade header of the
+// received request. If the received request has a Sec-WebSocket-Key header, the
+// matching Sec-WebSocket-Accept header is added. The connection is closed once
+// fn returns. Any data the client sent after the request headers can be read
+// from the connection.
+//
+// The connection is taken over with [http.Hijacker], so RespondUpgrade needs
+// an HTTP/1.x [Server]. If the connection cannot be hijacked, such as with
+// HTTP/2 or [Mock.RoundTripper], fn is not called and writing the response
+// fails with [ErrUpgrade].
+//
+//	Mock.On(http.MethodGet, "/ws", nil).MatchUpgrade("websocket").RespondUpgrade(func(conn net.Conn) {
+//		_, _ = conn.Write([]byte{0x81, 0x02, 'h', 'i'})
+//	})
+func (r *Request) RespondUpgrade(fn func(net.Conn)) *Response {
+	return r.RespondUsing(upgradeWriter(fn))
+}
+
+// upgradeWriter creates a [ResponseWriter] that hijacks the connection, writes
+// a 101 Switching Protocols response, and passes the connection to fn.
+func upgradeWriter(fn func(net.Conn)) ResponseWriter {
+	writer := func(w http.ResponseWriter, r *http.Request) (int, error) {
+		conn, buf, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return 0, fmt.Errorf("%w: %v", ErrUpgrade, err)
+		}
+		defer conn.Close()
+
+		h := http.Header{}
+		h.Set("Connection", "Upgrade")
+		h.Set("Upgrade", strings.Join(r.Header.Values("Upgrade"), ", "))
+		if key := r.Header.Get("Sec-WebSocket-Key"); key != "" {
+			h.Set("Sec-WebSocket-Accept", websocketAccept(key))
+		}
+
+		fmt.Fprintf(buf, "HTTP/1.1 %d %s\r\n", http.StatusSwitchingProtocols, http.StatusText(http.StatusSwitchingProtocols))
+		_ = h.Write(buf)
+		_, _ = buf.WriteString("\r\n")
+		if err := buf.Flush(); err != nil {
+			return 0, fmt.Errorf("%w: %v", ErrUpgrade, err)
+		}
+
+		fn(&upgradedConn{Conn: conn, reader: buf.Reader})
+		return 0, nil
+	}
+
+	return writer
+}
+
+// websocketAccept computes the Sec-WebSocket-Accept header for the given
+// Sec-WebSocket-Key header.
+func websocketAccept(key string) string {
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// upgradedConn is a hijacked connection whose reads first return any data that
+// the server had already buffered.
+type upgradedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *upgradedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
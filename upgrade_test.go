@@ -0,0 +1,142 @@
+package httpmock
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_upgradeMatcher(t *testing.T) {
+	tests := []struct {
+		name            string
+		header          http.Header
+		wantOutput      string
+		wantDifferences int
+	}{
+		{
+			name:            "match",
+			header:          http.Header{"Connection": []string{"Upgrade"}, "Upgrade": []string{"websocket"}},
+			wantOutput:      `PASS:  upgrade: "websocket" == "websocket"`,
+			wantDifferences: 0,
+		},
+		{
+			name:            "match-tokens",
+			header:          http.Header{"Connection": []string{"keep-alive, upgrade"}, "Upgrade": []string{"foo/1, WebSocket"}},
+			wantOutput:      `PASS:  upgrade: "foo/1, WebSocket" == "websocket"`,
+			wantDifferences: 0,
+		},
+		{
+			name:            "missing",
+			header:          http.Header{},
+			wantOutput:      `FAIL:  upgrade: (Missing) != "websocket"`,
+			wantDifferences: 1,
+		},
+		{
+			name:            "missing-connection",
+			header:          http.Header{"Upgrade": []string{"websocket"}},
+			wantOutput:      `FAIL:  upgrade: (Missing) != "websocket"`,
+			wantDifferences: 1,
+		},
+		{
+			name:            "mismatch",
+			header:          http.Header{"Connection": []string{"Upgrade"}, "Upgrade": []string{"h2c"}},
+			wantOutput:      `FAIL:  upgrade: "h2c" != "websocket"`,
+			wantDifferences: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			received := &http.Request{Header: tt.header}
+
+			// Test
+			gotOutput, gotDifferences := upgradeMatcher("websocket")(received)
+
+			// Assertions
+			assert.Equal(t, tt.wantOutput, gotOutput)
+			assert.Equal(t, tt.wantDifferences, gotDifferences)
+		})
+	}
+}
+
+func Test_websocketAccept(t *testing.T) {
+	// Test
+	got := websocketAccept("dGhlIHNhbXBsZSBub25jZQ==")
+
+	// Assertions
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", got)
+}
+
+func Test_upgradeWriter_NotHijacker(t *testing.T) {
+	// Setup
+	var called bool
+	recorder := httptest.NewRecorder()
+
+	// Test
+	gotN, gotErr := upgradeWriter(func(net.Conn) { called = true })(recorder, httptest.NewRequest(http.MethodGet, "/ws", http.NoBody))
+
+	// Assertions
+	assert.ErrorIs(t, gotErr, ErrUpgrade)
+	assert.Zero(t, gotN)
+	assert.False(t, called)
+	assert.Equal(t, http.StatusInternalServerError, recorder.Code)
+}
+
+func TestServer_RespondUpgrade(t *testing.T) {
+	// Setup
+	s := NewServer()
+	defer s.Close()
+	s.On(http.MethodGet, "/ws", nil).MatchUpgrade("websocket").RespondUpgrade(func(conn net.Conn) {
+		// Echo a line, including one sent along with the handshake
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		if err != nil {
+			return
+		}
+		_, _ = io.WriteString(conn, "echo: "+line)
+	})
+	s.On(http.MethodGet, "/ws", nil).RespondOK([]byte(testBody))
+
+	conn, err := net.Dial("tcp", s.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// Test
+	_, err = io.WriteString(conn, "GET /ws HTTP/1.1\r\nHost: example.com\r\nConnection: Upgrade\r\nUpgrade: websocket\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\nhello\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reader := bufio.NewReader(conn)
+	got, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gotLine, gotErr := reader.ReadString('\n')
+
+	plain, err := s.Client().Get(s.URLf("/ws"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer plain.Body.Close()
+	gotPlainBody, err := io.ReadAll(plain.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Assertions
+	assert.Equal(t, http.StatusSwitchingProtocols, got.StatusCode)
+	assert.Equal(t, "websocket", got.Header.Get("Upgrade"))
+	assert.Equal(t, "Upgrade", got.Header.Get("Connection"))
+	assert.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", got.Header.Get("Sec-WebSocket-Accept"))
+	assert.NoError(t, gotErr)
+	assert.Equal(t, "echo: hello\n", gotLine)
+	assert.Equal(t, testBody, string(gotPlainBody))
+	s.Mock.AssertNumberOfRequests(t, http.MethodGet, "/ws", 2)
+}